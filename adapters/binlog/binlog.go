@@ -0,0 +1,117 @@
+// Package binlog implements an adapter that appends the log entries to a
+// binary file with a time index, and a Reader that seeks to a time
+// without reading the whole file.
+//
+// The file starts with the 8 bytes "NLOGBIN1" followed by records, each
+// one made of a 4 bytes magic, the 4 bytes big endian length of the
+// payload and the payload. Entry records ("\xff\xfeEN") carry the JSON
+// encoding of the entry. Every indexInterval entries an index record
+// ("\xff\xfeIX") is written with the time of the last entry, in big endian
+// unix nanoseconds. The magic bytes are never found in JSON, so a reader
+// can find the next index record from any position of the file.
+//
+// Entries are written with a single write call to a file opened with
+// O_APPEND, the index is kept per process. Entries larger than 16 MiB once
+// encoded are not written.
+package binlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nuveo/log"
+)
+
+const (
+	header       = "NLOGBIN1"
+	entryMagic   = "\xff\xfeEN"
+	indexMagic   = "\xff\xfeIX"
+	recordHeader = 8
+	indexLen     = recordHeader + 8
+	maxRecord    = 16 << 20
+)
+
+var (
+	counts = make(map[string]int)
+	lock   = sync.Mutex{}
+)
+
+func init() {
+	log.AddAdapter("binlog", log.AdapterPod{
+		Adapter: binlogWrite,
+		Config: map[string]interface{}{
+			"fileName":      "log.bin",
+			"indexInterval": 1024,
+		},
+		Check: checkFile,
+	})
+}
+
+func binlogWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		fmt.Println("error try to encode entry", err)
+		return
+	}
+	if len(payload) > maxRecord {
+		fmt.Println("error try to write entry: entry too large", len(payload))
+		return
+	}
+	fileName := config["fileName"].(string)
+	interval, _ := config["indexInterval"].(int)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Println("error try to open file", err)
+		return
+	}
+	defer f.Close()
+
+	var b []byte
+	if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+		b = append(b, header...)
+	}
+	b = appendRecord(b, entryMagic, payload)
+	counts[fileName]++
+	if interval > 0 && counts[fileName]%interval == 0 {
+		var t [8]byte
+		binary.BigEndian.PutUint64(t[:], uint64(e.Time.UnixNano()))
+		b = appendRecord(b, indexMagic, t[:])
+	}
+
+	if _, err = f.Write(b); err != nil {
+		fmt.Println("error try to write file", err)
+	}
+}
+
+func appendRecord(b []byte, magic string, payload []byte) []byte {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(payload)))
+	b = append(b, magic...)
+	b = append(b, l[:]...)
+	return append(b, payload...)
+}
+
+// checkFile verifies that the file can be opened for writing
+func checkFile(config map[string]interface{}) error {
+	fileName, ok := config["fileName"].(string)
+	if !ok {
+		return errors.New("fileName not configured")
+	}
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
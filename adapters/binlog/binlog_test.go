@@ -0,0 +1,79 @@
+package binlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestSeekToTime(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.bin")
+	config := map[string]interface{}{"fileName": name, "indexInterval": 10}
+	start := time.Unix(1498405744, 0)
+	for i := 0; i < 1000; i++ {
+		binlogWrite(&log.Entry{
+			Seq:  uint64(i),
+			Time: start.Add(time.Duration(i) * time.Second),
+			Type: log.MessageLog,
+			Out:  log.LineOut,
+			Msg:  []interface{}{"message " + strconv.Itoa(i)},
+		}, config)
+	}
+
+	r, err := Open(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer r.Close()
+
+	for _, i := range []int{0, 1, 9, 10, 11, 555, 999} {
+		if err = r.SeekToTime(start.Add(time.Duration(i) * time.Second)); err != nil {
+			t.Fatal(err.Error())
+		}
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if e.Message() != "message "+strconv.Itoa(i) {
+			t.Fatalf("Error, seek to %d returned %q", i, e.Message())
+		}
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Fatalf("Error, expected EOF, got %v", err)
+	}
+	if err = r.SeekToTime(start.Add(time.Hour)); err != io.EOF {
+		t.Fatalf("Error, expected EOF after the last entry, got %v", err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.txt")
+	if err := os.WriteFile(name, []byte("2017/06/25 12:49:04 [msg] text\n"), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := Open(name); err != ErrFormat {
+		t.Fatalf("Error, expected ErrFormat, got %v", err)
+	}
+}
+
+func TestCorruptLength(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.bin")
+	b := appendRecord([]byte(header), entryMagic, []byte(`{}`))
+	b[len(header)+4] = 0xff
+	if err := os.WriteFile(name, b, 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	r, err := Open(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer r.Close()
+	if _, err = r.Next(); err != ErrFormat {
+		t.Fatalf("Error, expected ErrFormat, got %v", err)
+	}
+}
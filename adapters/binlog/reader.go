@@ -0,0 +1,170 @@
+package binlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// ErrFormat is returned when the file is not a binlog file or a record is
+// corrupted.
+var ErrFormat = errors.New("binlog: invalid format")
+
+// Reader reads the entries of a binlog file
+type Reader struct {
+	f       *os.File
+	r       *bufio.Reader
+	size    int64
+	pending *log.Entry
+}
+
+// Open opens the binlog file name for reading
+func Open(name string) (*Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &Reader{f: f, r: bufio.NewReader(f), size: fi.Size()}
+	h := make([]byte, len(header))
+	if _, err = io.ReadFull(r.r, h); err != nil || string(h) != header {
+		f.Close()
+		return nil, ErrFormat
+	}
+	return r, nil
+}
+
+// Close closes the file
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
+
+// Next returns the next entry, io.EOF at the end of the file
+func (r *Reader) Next() (*log.Entry, error) {
+	if e := r.pending; e != nil {
+		r.pending = nil
+		return e, nil
+	}
+	for {
+		magic, payload, err := readRecord(r.r, r.size)
+		if err != nil {
+			return nil, err
+		}
+		if magic == indexMagic {
+			continue
+		}
+		e := &log.Entry{}
+		if err = e.UnmarshalJSON(payload); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+}
+
+// SeekToTime positions the reader at the first entry with a time equal or
+// after t, by a binary search over the index records; only the entries
+// after the last index before t are read. Entries are expected in time
+// order, as appended by the adapter.
+func (r *Reader) SeekToTime(t time.Time) error {
+	start := int64(len(header))
+	lo, hi := start, r.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		off, it, err := r.findIndex(mid)
+		if err != nil {
+			return err
+		}
+		if off < 0 || !it.Before(t) {
+			hi = mid
+			continue
+		}
+		start = off + indexLen
+		lo = off + 1
+	}
+
+	if _, err := r.f.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	r.r.Reset(r.f)
+	r.pending = nil
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if !e.Time.Before(t) {
+			r.pending = e
+			return nil
+		}
+	}
+}
+
+// findIndex returns the offset and the time of the first index record
+// found at or after off, offset -1 if there is none.
+func (r *Reader) findIndex(off int64) (int64, time.Time, error) {
+	sr := bufio.NewReader(io.NewSectionReader(r.f, off, r.size-off))
+	pos := off
+	for {
+		b, err := sr.Peek(indexLen + 4)
+		if len(b) < indexLen {
+			return -1, time.Time{}, nil
+		}
+		if string(b[:4]) == indexMagic && binary.BigEndian.Uint32(b[4:8]) == 8 &&
+			(len(b) == indexLen || validMagic(b[indexLen:])) {
+			t := time.Unix(0, int64(binary.BigEndian.Uint64(b[8:indexLen])))
+			return pos, t, nil
+		}
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return -1, time.Time{}, err
+		}
+		// skip to the next possible magic
+		i := bytes.IndexByte(b[1:], indexMagic[0])
+		if i < 0 {
+			i = len(b) - 1
+		}
+		if _, err = sr.Discard(i + 1); err != nil {
+			return -1, time.Time{}, nil
+		}
+		pos += int64(i + 1)
+	}
+}
+
+func validMagic(b []byte) bool {
+	m := string(b[:4])
+	return m == entryMagic || m == indexMagic
+}
+
+// readRecord reads the next record, the length is checked against the
+// maximum record size and the file size before any allocation.
+func readRecord(r io.Reader, size int64) (string, []byte, error) {
+	var h [recordHeader]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", nil, ErrFormat
+		}
+		return "", nil, err
+	}
+	magic := string(h[:4])
+	if magic != entryMagic && magic != indexMagic {
+		return "", nil, ErrFormat
+	}
+	n := binary.BigEndian.Uint32(h[4:])
+	if n > maxRecord || int64(n) > size || (magic == indexMagic && n != 8) {
+		return "", nil, ErrFormat
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return "", nil, ErrFormat
+	}
+	return magic, payload, nil
+}
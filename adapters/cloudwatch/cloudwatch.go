@@ -0,0 +1,387 @@
+// Package cloudwatch implements an adapter that sends the entries to AWS
+// CloudWatch Logs, as JSON formatted by log.JSONFormatter so Logs
+// Insights discovers their fields.
+//
+// The "group" config is the log group, the name of the program by
+// default, and "stream" the log stream, the host name by default, both
+// are created if they don't exist. "region" is the region of the service,
+// AWS_REGION or AWS_DEFAULT_REGION by default, us-east-1 without them, and
+// "endpoint" replaces the endpoint of the region, e.g. for a VPC endpoint.
+//
+// The credentials are looked up like the AWS SDKs do: in the environment
+// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN), in the
+// "profile" of the shared credentials file (AWS_PROFILE or default), in
+// the role of the ECS task and in the role of the EC2 instance. The
+// temporary credentials are refreshed before they expire.
+//
+// The entries are sent in batches after "interval" (a time.Duration, 5
+// seconds by default) or when a batch reaches the limits of PutLogEvents,
+// 10000 events or 1 MB, the messages longer than the 256 KB of an event
+// are truncated. log.Flush sends the batch waiting right away. A failed
+// batch is sent again with the next one, keeping at most "bufferSize"
+// entries (an int, 10000 by default), and its error is returned by the
+// next write or log.Flush. The sequence tokens of the streams are kept
+// and corrected when the service reports another one.
+package cloudwatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// Limits of PutLogEvents
+const (
+	maxBatchEvents = 10000
+	maxBatchBytes  = 1048576
+	// eventOverhead is added to the size of each message
+	eventOverhead = 26
+	maxEventBytes = 262144 - eventOverhead
+	maxBatchSpan  = 24 * time.Hour
+)
+
+type event struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+func (e event) size() int {
+	return len(e.Message) + eventOverhead
+}
+
+// stream batches the events of a log stream
+type stream struct {
+	region, endpoint, group, name, profile string
+
+	lock    sync.Mutex
+	events  []event
+	bytes   int
+	timer   *time.Timer
+	err     error
+	dropped int
+	// sending serializes the requests and guards the fields below
+	sending sync.Mutex
+	token   string
+}
+
+var (
+	streams = make(map[string]*stream)
+	lock    = sync.Mutex{}
+	client  = &http.Client{Timeout: 30 * time.Second}
+	now     = time.Now
+)
+
+func init() {
+	log.AddAdapter("cloudwatch", log.AdapterPod{
+		Write:  cloudwatchWrite,
+		Config: map[string]interface{}{},
+		Flush:  flushStreams,
+		Close:  flushStreams,
+		Check:  checkStream,
+	})
+}
+
+func stringConfig(config map[string]interface{}, name, def string) string {
+	if s, ok := config[name].(string); ok && s != "" {
+		return s
+	}
+	return def
+}
+
+func intConfig(config map[string]interface{}, name string, def int) int {
+	if n, ok := config[name].(int); ok && n > 0 {
+		return n
+	}
+	return def
+}
+
+// getStream returns the stream of config
+func getStream(config map[string]interface{}) *stream {
+	host, _ := os.Hostname()
+	region := stringConfig(config, "region", os.Getenv("AWS_REGION"))
+	if region == "" {
+		region = stringConfig(nil, "", os.Getenv("AWS_DEFAULT_REGION"))
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	s := &stream{
+		region:   region,
+		endpoint: stringConfig(config, "endpoint", "https://logs."+region+".amazonaws.com"),
+		group:    stringConfig(config, "group", filepath.Base(os.Args[0])),
+		name:     stringConfig(config, "stream", host),
+		profile:  stringConfig(config, "profile", os.Getenv("AWS_PROFILE")),
+	}
+	if s.profile == "" {
+		s.profile = "default"
+	}
+	key := s.endpoint + " " + s.group + " " + s.name
+	lock.Lock()
+	defer lock.Unlock()
+	if cur, ok := streams[key]; ok {
+		return cur
+	}
+	streams[key] = s
+	return s
+}
+
+func cloudwatchWrite(e *log.Entry, config map[string]interface{}) error {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return nil
+	}
+	msg := strings.TrimSuffix(log.JSONFormatter(e), "\n")
+	if len(msg) > maxEventBytes {
+		msg = msg[:maxEventBytes]
+	}
+	ev := event{Timestamp: e.Time.UnixNano() / int64(time.Millisecond), Message: msg}
+	interval, ok := config["interval"].(time.Duration)
+	if !ok {
+		interval = 5 * time.Second
+	}
+
+	s := getStream(config)
+	s.lock.Lock()
+	if len(s.events) >= intConfig(config, "bufferSize", 10000) {
+		s.bytes -= s.events[0].size()
+		s.events = s.events[1:]
+		s.dropped++
+	}
+	s.events = append(s.events, ev)
+	s.bytes += ev.size()
+	full := len(s.events) >= maxBatchEvents || s.bytes >= maxBatchBytes
+	if s.timer == nil && !full {
+		s.timer = time.AfterFunc(interval, s.flushLater)
+	}
+	err := s.err
+	s.err = nil
+	s.lock.Unlock()
+	if full {
+		if e := s.flush(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// flush sends the events waiting, the ones not sent are kept for the next
+// flush if it fails
+func (s *stream) flush() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.lock.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	events, dropped := s.events, s.dropped
+	s.events, s.bytes, s.dropped = nil, 0, 0
+	s.lock.Unlock()
+
+	// the events of a batch must be in chronological order
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+	for len(events) > 0 {
+		n := batchLen(events)
+		if err := s.put(events[:n]); err != nil {
+			s.lock.Lock()
+			s.events = append(events, s.events...)
+			for _, e := range events {
+				s.bytes += e.size()
+			}
+			s.dropped += dropped
+			s.lock.Unlock()
+			return err
+		}
+		events = events[n:]
+	}
+	if dropped > 0 {
+		return fmt.Errorf("cloudwatch: %s/%s: %d entries dropped, the buffer was full", s.group, s.name, dropped)
+	}
+	return nil
+}
+
+// flushLater flushes the stream from its timer, the error is returned by
+// the next write
+func (s *stream) flushLater() {
+	if err := s.flush(); err != nil {
+		s.lock.Lock()
+		s.err = err
+		s.lock.Unlock()
+	}
+}
+
+// batchLen returns how many of the sorted events fit in a batch
+func batchLen(events []event) int {
+	bytes := 0
+	for i, e := range events {
+		bytes += e.size()
+		if i == maxBatchEvents || bytes > maxBatchBytes ||
+			time.Duration(e.Timestamp-events[0].Timestamp)*time.Millisecond > maxBatchSpan {
+			return i
+		}
+	}
+	return len(events)
+}
+
+// apiError is the error response of the service
+type apiError struct {
+	Type          string `json:"__type"`
+	Message       string `json:"message"`
+	ExpectedToken string `json:"expectedSequenceToken"`
+}
+
+func (e *apiError) Error() string {
+	return "cloudwatch: " + e.Type + ": " + e.Message
+}
+
+// call sends a request of the action, decoding the response to out
+func (s *stream) call(action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	c, err := getCredentials(s.profile)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	sign(req, body, c, s.region, "logs", now())
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &apiError{}
+		if json.Unmarshal(b, apiErr) != nil || apiErr.Type == "" {
+			return errors.New("cloudwatch: " + action + ": " + resp.Status)
+		}
+		// e.g. com.amazonaws.logs#ResourceNotFoundException
+		apiErr.Type = apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
+
+// create creates the log group and the log stream if they don't exist
+func (s *stream) create() error {
+	for _, r := range []struct {
+		action string
+		in     map[string]string
+	}{
+		{"CreateLogGroup", map[string]string{"logGroupName": s.group}},
+		{"CreateLogStream", map[string]string{"logGroupName": s.group, "logStreamName": s.name}},
+	} {
+		err := s.call(r.action, r.in, nil)
+		var apiErr *apiError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.Type == "ResourceAlreadyExistsException") {
+			return err
+		}
+	}
+	return nil
+}
+
+type putRequest struct {
+	Group  string  `json:"logGroupName"`
+	Stream string  `json:"logStreamName"`
+	Events []event `json:"logEvents"`
+	Token  string  `json:"sequenceToken,omitempty"`
+}
+
+type putResponse struct {
+	NextToken string `json:"nextSequenceToken"`
+	Rejected  *struct {
+		TooNew  *int `json:"tooNewLogEventStartIndex"`
+		TooOld  *int `json:"tooOldLogEventEndIndex"`
+		Expired *int `json:"expiredLogEventEndIndex"`
+	} `json:"rejectedLogEventsInfo"`
+}
+
+// put sends the batch, creating the stream and correcting the sequence
+// token when needed
+func (s *stream) put(events []event) error {
+	var err error
+	for try := 0; try < 3; try++ {
+		var resp putResponse
+		err = s.call("PutLogEvents", putRequest{s.group, s.name, events, s.token}, &resp)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			switch apiErr.Type {
+			case "ResourceNotFoundException":
+				if err = s.create(); err != nil {
+					return err
+				}
+				s.token = ""
+				continue
+			case "InvalidSequenceTokenException":
+				s.token = apiErr.ExpectedToken
+				continue
+			case "DataAlreadyAcceptedException":
+				s.token = apiErr.ExpectedToken
+				return nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		s.token = resp.NextToken
+		if r := resp.Rejected; r != nil && (r.TooNew != nil || r.TooOld != nil || r.Expired != nil) {
+			// the rejected events would be rejected again
+			return fmt.Errorf("cloudwatch: %s/%s: events rejected, too old, too new or expired", s.group, s.name)
+		}
+		return nil
+	}
+	return err
+}
+
+func allStreams() []*stream {
+	lock.Lock()
+	defer lock.Unlock()
+	list := make([]*stream, 0, len(streams))
+	for _, s := range streams {
+		list = append(list, s)
+	}
+	return list
+}
+
+// flushStreams sends the events waiting in every stream
+func flushStreams(config map[string]interface{}) error {
+	var err error
+	for _, s := range allStreams() {
+		if e := s.flush(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// checkStream verifies the credentials and creates the log group and the
+// log stream if they don't exist
+func checkStream(config map[string]interface{}) error {
+	s := getStream(config)
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	return s.create()
+}
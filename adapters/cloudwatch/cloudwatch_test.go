@@ -0,0 +1,122 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// fakeService implements the actions of CloudWatch Logs used by the
+// adapter for a single stream
+type fakeService struct {
+	lock    sync.Mutex
+	created []string
+	exists  bool
+	token   int
+	batches [][]event
+}
+
+func (f *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	fail := func(kind, extra string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"com.amazonaws.logs#` + kind + `","message":"failed"` + extra + `}`))
+	}
+	action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+	switch action {
+	case "CreateLogGroup", "CreateLogStream":
+		f.created = append(f.created, action)
+		f.exists = true
+		_, _ = w.Write([]byte(`{}`))
+	case "PutLogEvents":
+		var req putRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case !f.exists:
+			fail("ResourceNotFoundException", "")
+		case f.token > 0 && req.Token != strconv.Itoa(f.token):
+			fail("InvalidSequenceTokenException", `,"expectedSequenceToken":"`+strconv.Itoa(f.token)+`"`)
+		default:
+			f.token++
+			f.batches = append(f.batches, req.Events)
+			_, _ = w.Write([]byte(`{"nextSequenceToken":"` + strconv.Itoa(f.token) + `"}`))
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestCloudWatch(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	f := &fakeService{}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	config := map[string]interface{}{"endpoint": srv.URL, "group": "api", "stream": "host", "interval": time.Hour}
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		e := &log.Entry{Time: base.Add(time.Duration(2-i) * time.Second), Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"msg", i}}
+		if err := cloudwatchWrite(e, config); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if err := flushStreams(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Join(f.created, " ") != "CreateLogGroup CreateLogStream" {
+		t.Errorf("expected the group and the stream created, got %v", f.created)
+	}
+	if len(f.batches) != 1 || len(f.batches[0]) != 3 {
+		t.Fatalf("expected a batch of 3 events, got %v", f.batches)
+	}
+	for i, e := range f.batches[0] {
+		if e.Timestamp != base.Add(time.Duration(i)*time.Second).UnixNano()/1e6 || !strings.Contains(e.Message, `"message":"msg`+strconv.Itoa(2-i)+`"`) {
+			t.Errorf("unexpected event %d %+v", i, e)
+		}
+	}
+
+	// another producer used the stream
+	getStream(config).token = "stale"
+	if err := cloudwatchWrite(&log.Entry{Time: base, Type: log.ErrorLog, Msg: []interface{}{"again"}}, config); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := flushStreams(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(f.batches) != 2 || getStream(config).token != "2" {
+		t.Errorf("expected the sequence token corrected, got %d batches and token %q", len(f.batches), getStream(config).token)
+	}
+}
+
+func TestBatchLen(t *testing.T) {
+	events := make([]event, maxBatchEvents+5)
+	if n := batchLen(events); n != maxBatchEvents {
+		t.Errorf("expected %d events, got %d", maxBatchEvents, n)
+	}
+	big := strings.Repeat("x", maxEventBytes)
+	events = []event{{Message: big}, {Message: big}, {Message: big}, {Message: big}, {Message: big}}
+	if n := batchLen(events); n != 4 {
+		t.Errorf("expected 4 events under 1 MB, got %d", n)
+	}
+	day := int64(24 * time.Hour / time.Millisecond)
+	events = []event{{Timestamp: 0}, {Timestamp: day}, {Timestamp: day + 1}}
+	if n := batchLen(events); n != 2 {
+		t.Errorf("expected 2 events in 24 hours, got %d", n)
+	}
+}
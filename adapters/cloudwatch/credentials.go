@@ -0,0 +1,210 @@
+package cloudwatch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentials of AWS, the Expiration is zero if they don't expire
+type credentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string `json:"Token"`
+	Expiration      time.Time
+}
+
+// Endpoints of the credentials of ECS tasks and EC2 instances, variables
+// so the tests can replace them
+var (
+	containerHost = "http://169.254.170.2"
+	imdsHost      = "http://169.254.169.254"
+)
+
+var (
+	credsLock = sync.Mutex{}
+	cached    = make(map[string]credentials)
+	metadata  = &http.Client{Timeout: 2 * time.Second}
+)
+
+// getCredentials returns the credentials of the profile, cached until 5
+// minutes before they expire. They are looked up in order in the
+// environment, in the shared credentials file, in the endpoint of the ECS
+// task and in the metadata of the EC2 instance.
+func getCredentials(profile string) (credentials, error) {
+	credsLock.Lock()
+	defer credsLock.Unlock()
+	if c, ok := cached[profile]; ok && (c.Expiration.IsZero() || time.Until(c.Expiration) > 5*time.Minute) {
+		return c, nil
+	}
+	providers := []func(string) (credentials, error){envCredentials, fileCredentials, containerCredentials, instanceCredentials}
+	var errs []string
+	for _, p := range providers {
+		c, err := p(profile)
+		if err == nil {
+			cached[profile] = c
+			return c, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return credentials{}, errors.New("cloudwatch: no credentials: " + strings.Join(errs, ", "))
+}
+
+func envCredentials(profile string) (credentials, error) {
+	c := credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return c, errors.New("AWS_ACCESS_KEY_ID not set")
+	}
+	return c, nil
+}
+
+// fileCredentials reads the profile of AWS_SHARED_CREDENTIALS_FILE or
+// ~/.aws/credentials
+func fileCredentials(profile string) (credentials, error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return credentials{}, err
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return credentials{}, err
+	}
+	defer f.Close()
+
+	var c credentials
+	section := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if section != profile || len(kv) != 2 {
+			continue
+		}
+		v := strings.TrimSpace(kv[1])
+		switch strings.TrimSpace(kv[0]) {
+		case "aws_access_key_id":
+			c.AccessKeyID = v
+		case "aws_secret_access_key":
+			c.SecretAccessKey = v
+		case "aws_session_token":
+			c.SessionToken = v
+		}
+	}
+	if err := s.Err(); err != nil {
+		return c, err
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return c, fmt.Errorf("profile %s not found in %s", profile, path)
+	}
+	return c, nil
+}
+
+// getJSON decodes the credentials returned by the request
+func getJSON(req *http.Request) (credentials, error) {
+	var c credentials
+	resp, err := metadata.Do(req)
+	if err != nil {
+		return c, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return c, errors.New(req.URL.String() + ": " + resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return c, err
+	}
+	if c.AccessKeyID == "" {
+		return c, errors.New(req.URL.String() + ": no credentials")
+	}
+	return c, nil
+}
+
+// containerCredentials requests the credentials of the role of the ECS
+// task
+func containerCredentials(string) (credentials, error) {
+	url := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		url = containerHost + uri
+	}
+	if url == "" {
+		return credentials{}, errors.New("not in a container")
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return credentials{}, err
+	}
+	if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return getJSON(req)
+}
+
+// instanceCredentials requests the credentials of the role of the EC2
+// instance with IMDSv2
+func instanceCredentials(string) (credentials, error) {
+	req, err := http.NewRequest("PUT", imdsHost+"/latest/api/token", nil)
+	if err != nil {
+		return credentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+	token, err := metadataText(req)
+	if err != nil {
+		return credentials{}, err
+	}
+
+	const path = "/latest/meta-data/iam/security-credentials/"
+	if req, err = http.NewRequest("GET", imdsHost+path, nil); err != nil {
+		return credentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", token)
+	role, err := metadataText(req)
+	if err != nil {
+		return credentials{}, err
+	}
+	role = strings.TrimSpace(strings.SplitN(role, "\n", 2)[0])
+
+	if req, err = http.NewRequest("GET", imdsHost+path+role, nil); err != nil {
+		return credentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", token)
+	return getJSON(req)
+}
+
+func metadataText(req *http.Request) (string, error) {
+	resp, err := metadata.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(req.URL.String() + ": " + resp.Status)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,79 @@
+package cloudwatch
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	data := "[default]\naws_access_key_id = AKID1\naws_secret_access_key = s1\n\n" +
+		"# staging\n[staging]\naws_access_key_id=AKID2\naws_secret_access_key=s2\naws_session_token=tok\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", path)
+	c, err := fileCredentials("staging")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if c.AccessKeyID != "AKID2" || c.SecretAccessKey != "s2" || c.SessionToken != "tok" {
+		t.Errorf("unexpected credentials %+v", c)
+	}
+	if _, err := fileCredentials("prod"); err == nil {
+		t.Error("expected an error for a missing profile")
+	}
+}
+
+func TestInstanceCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("token"))
+		case r.Header.Get("X-Aws-Ec2-Metadata-Token") != "token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			_, _ = w.Write([]byte("web-role\n"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/web-role":
+			_, _ = w.Write([]byte(`{"Code":"Success","AccessKeyId":"ASIA","SecretAccessKey":"s","Token":"t","Expiration":"2030-01-02T03:04:05Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	defer func(h string) { imdsHost = h }(imdsHost)
+	imdsHost = srv.URL
+
+	c, err := instanceCredentials("default")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if c.AccessKeyID != "ASIA" || c.SessionToken != "t" || c.Expiration.Year() != 2030 {
+		t.Errorf("unexpected credentials %+v", c)
+	}
+}
+
+func TestContainerCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/credentials/id" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"AccessKeyId":"ASIA","SecretAccessKey":"s","Token":"t","Expiration":"2030-01-02T03:04:05Z"}`))
+	}))
+	defer srv.Close()
+	defer func(h string) { containerHost = h }(containerHost)
+	containerHost = srv.URL
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/id")
+
+	c, err := containerCredentials("default")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if c.AccessKeyID != "ASIA" || c.SecretAccessKey != "s" {
+		t.Errorf("unexpected credentials %+v", c)
+	}
+}
@@ -0,0 +1,38 @@
+// Package eventlog implements an adapter that writes the log messages to
+// the Windows Event Log. The adapter is only registered on Windows.
+//
+// The event source given in the "source" config should be registered
+// (e.g. with New-EventLog) so the Event Viewer can show the messages
+// without complaining about a missing description.
+package eventlog
+
+import "github.com/nuveo/log"
+
+// Event types of ReportEvent
+const (
+	errorType       uint16 = 0x0001
+	warningType     uint16 = 0x0002
+	informationType uint16 = 0x0004
+)
+
+// eventID used for all messages, the text goes in the event strings
+const eventID uint32 = 1
+
+func containsType(m log.MsgType, ts []log.MsgType) bool {
+	for _, t := range ts {
+		if m == t {
+			return true
+		}
+	}
+	return false
+}
+
+func eventType(m log.MsgType) uint16 {
+	switch m {
+	case log.ErrorLog, log.FatalLog, log.PanicLog:
+		return errorType
+	case log.WarningLog:
+		return warningType
+	}
+	return informationType
+}
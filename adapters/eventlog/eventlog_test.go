@@ -0,0 +1,24 @@
+package eventlog
+
+import (
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestEventType(t *testing.T) {
+	testCases := []struct {
+		m        log.MsgType
+		expected uint16
+	}{
+		{log.ErrorLog, errorType},
+		{log.WarningLog, warningType},
+		{log.MessageLog, informationType},
+		{log.DebugLog, informationType},
+	}
+	for _, tc := range testCases {
+		if got := eventType(tc.m); got != tc.expected {
+			t.Errorf("expected %v, but got %v", tc.expected, got)
+		}
+	}
+}
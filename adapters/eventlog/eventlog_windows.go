@@ -0,0 +1,110 @@
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/nuveo/log"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+
+	handles = make(map[string]uintptr)
+	lock    = sync.Mutex{}
+)
+
+func init() {
+	log.AddAdapter("eventlog", log.AdapterPod{
+		Adapter: eventLog,
+		Config: map[string]interface{}{
+			"source":         "logSys",
+			"enableMsgTypes": []log.MsgType{log.WarningLog, log.ErrorLog, log.FatalLog, log.PanicLog},
+		},
+		Close: deregister,
+		Check: checkSource,
+	})
+}
+
+func eventLog(e *log.Entry, config map[string]interface{}) {
+	ts := config["enableMsgTypes"].([]log.MsgType)
+
+	if !containsType(e.Type, ts) {
+		return
+	}
+
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+
+	h, err := handle(config["source"].(string))
+	if err != nil {
+		fmt.Println("error try to open event log", err)
+		return
+	}
+
+	msg, err := syscall.UTF16PtrFromString(e.Message())
+	if err != nil {
+		fmt.Println("error try to encode message", err)
+		return
+	}
+	strs := []*uint16{msg}
+
+	r, _, err := procReportEvent.Call(
+		h,
+		uintptr(eventType(e.Type)),
+		0,
+		uintptr(eventID),
+		0,
+		uintptr(len(strs)),
+		0,
+		uintptr(unsafe.Pointer(&strs[0])),
+		0)
+	if r == 0 {
+		fmt.Println("error try to report event", err)
+	}
+}
+
+func handle(source string) (uintptr, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if h, ok := handles[source]; ok {
+		return h, nil
+	}
+	s, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return 0, err
+	}
+	h, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(s)))
+	if h == 0 {
+		return 0, err
+	}
+	handles[source] = h
+	return h, nil
+}
+
+func deregister(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	for source, h := range handles {
+		_, _, _ = procDeregisterEventSource.Call(h)
+		delete(handles, source)
+	}
+	return nil
+}
+
+// checkSource verifies that the event source can be opened
+func checkSource(config map[string]interface{}) error {
+	source, ok := config["source"].(string)
+	if !ok {
+		return errors.New("source not configured")
+	}
+	_, err := handle(source)
+	return err
+}
@@ -0,0 +1,113 @@
+package file
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// batch of messages waiting to be written to a file, with the rotation
+// of the adapter that queued them
+type batch struct {
+	template string
+	t        time.Time
+	bufs     [][]byte
+	rotation rotation
+	timer    *time.Timer
+}
+
+var (
+	batches   = make(map[string]*batch)
+	batchLock = sync.Mutex{}
+	// batchErr is the error of the last batch written by its timer,
+	// returned by the next flush
+	batchErr error
+)
+
+// queue adds b to the batch of the file and returns the messages to be
+// written, nil while the batch is not full and flush is false. The batch
+// is written by a timer after interval if it doesn't fill.
+func queue(template string, t time.Time, b []byte, size int, flush bool, interval time.Duration, r rotation) [][]byte {
+	name := expandPath(template, t.UTC())
+	batchLock.Lock()
+	defer batchLock.Unlock()
+	q, ok := batches[name]
+	if !ok {
+		q = &batch{template: template, rotation: r}
+		batches[name] = q
+		q.timer = time.AfterFunc(interval, func() { writeBatch(name, q) })
+	}
+	q.t = t
+	q.bufs = append(q.bufs, b)
+	if len(q.bufs) < size && !flush {
+		return nil
+	}
+	q.timer.Stop()
+	delete(batches, name)
+	return q.bufs
+}
+
+// writeBatch writes the batch q of the file name if it is still waiting
+func writeBatch(name string, q *batch) {
+	batchLock.Lock()
+	if batches[name] != q {
+		batchLock.Unlock()
+		return
+	}
+	delete(batches, name)
+	batchLock.Unlock()
+	if err := writeFile(q.template, q.t, q.bufs, false, q.rotation); err != nil {
+		batchLock.Lock()
+		batchErr = err
+		batchLock.Unlock()
+	}
+}
+
+// flushBatches writes the messages waiting in every batch, with the
+// rotation of the adapter that queued them. It returns the first error,
+// or the error of a batch written by its timer since the last flush.
+func flushBatches(config map[string]interface{}) error {
+	batchLock.Lock()
+	pending := batches
+	batches = make(map[string]*batch)
+	err := batchErr
+	batchErr = nil
+	batchLock.Unlock()
+
+	for _, q := range pending {
+		q.timer.Stop()
+		if e := writeFile(q.template, q.t, q.bufs, false, q.rotation); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// flushFile writes the waiting messages and syncs the current file
+func flushFile(config map[string]interface{}) error {
+	if err := flushBatches(config); err != nil {
+		return err
+	}
+	return syncFile(config)
+}
+
+func joinBuffers(bufs [][]byte) []byte {
+	if len(bufs) == 1 {
+		return bufs[0]
+	}
+	n := 0
+	for _, b := range bufs {
+		n += len(b)
+	}
+	out := make([]byte, 0, n)
+	for _, b := range bufs {
+		out = append(out, b...)
+	}
+	return out
+}
+
+// writeJoined writes bufs to f copied to a single buffer
+func writeJoined(f *os.File, bufs [][]byte) error {
+	_, err := f.Write(joinBuffers(bufs))
+	return err
+}
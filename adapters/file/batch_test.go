@@ -0,0 +1,102 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestBatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	config := map[string]interface{}{"fileName": name, "batchSize": 3, "sync": SyncError}
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	write := func(m log.MsgType, msg string) {
+		fileWrite(&log.Entry{Time: now, Type: m, Out: log.LineOut, Msg: []interface{}{msg}}, config)
+	}
+	read := func() string {
+		b, err := ioutil.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err.Error())
+		}
+		return string(b)
+	}
+
+	write(log.MessageLog, "one")
+	write(log.MessageLog, "two")
+	if got := read(); got != "" {
+		t.Fatalf("expected nothing written, but got %q", got)
+	}
+	write(log.MessageLog, "three")
+	expected := "2017/07/01 00:00:00.000 [msg] one\n2017/07/01 00:00:00.000 [msg] two\n2017/07/01 00:00:00.000 [msg] three\n"
+	if got := read(); got != expected {
+		t.Fatalf("expected %q, but got %q", expected, got)
+	}
+
+	write(log.MessageLog, "four")
+	write(log.ErrorLog, "five")
+	expected += "2017/07/01 00:00:00.000 [msg] four\n2017/07/01 00:00:00.000 [error] five\n"
+	if got := read(); got != expected {
+		t.Fatalf("expected %q, but got %q", expected, got)
+	}
+
+	write(log.MessageLog, "six")
+	if err := flushFile(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	expected += "2017/07/01 00:00:00.000 [msg] six\n"
+	if got := read(); got != expected {
+		t.Fatalf("expected %q, but got %q", expected, got)
+	}
+}
+
+func TestBatchInterval(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	config := map[string]interface{}{"fileName": name, "batchSize": 100, "batchInterval": 20 * time.Millisecond}
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	fileWrite(&log.Entry{Time: now, Type: log.MessageLog, Out: log.LineOut, Msg: []interface{}{"one"}}, config)
+
+	expected := "2017/07/01 00:00:00.000 [msg] one\n"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, _ := ioutil.ReadFile(name)
+		if string(b) == expected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %q written by the timer, but got %q", expected, string(b))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBatchRotation(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	other := filepath.Join(dir, "other.log")
+	rotating := map[string]interface{}{"fileName": name, "batchSize": 100, "maxSize": 40, "batchInterval": time.Hour}
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	if err := ioutil.WriteFile(name, []byte("2017/07/01 00:00:00 [msg] old message\n"), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	fileWrite(&log.Entry{Time: now, Type: log.MessageLog, Out: log.LineOut, Msg: []interface{}{"new message"}}, rotating)
+
+	// flushed by an adapter without rotation
+	if err := flushBatches(map[string]interface{}{"fileName": other}); err != nil {
+		t.Fatal(err.Error())
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected the file and a backup, but got %d files", len(files))
+	}
+	if b, _ := ioutil.ReadFile(name); string(b) != "2017/07/01 00:00:00.000 [msg] new message\n" {
+		t.Fatalf("expected the file rotated, but got %q", string(b))
+	}
+}
@@ -0,0 +1,90 @@
+package file
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// checksumMark starts the line that ends a segment with its checksum; the
+// lines of the message that start with it are escaped by doubling it.
+const (
+	checksumMark = "\x1e"
+	checksumTag  = checksumMark + "crc32="
+)
+
+// appendChecksum returns the segment s followed by its checksum line.
+func appendChecksum(s string) string {
+	sum := crc32.ChecksumIEEE([]byte(s))
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, checksumMark) {
+			lines[i] = checksumMark + l
+		}
+	}
+	return fmt.Sprintf("%s\n%s%08x\n", strings.Join(lines, "\n"), checksumTag, sum)
+}
+
+// parseChecksum returns the checksum of a checksum line.
+func parseChecksum(line string) (uint32, bool) {
+	if !strings.HasPrefix(line, checksumTag) || len(line) != len(checksumTag)+8 {
+		return 0, false
+	}
+	sum, err := strconv.ParseUint(line[len(checksumTag):], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(sum), true
+}
+
+// ReadChecked reads a file written with the "checksum" option and calls
+// fn with the text of every valid message, without the checksum. The lines
+// that don't belong to a valid message, e.g. left by a partial write
+// before a crash, are skipped and counted in corrupted.
+func ReadChecked(name string, fn func(msg string)) (corrupted int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var pending []string
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimSuffix(line, "\n")
+		if sum, ok := parseChecksum(line); ok {
+			if len(pending) == 0 {
+				corrupted++
+			} else {
+				// drop the leading lines of a previous damaged
+				// segment until the checksum matches
+				for i := range pending {
+					msg := strings.Join(pending[i:], "\n")
+					if crc32.ChecksumIEEE([]byte(msg)) == sum {
+						fn(msg)
+						corrupted += i
+						pending = pending[:0]
+						break
+					}
+				}
+				if len(pending) > 0 {
+					corrupted += len(pending) + 1
+					pending = pending[:0]
+				}
+			}
+		} else if line != "" || err == nil {
+			pending = append(pending, strings.TrimPrefix(line, checksumMark))
+		}
+		if err == io.EOF {
+			return corrupted + len(pending), nil
+		}
+		if err != nil {
+			return corrupted, err
+		}
+	}
+}
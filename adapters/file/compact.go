@@ -0,0 +1,153 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// record is a message read back from a log file, with its continuation
+// lines.
+type record struct {
+	time time.Time
+	text string
+}
+
+// Compact merges the log files srcs, plain or gzip compressed, into the
+// archive dst. The messages are sorted by time and the ones repeated in
+// more than one source, e.g. from overlapping copies of the same file,
+// are written only once: a message is written as many times as it is
+// found in the source with most of it, the messages repeated within a
+// file are kept. The archive is gzip compressed when dst ends with ".gz".
+//
+// The messages must start with a timestamp in log.TimeFormat, as written
+// by the adapter, or in log.SecondTimeFormat, as written by the older
+// versions; lines without a timestamp are kept with the message
+// before them. All messages are loaded in memory.
+func Compact(dst string, srcs ...string) error {
+	var records []record
+	// most is the count of each message in the source with most of it
+	most := make(map[string]int)
+	for _, src := range srcs {
+		r, err := readRecords(src)
+		if err != nil {
+			return err
+		}
+		count := make(map[string]int, len(r))
+		for _, rec := range r {
+			if count[rec.text]++; count[rec.text] > most[rec.text] {
+				most[rec.text] = count[rec.text]
+			}
+		}
+		records = append(records, r...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].time.Before(records[j].time)
+	})
+
+	written := make(map[string]int, len(most))
+	unique := records[:0]
+	for _, r := range records {
+		if written[r.text] < most[r.text] {
+			written[r.text]++
+			unique = append(unique, r)
+		}
+	}
+	return writeRecords(dst, unique)
+}
+
+// writeRecords creates the file name with the records, gzip compressed
+// when name ends with ".gz".
+func writeRecords(name string, records []record) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if strings.HasSuffix(name, ".gz") {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	bw := bufio.NewWriter(w)
+	for _, r := range records {
+		if _, err = bw.WriteString(r.text); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if zw != nil && err == nil {
+		err = zw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// lineTime parses the timestamp at the start of line
+func lineTime(line string) (time.Time, bool) {
+	for _, f := range []string{log.TimeFormat, log.SecondTimeFormat} {
+		if len(line) >= len(f) {
+			if t, err := time.Parse(f, line[:len(f)]); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// readRecords reads the messages of the file name
+func readRecords(name string) ([]record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseRecords(f)
+}
+
+// parseRecords reads the messages of f, decompressing it if it starts
+// with the gzip magic number.
+func parseRecords(f io.Reader) (records []record, err error) {
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	lr := bufio.NewReader(r)
+	for {
+		line, err := lr.ReadString('\n')
+		if line != "" {
+			if t, ok := lineTime(line); ok {
+				records = append(records, record{time: t, text: line})
+				continue
+			}
+			if len(records) == 0 {
+				records = append(records, record{text: line})
+			} else {
+				records[len(records)-1].text += line
+			}
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package file
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "app-01.log")
+	b := filepath.Join(dir, "app-02.log.gz")
+	dst := filepath.Join(dir, "archive.log.gz")
+
+	err := ioutil.WriteFile(a, []byte("2017/07/01 00:00:02 [msg] second\n"+
+		"2017/07/01 00:00:00 [error] first\ncontinuation\n"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f, err := os.Create(b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write([]byte("2017/07/01 00:00:01 [warning] middle\n" +
+		"2017/07/01 00:00:02 [msg] second\n"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	zw.Close()
+	f.Close()
+
+	if err = Compact(dst, a, b); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	f, err = os.Open(dst)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := "2017/07/01 00:00:00 [error] first\ncontinuation\n" +
+		"2017/07/01 00:00:01 [warning] middle\n" +
+		"2017/07/01 00:00:02 [msg] second\n"
+	if string(got) != expected {
+		t.Fatalf("expected %q, but got %q", expected, string(got))
+	}
+
+	if err = Compact(dst, filepath.Join(dir, "missing.log")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestCompactRepeated(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "app-01.log")
+	b := filepath.Join(dir, "app-02.log")
+	dst := filepath.Join(dir, "archive.log")
+
+	repeated := "2017/07/01 00:00:00 [error] retry failed\n"
+	if err := ioutil.WriteFile(a, []byte(repeated+repeated+"2017/07/01 00:00:01 [msg] done\n"), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	// a copy overlapping one of the repeated messages
+	if err := ioutil.WriteFile(b, []byte(repeated+"2017/07/01 00:00:02 [msg] next\n"), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := Compact(dst, a, b); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := repeated + repeated + "2017/07/01 00:00:01 [msg] done\n" + "2017/07/01 00:00:02 [msg] next\n"
+	if string(got) != expected {
+		t.Fatalf("expected %q, but got %q", expected, string(got))
+	}
+}
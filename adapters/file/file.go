@@ -1,62 +1,189 @@
+// Package file implements an adapter that appends the log messages to a
+// file.
+//
+// The file name can be a template with the verbs %Y (year), %m (month),
+// %d (day), %H (hour), %M (minute) and %% (a literal %), expanded with the
+// UTC time of each message, e.g. "/var/log/app/%Y/%m/app-%d.log". The
+// messages are written to a new file, and the missing directories are
+// created, as soon as the date changes.
+//
+// Several processes can share the same file: the file is opened with
+// O_APPEND, every message is written with a single write call and, on
+// unix systems, the write holds an exclusive advisory lock (flock) on the
+// file, so lines from different processes are never interleaved. The
+// advisory lock is only honored by programs that also use it and may not
+// work on network file systems.
+//
+// With the "checksum" option every message is followed by a line with the
+// CRC-32 of its text, started by the "\x1e" mark (doubled at the start of
+// the lines of the message), so ReadChecked can detect and skip the
+// messages corrupted by a crash or a disk failure.
+//
+// The "sync" option sets when the file is synced to the disk, one of
+// SyncNever, SyncInterval, SyncError or SyncEntry; with SyncInterval the
+// file is synced by the first write after "syncInterval" (a time.Duration,
+// one second by default) and by log.Flush.
+//
+// With "batchSize" (an int) the messages are written together, with a
+// single write call, when that many are waiting, "batchInterval" (a
+// time.Duration, one second by default) after the first one, by a message
+// synced by the "sync" option and by log.Flush. Built with the writev tag
+// on Linux the batch is written with writev(2), without copying it to a
+// single buffer.
+//
+// With "maxSize" (an int, in bytes) the file is renamed to a backup with
+// the time of the rotation in its name, e.g. app-20240102T150405.000.log,
+// before a message would make it larger than maxSize. "maxBackups" (an
+// int) and "maxAge" (a time.Duration) limit the backups kept and with
+// "compress" the backups are compressed with gzip. The rotation is done
+// while holding the lock of the file, so the processes sharing the file
+// rotate it once, but not on Windows, where open files can't be renamed.
 package file
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/nuveo/log"
 )
 
-var now = time.Now
-
 func init() {
 	log.AddAdapter("file", log.AdapterPod{
 		Adapter: fileWrite,
 		Config:  map[string]interface{}{"fileName": "file.log"},
+		Flush:   flushFile,
+		Close:   flushBatches,
+		Check:   checkFile,
 	})
 }
 
-func fileWrite(m log.MsgType, o log.OutType, config map[string]interface{}, msg ...interface{}) {
-	if m == log.DebugLog && !log.DebugMode {
+func fileWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
 		return
 	}
 
-	var debugInfo, lineBreak, output string
+	var debugInfo, lineBreak string
 
-	if log.DebugMode {
-		_, fn, line, _ := runtime.Caller(5)
-		fn = filepath.Base(fn)
-		debugInfo = fmt.Sprintf("%s:%d ", fn, line)
+	if e.ShowCaller() {
+		debugInfo = e.Caller + " "
+		if e.Caller == "" {
+			_, fn, line, _ := runtime.Caller(5)
+			fn = filepath.Base(fn)
+			debugInfo = fmt.Sprintf("%s:%d ", fn, line)
+		}
 	}
 
-	if o == log.FormattedOut {
-		output = fmt.Sprintf(msg[0].(string), msg[1:]...)
-	} else {
-		output = fmt.Sprint(msg...)
+	output := e.Message()
+	if e.Out == log.LineOut {
 		lineBreak = "\n"
 	}
+	if kv := e.KeyValues(); kv != "" {
+		output += " " + kv
+	}
+	if info := e.ContextInfo(); info != "" {
+		output += " (" + info + ")"
+	}
 
 	output = fmt.Sprintf("%s [%s] %s%s",
-		now().UTC().Format(log.TimeFormat),
-		log.Prefixes[m],
+		e.Time.UTC().Format(log.TimeFormat),
+		log.Prefixes[e.Type],
 		debugInfo,
 		output)
 
-	if len(output) > log.MaxLineSize {
-		output = output[:log.MaxLineSize] + "..."
+	if size := log.GetMaxLineSize(); len(output) > size {
+		output = output[:size] + "..."
+	}
+	if checksum, _ := config["checksum"].(bool); checksum {
+		output = appendChecksum(strings.TrimSuffix(output, "\n"))
+	} else {
+		output = output + lineBreak
 	}
-	output = output + lineBreak
 
-	f, err := os.OpenFile(config["fileName"].(string), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
+	fileName := config["fileName"].(string)
+	sync := needSync(e, config)
+	bufs := [][]byte{[]byte(output)}
+	r := rotationConfig(config)
+	if size, _ := config["batchSize"].(int); size > 0 {
+		interval, ok := config["batchInterval"].(time.Duration)
+		if !ok {
+			interval = time.Second
+		}
+		if bufs = queue(fileName, e.Time, bufs[0], size, sync, interval, r); bufs == nil {
+			return
+		}
+	}
+	if err := writeFile(fileName, e.Time, bufs, sync, r); err != nil {
 		panic(err)
 	}
-	defer f.Close()
+}
 
-	if _, err = f.WriteString(output); err != nil {
-		panic(err)
+// writeFile writes bufs with a single write call to the file of the
+// template fileName for the time t, holding the lock, rotating the file
+// first if it would exceed the maximum size.
+func writeFile(fileName string, t time.Time, bufs [][]byte, sync bool, r rotation) error {
+	f, err := openFile(fileName, t)
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	// the file may have been renamed by a rotation or by Scrub before
+	// the lock
+	f, err = lockCurrent(f, name)
+	if f == nil {
+		return err
+	}
+	var backup string
+	if err == nil && r.maxSize > 0 {
+		n := 0
+		for _, b := range bufs {
+			n += len(b)
+		}
+		f, backup, err = r.rotate(f, name, n)
+	}
+	if err == nil {
+		err = writeBuffers(f, bufs)
+	}
+	if err == nil && sync {
+		err = f.Sync()
+	}
+	unlockFile(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && backup != "" {
+		err = r.cleanup(name, backup)
+	}
+	return err
+}
+
+// openFile opens the file of the template fileName for the time t,
+// creating the missing directories.
+func openFile(fileName string, t time.Time) (*os.File, error) {
+	fileName = expandPath(fileName, t.UTC())
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if os.IsNotExist(err) {
+		err = os.MkdirAll(filepath.Dir(fileName), 0700)
+		if err == nil {
+			f, err = os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		}
+	}
+	return f, err
+}
+
+// checkFile verifies that the current file can be opened for writing
+func checkFile(config map[string]interface{}) error {
+	fileName, ok := config["fileName"].(string)
+	if !ok {
+		return errors.New("fileName not configured")
+	}
+	f, err := openFile(fileName, time.Now())
+	if err != nil {
+		return err
 	}
+	return f.Close()
 }
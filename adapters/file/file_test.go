@@ -3,6 +3,9 @@ package file
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,23 +13,17 @@ import (
 )
 
 func TestFileWrite(t *testing.T) {
-	now = func() time.Time { return time.Unix(1498405744, 0) }
+	now := time.Unix(1498405744, 0)
 
 	fileWrite(
-		log.ErrorLog,
-		log.LineOut,
-		map[string]interface{}{"fileName": "logfile.txt"},
-		"test log")
+		&log.Entry{Time: now, Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"test log"}},
+		map[string]interface{}{"fileName": "logfile.txt"})
 	fileWrite(
-		log.DebugLog,
-		log.LineOut,
-		map[string]interface{}{"fileName": "logfile.txt"},
-		"test log")
+		&log.Entry{Time: now, Type: log.DebugLog, Out: log.LineOut, Msg: []interface{}{"test log"}},
+		map[string]interface{}{"fileName": "logfile.txt"})
 	fileWrite(
-		log.WarningLog,
-		log.LineOut,
-		map[string]interface{}{"fileName": "logfile.txt"},
-		"test log")
+		&log.Entry{Time: now, Type: log.WarningLog, Out: log.LineOut, Msg: []interface{}{"test log"}},
+		map[string]interface{}{"fileName": "logfile.txt"})
 
 	b, err := ioutil.ReadFile("logfile.txt")
 	if err != nil {
@@ -38,8 +35,86 @@ func TestFileWrite(t *testing.T) {
 		return
 	}
 
-	expectd := "2017/06/25 15:49:04 [error] test log\n2017/06/25 15:49:04 [warning] test log\n"
+	expectd := "2017/06/25 15:49:04.000 [error] test log\n2017/06/25 15:49:04.000 [warning] test log\n"
 	if string(b) != expectd {
 		t.Fatalf("Error expectd %q, got %q\n", expectd, string(b))
 	}
 }
+
+func TestConcurrentFileWrite(t *testing.T) {
+	now := time.Unix(1498405744, 0)
+	fileName := filepath.Join(t.TempDir(), "logfile.txt")
+	config := map[string]interface{}{"fileName": fileName}
+	msg := strings.Repeat("x", 1000)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				fileWrite(&log.Entry{Time: now, Type: log.WarningLog, Out: log.LineOut, Msg: []interface{}{msg}}, config)
+			}
+		}()
+	}
+	wg.Wait()
+
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expectd := "2017/06/25 15:49:04.000 [warning] " + msg
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 200 {
+		t.Fatalf("Error expectd 200 lines, got %d\n", len(lines))
+	}
+	for _, l := range lines {
+		if l != expectd {
+			t.Fatalf("Error expectd %q, got %q\n", expectd, l)
+		}
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	dir := t.TempDir()
+	err := checkFile(map[string]interface{}{"fileName": filepath.Join(dir, "%Y", "logfile.txt")})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = checkFile(map[string]interface{}{"fileName": dir})
+	if err == nil {
+		t.Fatal("Error expectd error opening a directory")
+	}
+}
+
+func TestReadChecked(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	config := map[string]interface{}{"fileName": name, "checksum": true}
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	fileWrite(&log.Entry{Time: now, Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"first"}}, config)
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// a partial write and a damaged message
+	_, err = f.WriteString("2017/07/01 00:00:00 [msg] trunc\n2017/07/01 00:00:00 [msg] bad\n\x1ecrc32=00000000\n")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f.Close()
+	fileWrite(&log.Entry{Time: now, Type: log.WarningLog, Out: log.FormattedOut, Msg: []interface{}{"two\nlines crc32=0badc0de\n\x1ecrc32=0badc0de\n"}}, config)
+
+	var msgs []string
+	corrupted, err := ReadChecked(name, func(msg string) { msgs = append(msgs, msg) })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := []string{
+		"2017/07/01 00:00:00.000 [error] first",
+		"2017/07/01 00:00:00.000 [warning] two\nlines crc32=0badc0de\n\x1ecrc32=0badc0de",
+	}
+	if strings.Join(msgs, "|") != strings.Join(expected, "|") || corrupted != 3 {
+		t.Fatalf("Error expected %q and 3 corrupted lines, got %q and %d", expected, msgs, corrupted)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package file
+
+import "os"
+
+// lockFile does nothing where flock is not available, writes still rely
+// on O_APPEND.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) {}
@@ -0,0 +1,171 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the time in the name of the rotated files
+const backupTimeFormat = "20060102T150405.000"
+
+// rotation options of the adapter
+type rotation struct {
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+}
+
+func rotationConfig(config map[string]interface{}) rotation {
+	var r rotation
+	if size, ok := config["maxSize"].(int); ok {
+		r.maxSize = int64(size)
+	}
+	r.maxBackups, _ = config["maxBackups"].(int)
+	r.maxAge, _ = config["maxAge"].(time.Duration)
+	r.compress, _ = config["compress"].(bool)
+	return r
+}
+
+// lockCurrent locks f and returns it if it is still the file name,
+// otherwise another process rotated or scrubbed the file while we waited
+// for the lock and the new file is opened and locked.
+func lockCurrent(f *os.File, name string) (*os.File, error) {
+	for {
+		if err := lockFile(f); err != nil {
+			return f, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			return f, nil
+		}
+		ni, err := os.Stat(name)
+		if err != nil || os.SameFile(fi, ni) {
+			return f, nil
+		}
+		unlockFile(f)
+		f.Close()
+		if f, err = os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rotate renames the file name, locked by f, to a backup if writing n
+// bytes would exceed maxSize and returns the new file, locked, and the
+// name of the backup.
+func (r rotation) rotate(f *os.File, name string, n int) (*os.File, string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return f, "", err
+	}
+	if info.Size() == 0 || info.Size()+int64(n) <= r.maxSize {
+		return f, "", nil
+	}
+	t := time.Now()
+	backup := backupName(name, t)
+	for exists(backup) || exists(backup+".gz") {
+		// rotated in the same millisecond
+		t = t.Add(time.Millisecond)
+		backup = backupName(name, t)
+	}
+	if err = os.Rename(name, backup); err != nil {
+		return f, "", err
+	}
+	nf, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return f, backup, err
+	}
+	if err = lockFile(nf); err != nil {
+		nf.Close()
+		return f, backup, err
+	}
+	unlockFile(f)
+	f.Close()
+	return nf, backup, nil
+}
+
+// backupName returns the name of the backup of the file name rotated at
+// t, e.g. app-20240102T150405.000.log for app.log
+func backupName(name string, t time.Time) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// cleanup compresses the backup, if enabled, and removes the backups of
+// the file name beyond maxBackups or older than maxAge
+func (r rotation) cleanup(name, backup string) error {
+	if r.compress {
+		if err := compressFile(backup); err != nil {
+			return err
+		}
+	}
+	if r.maxBackups <= 0 && r.maxAge <= 0 {
+		return nil
+	}
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return err
+	}
+	type backupFile struct {
+		name string
+		t    time.Time
+	}
+	var backups []backupFile
+	for _, m := range matches {
+		s := strings.TrimSuffix(strings.TrimSuffix(m, ".gz"), ext)
+		t, err := time.Parse(backupTimeFormat, strings.TrimPrefix(s, prefix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{name: m, t: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].t.After(backups[j].t) })
+	cutoff := time.Now().Add(-r.maxAge)
+	for i, b := range backups {
+		if (r.maxBackups > 0 && i >= r.maxBackups) || (r.maxAge > 0 && b.t.Before(cutoff)) {
+			if e := os.Remove(b.name); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// compressFile replaces the file name by name.gz
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if e := zw.Close(); err == nil {
+		err = e
+	}
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
@@ -0,0 +1,68 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "app.log")
+	config := map[string]interface{}{
+		"fileName":   fileName,
+		"maxSize":    100,
+		"maxBackups": 2,
+		"maxAge":     24 * time.Hour,
+		"compress":   true,
+	}
+	// a backup older than maxAge
+	old := backupName(fileName, time.Now().Add(-48*time.Hour)) + ".gz"
+	if err := ioutil.WriteFile(old, nil, 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	now := time.Unix(1498405744, 0)
+	for i := 0; i < 10; i++ {
+		// 46 bytes, two messages per file
+		fileWrite(&log.Entry{Time: now, Type: log.WarningLog, Out: log.LineOut, Msg: []interface{}{"rotated message"}}, config)
+	}
+
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := strings.Count(string(b), "\n"); n != 2 {
+		t.Fatalf("Error, expected 2 messages in the current file, got %d", n)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sort.Strings(matches)
+	if len(matches) != 2 {
+		t.Fatalf("Error, expected 2 backups, got %v", matches)
+	}
+	for _, m := range matches {
+		if m == old || !strings.HasSuffix(m, ".log.gz") {
+			t.Fatalf("Error, unexpected backup %s", m)
+		}
+	}
+	records, err := readRecords(matches[0])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 2 {
+		t.Fatalf("Error, expected 2 messages in %s, got %d", matches[0], len(records))
+	}
+	if _, err = os.Stat(strings.TrimSuffix(matches[0], ".gz")); !os.IsNotExist(err) {
+		t.Fatalf("Error, the uncompressed backup was not removed: %v", err)
+	}
+}
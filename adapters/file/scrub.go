@@ -0,0 +1,122 @@
+package file
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ScrubAudit records a scrub made by Scrub. The subject itself is not
+// recorded, only its keyed hash, so the audit record can be kept as the
+// proof of the deletion.
+type ScrubAudit struct {
+	// Subject is the hex HMAC-SHA256 of the subject identifier with the
+	// key given to Scrub, empty without key. The key must be kept secret,
+	// the plain hash of an email is reversed by a dictionary.
+	Subject string `json:"subject,omitempty"`
+	// Time of the scrub
+	Time time.Time `json:"time"`
+	// Files has the number of messages removed from each file
+	Files map[string]int `json:"files"`
+	// Total number of messages removed
+	Total int `json:"total"`
+}
+
+// Scrub removes from the log files names, plain or gzip compressed, every
+// message that contains the subject identifier, e.g. a user ID or an
+// email, ignoring the case, to fulfill a data deletion request. Each file
+// is rewritten to a temporary file and renamed over the original, holding
+// the lock used by the adapter, so it can be used on the current log
+// file as well, the adapter writes to the new file once the lock is
+// released. Compressed files are recognized by the ".gz" suffix. The
+// audit record has the subject hashed with key, nil to leave it out.
+func Scrub(key []byte, subject string, names ...string) (*ScrubAudit, error) {
+	audit := &ScrubAudit{
+		Time:  time.Now().UTC(),
+		Files: make(map[string]int),
+	}
+	if key != nil {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(subject))
+		audit.Subject = hex.EncodeToString(mac.Sum(nil))
+	}
+	subject = strings.ToLower(subject)
+	for _, name := range names {
+		n, err := scrubFile(name, subject)
+		if err != nil {
+			return audit, err
+		}
+		audit.Files[name] = n
+		audit.Total += n
+	}
+	return audit, nil
+}
+
+func scrubFile(name, subject string) (int, error) {
+	f, err := openCurrent(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+
+	records, err := parseRecords(f)
+	if err != nil {
+		return 0, err
+	}
+	kept := records[:0]
+	for _, r := range records {
+		if !strings.Contains(strings.ToLower(r.text), subject) {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(records) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	dir, base := filepath.Split(name)
+	tmp := filepath.Join(dir, ".scrub-"+base)
+	if strings.HasSuffix(name, ".gz") {
+		tmp += ".gz"
+	}
+	if err = writeRecords(tmp, kept); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err = os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return removed, nil
+}
+
+// openCurrent opens the file name for reading and locks it, opening it
+// again if it was renamed, e.g. by a rotation, before the lock
+func openCurrent(name string) (*os.File, error) {
+	for {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		if err = lockFile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			unlockFile(f)
+			f.Close()
+			return nil, err
+		}
+		if ni, err := os.Stat(name); err != nil || os.SameFile(fi, ni) {
+			return f, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
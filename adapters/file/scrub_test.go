@@ -0,0 +1,78 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	err := ioutil.WriteFile(name, []byte("2017/07/01 00:00:00 [msg] login John@Example.com\n"+
+		"2017/07/01 00:00:01 [error] failed\nuser john@example.com\n"+
+		"2017/07/01 00:00:02 [msg] login other@example.com\n"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	audit, err := Scrub([]byte("key"), "john@example.com", name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if audit.Total != 2 || audit.Files[name] != 2 {
+		t.Fatalf("expected 2 messages removed, but got %+v", audit)
+	}
+	if audit.Subject == "john@example.com" || len(audit.Subject) != 64 {
+		t.Fatalf("expected the hash of the subject, but got %q", audit.Subject)
+	}
+	if other, _ := Scrub([]byte("other"), "john@example.com", name); other.Subject == audit.Subject {
+		t.Fatal("expected the hash to depend on the key")
+	}
+	if plain, _ := Scrub(nil, "john@example.com", name); plain.Subject != "" {
+		t.Fatalf("expected no hash without key, but got %q", plain.Subject)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := "2017/07/01 00:00:02 [msg] login other@example.com\n"
+	if string(b) != expected {
+		t.Fatalf("expected %q, but got %q", expected, string(b))
+	}
+}
+
+func TestScrubConcurrentWrite(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	err := ioutil.WriteFile(name, []byte("2017/07/01 00:00:00 [msg] login john@example.com\n"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// a write that opened the file before the scrub renamed it
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err = Scrub(nil, "john@example.com", name); err != nil {
+		t.Fatal(err.Error())
+	}
+	if f, err = lockCurrent(f, name); err != nil {
+		t.Fatal(err.Error())
+	}
+	_, err = f.WriteString("2017/07/01 00:00:01 [msg] kept\n")
+	unlockFile(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := "2017/07/01 00:00:01 [msg] kept\n"; string(b) != expected {
+		t.Fatalf("expected %q, but got %q", expected, string(b))
+	}
+}
@@ -0,0 +1,69 @@
+package file
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// Values of the "sync" option
+const (
+	// SyncNever leaves the sync to the operating system, the default
+	SyncNever = "never"
+	// SyncInterval syncs at most once every "syncInterval"
+	SyncInterval = "interval"
+	// SyncError syncs after every error message
+	SyncError = "every-error"
+	// SyncEntry syncs after every message
+	SyncEntry = "every-entry"
+)
+
+var (
+	lastSync     = make(map[string]time.Time)
+	lastSyncLock = sync.Mutex{}
+)
+
+// needSync reports if the file must be synced after writing e
+func needSync(e *log.Entry, config map[string]interface{}) bool {
+	policy, _ := config["sync"].(string)
+	switch policy {
+	case SyncEntry:
+		return true
+	case SyncError:
+		return e.Type.IsError()
+	case SyncInterval:
+		interval, ok := config["syncInterval"].(time.Duration)
+		if !ok {
+			interval = time.Second
+		}
+		name, _ := config["fileName"].(string)
+		lastSyncLock.Lock()
+		defer lastSyncLock.Unlock()
+		now := time.Now()
+		if now.Sub(lastSync[name]) < interval {
+			return false
+		}
+		lastSync[name] = now
+		return true
+	}
+	return false
+}
+
+// syncFile syncs the current file to the disk
+func syncFile(config map[string]interface{}) error {
+	fileName, ok := config["fileName"].(string)
+	if !ok {
+		return errors.New("fileName not configured")
+	}
+	f, err := openFile(fileName, time.Now())
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package file
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestNeedSync(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	errEntry := &log.Entry{Type: log.ErrorLog}
+	msgEntry := &log.Entry{Type: log.MessageLog}
+
+	testCases := []struct {
+		policy   interface{}
+		e        *log.Entry
+		expected bool
+	}{
+		{nil, errEntry, false},
+		{SyncNever, errEntry, false},
+		{SyncEntry, msgEntry, true},
+		{SyncError, msgEntry, false},
+		{SyncError, errEntry, true},
+	}
+	for _, tc := range testCases {
+		config := map[string]interface{}{"fileName": name, "sync": tc.policy}
+		if got := needSync(tc.e, config); got != tc.expected {
+			t.Errorf("expected %v for %v, but got %v", tc.expected, tc.policy, got)
+		}
+	}
+
+	config := map[string]interface{}{"fileName": name, "sync": SyncInterval, "syncInterval": time.Hour}
+	if !needSync(msgEntry, config) || needSync(msgEntry, config) {
+		t.Fatal("expected a single sync in the interval")
+	}
+
+	fileWrite(&log.Entry{Time: time.Now(), Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"test log"}},
+		map[string]interface{}{"fileName": name, "sync": SyncEntry})
+	if err := syncFile(map[string]interface{}{"fileName": name}); err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,41 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// expandPath replaces the time verbs of the template with the values of t
+func expandPath(template string, t time.Time) string {
+	if !strings.Contains(template, "%") {
+		return template
+	}
+	b := strings.Builder{}
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c != '%' || i == len(template)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch template[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'm':
+			fmt.Fprintf(&b, "%02d", t.Month())
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(template[i])
+		}
+	}
+	return b.String()
+}
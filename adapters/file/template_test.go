@@ -0,0 +1,56 @@
+package file
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestExpandPath(t *testing.T) {
+	now := time.Date(2017, 6, 5, 7, 9, 4, 0, time.UTC)
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{"file.log", "file.log"},
+		{"/var/log/app/%Y/%m/app-%d.log", "/var/log/app/2017/06/app-05.log"},
+		{"app-%H%M.log", "app-0709.log"},
+		{"100%%-%x.log%", "100%-%x.log%"},
+	}
+	for _, tc := range testCases {
+		got := expandPath(tc.template, now)
+		if got != tc.expected {
+			t.Errorf("expected %q, but got %q", tc.expected, got)
+		}
+	}
+}
+
+func TestFileWriteTemplate(t *testing.T) {
+	dir := t.TempDir()
+	config := map[string]interface{}{"fileName": filepath.Join(dir, "%Y", "%m", "app-%d.log")}
+
+	days := []time.Time{
+		time.Date(2017, 6, 30, 23, 59, 59, 0, time.UTC),
+		time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, d := range days {
+		fileWrite(&log.Entry{Time: d, Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"test log"}}, config)
+	}
+
+	files := map[string]string{
+		filepath.Join(dir, "2017", "06", "app-30.log"): "2017/06/30 23:59:59.000 [error] test log\n",
+		filepath.Join(dir, "2017", "07", "app-01.log"): "2017/07/01 00:00:00.000 [error] test log\n",
+	}
+	for name, expectd := range files {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(b) != expectd {
+			t.Fatalf("Error expectd %q, got %q\n", expectd, string(b))
+		}
+	}
+}
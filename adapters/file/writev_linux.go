@@ -0,0 +1,58 @@
+//go:build linux && writev
+// +build linux,writev
+
+package file
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// maxIovecs is the IOV_MAX limit of writev
+const maxIovecs = 1024
+
+// writeBuffers writes bufs to f with writev(2), without copying them
+func writeBuffers(f *os.File, bufs [][]byte) error {
+	if len(bufs) > maxIovecs {
+		return writeJoined(f, bufs)
+	}
+	iov := make([]syscall.Iovec, 0, len(bufs))
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		v := syscall.Iovec{Base: &b[0]}
+		v.SetLen(len(b))
+		iov = append(iov, v)
+	}
+	if len(iov) == 0 {
+		return nil
+	}
+	total := 0
+	for _, b := range bufs {
+		total += len(b)
+	}
+	raw, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var n uintptr
+	var errno syscall.Errno
+	err = raw.Write(func(fd uintptr) bool {
+		n, _, errno = syscall.Syscall(syscall.SYS_WRITEV, fd,
+			uintptr(unsafe.Pointer(&iov[0])), uintptr(len(iov)))
+		return errno != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return &os.PathError{Op: "writev", Path: f.Name(), Err: errno}
+	}
+	if int(n) < total {
+		// a short write, write the rest in a single buffer
+		return writeJoined(f, [][]byte{joinBuffers(bufs)[n:]})
+	}
+	return nil
+}
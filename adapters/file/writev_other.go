@@ -0,0 +1,11 @@
+//go:build !linux || !writev
+// +build !linux !writev
+
+package file
+
+import "os"
+
+// writeBuffers writes bufs to f with a single write call
+func writeBuffers(f *os.File, bufs [][]byte) error {
+	return writeJoined(f, bufs)
+}
@@ -0,0 +1,133 @@
+// Package journal implements an adapter that writes the log messages to
+// the systemd journal with its native protocol, so they can be filtered by
+// unit and priority, e.g. with journalctl -u myservice -p err. The adapter
+// is only registered on Linux.
+//
+// The messages have the PRIORITY of the syslog severity of their type,
+// the SYSLOG_IDENTIFIER "identifier", the name of the program by default,
+// and the CODE_FILE and CODE_LINE of the caller when it is reported. The
+// fields given with WithFields are journal fields with their names in
+// upper case, the characters other than letters, digits and underscores
+// replaced by underscores, e.g. the field "user-id" is USER_ID and can be
+// matched with journalctl USER_ID=42. The "socket" config is the path of
+// the socket of journald, /run/systemd/journal/socket by default.
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nuveo/log"
+)
+
+// DefaultSocket is the path of the socket of journald
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// Priorities of the syslog severities
+const (
+	priorityCritical = 2
+	priorityError    = 3
+	priorityWarning  = 4
+	priorityNotice   = 5
+	priorityInfo     = 6
+	priorityDebug    = 7
+)
+
+func priority(m log.MsgType) int {
+	switch m {
+	case log.FatalLog, log.PanicLog:
+		return priorityCritical
+	case log.ErrorLog:
+		return priorityError
+	case log.WarningLog:
+		return priorityWarning
+	case log.DebugLog:
+		return priorityDebug
+	case log.EventLog:
+		return priorityNotice
+	}
+	return priorityInfo
+}
+
+// reserved are the fields set by the adapter, the entry fields with the
+// same names are prefixed by FIELD_
+var reserved = map[string]bool{
+	"MESSAGE": true, "PRIORITY": true, "SYSLOG_IDENTIFIER": true,
+	"CODE_FILE": true, "CODE_LINE": true,
+}
+
+// fieldName returns the journal field of the entry field k, empty if it
+// has no valid character
+func fieldName(k string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, k)
+	// the names starting with an underscore are trusted fields set by
+	// journald, and the names can't start with a digit
+	name = strings.TrimLeft(name, "_0123456789")
+	if reserved[name] {
+		name = "FIELD_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// writeField appends the field to b, with the binary format if the value
+// has line breaks
+func writeField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		b.WriteString(name + "=" + value + "\n")
+		return
+	}
+	b.WriteString(name + "\n")
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
+
+// format returns e as a message of the native protocol
+func format(e *log.Entry, config map[string]interface{}) []byte {
+	var b bytes.Buffer
+	writeField(&b, "MESSAGE", strings.TrimSuffix(e.Message(), "\n"))
+	writeField(&b, "PRIORITY", strconv.Itoa(priority(e.Type)))
+	id, _ := config["identifier"].(string)
+	if id == "" {
+		id = filepath.Base(os.Args[0])
+	}
+	writeField(&b, "SYSLOG_IDENTIFIER", id)
+	if e.Caller != "" && e.ShowCaller() {
+		file, line := e.Caller, ""
+		if i := strings.LastIndex(file, ":"); i > 0 {
+			file, line = file[:i], file[i+1:]
+		}
+		writeField(&b, "CODE_FILE", file)
+		if line != "" {
+			writeField(&b, "CODE_LINE", line)
+		}
+	}
+	for _, k := range e.Keys {
+		if name := fieldName(k); name != "" {
+			writeField(&b, name, fmt.Sprint(e.Fields[k]))
+		}
+	}
+	return b.Bytes()
+}
+
+func socketPath(config map[string]interface{}) string {
+	if p, ok := config["socket"].(string); ok && p != "" {
+		return p
+	}
+	return DefaultSocket
+}
@@ -0,0 +1,104 @@
+package journal
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/nuveo/log"
+)
+
+var (
+	conns = make(map[string]*net.UnixConn)
+	lock  = sync.Mutex{}
+)
+
+func init() {
+	log.AddAdapter("journal", log.AdapterPod{
+		Write:  journalWrite,
+		Config: map[string]interface{}{},
+		Close:  closeConns,
+		Check:  checkSocket,
+	})
+}
+
+func journalWrite(e *log.Entry, config map[string]interface{}) error {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return nil
+	}
+	msg := format(e, config)
+	path := socketPath(config)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// try again once with a new socket, journald may have been restarted
+	var err error
+	for i := 0; i < 2; i++ {
+		c, ok := conns[path]
+		if !ok {
+			if c, err = dial(path); err != nil {
+				return err
+			}
+			conns[path] = c
+		}
+		if err = send(c, msg); err == nil {
+			return nil
+		}
+		_ = c.Close()
+		delete(conns, path)
+	}
+	return err
+}
+
+func dial(path string) (*net.UnixConn, error) {
+	return net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+}
+
+// send writes msg in a datagram, or in a file passed to journald if it is
+// too large for a datagram
+func send(c *net.UnixConn, msg []byte) error {
+	_, err := c.Write(msg)
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+	f, err := ioutil.TempFile("/dev/shm", "journal.")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// journald reads the file by its descriptor
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(msg); err != nil {
+		return err
+	}
+	_, _, err = c.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), nil)
+	return err
+}
+
+func closeConns(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	var err error
+	for path, c := range conns {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(conns, path)
+	}
+	return err
+}
+
+// checkSocket verifies that the socket of journald accepts messages
+func checkSocket(config map[string]interface{}) error {
+	c, err := dial(socketPath(config))
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
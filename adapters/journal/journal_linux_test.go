@@ -0,0 +1,39 @@
+package journal
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestJournalWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	l, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer l.Close()
+	config := map[string]interface{}{"socket": path, "identifier": "api"}
+	if err := checkSocket(config); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	e := &log.Entry{Type: log.WarningLog, Out: log.LineOut, Msg: []interface{}{"slow"}}
+	if err := journalWrite(e, config); err != nil {
+		t.Fatal(err.Error())
+	}
+	b := make([]byte, 1024)
+	n, err := l.Read(b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if msg := string(b[:n]); !strings.HasPrefix(msg, "MESSAGE=slow\nPRIORITY=4\n") {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if err := closeConns(config); err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,64 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestPriority(t *testing.T) {
+	testCases := []struct {
+		m        log.MsgType
+		expected int
+	}{
+		{log.MessageLog, priorityInfo},
+		{log.Message2Log, priorityInfo},
+		{log.WarningLog, priorityWarning},
+		{log.DebugLog, priorityDebug},
+		{log.ErrorLog, priorityError},
+		{log.FatalLog, priorityCritical},
+		{log.PanicLog, priorityCritical},
+		{log.EventLog, priorityNotice},
+	}
+	for _, tc := range testCases {
+		if got := priority(tc.m); got != tc.expected {
+			t.Errorf("expected %v, but got %v", tc.expected, got)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for k, expected := range map[string]string{
+		"user-id": "USER_ID",
+		"Request": "REQUEST",
+		"_pid":    "PID",
+		"2fa":     "FA",
+		"message": "FIELD_MESSAGE",
+		"日本":      "",
+	} {
+		if got := fieldName(k); got != expected {
+			t.Errorf("%q: expected %q, but got %q", k, expected, got)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	e := &log.Entry{
+		Type:   log.ErrorLog,
+		Out:    log.LineOut,
+		Msg:    []interface{}{"copy failed"},
+		Fields: log.Fields{"user-id": 42, "query": "SELECT *\nFROM t"},
+		Keys:   []string{"query", "user-id"},
+	}
+	var query bytes.Buffer
+	query.WriteString("QUERY\n")
+	_ = binary.Write(&query, binary.LittleEndian, uint64(len("SELECT *\nFROM t")))
+	query.WriteString("SELECT *\nFROM t\n")
+	expected := "MESSAGE=copy failed\nPRIORITY=3\nSYSLOG_IDENTIFIER=api\n" + query.String() + "USER_ID=42\n"
+	got := format(e, map[string]interface{}{"identifier": "api"})
+	if string(got) != expected {
+		t.Errorf("expected %q, but got %q", expected, got)
+	}
+}
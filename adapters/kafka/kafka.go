@@ -0,0 +1,349 @@
+// Package kafka implements an adapter that publishes the entries to a
+// Kafka topic, as JSON formatted by log.JSONFormatter, without a sidecar.
+//
+// The "brokers" config is the bootstrap brokers of the cluster, a
+// []string or a comma separated string, localhost:9092 by default, and
+// "topic" the topic, logs by default. The "key" config is the key
+// strategy of the messages: empty, the default, sends the messages to the
+// partitions in turn, "level" uses the level of the entry, "id" the ID of
+// the entry and any other value is the name of the field used as key, the
+// entries without it are not keyed. The keyed messages are sent to the
+// partitions as by the default partitioner of the Java client. "acks" (an
+// int) is the acknowledgment required: 1, the default, by the leader, -1
+// by all the in-sync replicas and 0 none.
+//
+// The entries are sent in batches of "batchSize" (an int, 100 by
+// default), when the oldest entry waiting is older than "interval" (a
+// time.Duration, one second by default) and by log.Flush. The batches
+// that failed are sent again with the next one, keeping at most
+// "bufferSize" entries (an int, 10000 by default), the oldest are dropped.
+// The entries of a batch accepted by some of the leaders may be published
+// twice.
+//
+// Only the plaintext protocol without authentication is supported and the
+// messages are not compressed.
+package kafka
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// Defaults of the config
+const (
+	DefaultBrokers = "localhost:9092"
+	DefaultTopic   = "logs"
+)
+
+const timeout = 10 * time.Second
+
+// producer publishes the records of a topic
+type producer struct {
+	brokers []string
+	topic   string
+
+	lock    sync.Mutex
+	pending []record
+	first   time.Time
+
+	// sendLock guards the fields below and serializes the requests
+	sendLock    sync.Mutex
+	conns       map[string]net.Conn
+	md          *metadata
+	correlation int32
+	next        int
+}
+
+var (
+	producers = make(map[string]*producer)
+	lock      = sync.Mutex{}
+	dial      = net.DialTimeout
+)
+
+func init() {
+	log.AddAdapter("kafka", log.AdapterPod{
+		Write: kafkaWrite,
+		Config: map[string]interface{}{
+			"brokers": DefaultBrokers,
+			"topic":   DefaultTopic,
+		},
+		Flush: flushProducers,
+		Close: closeProducers,
+		Check: checkBrokers,
+	})
+}
+
+// brokers returns the bootstrap brokers and the topic of config
+func brokers(config map[string]interface{}) ([]string, string) {
+	var list []string
+	switch b := config["brokers"].(type) {
+	case []string:
+		list = b
+	case string:
+		for _, s := range strings.Split(b, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	if len(list) == 0 {
+		list = []string{DefaultBrokers}
+	}
+	topic, _ := config["topic"].(string)
+	if topic == "" {
+		topic = DefaultTopic
+	}
+	return list, topic
+}
+
+func getProducer(config map[string]interface{}) *producer {
+	list, topic := brokers(config)
+	key := strings.Join(list, ",") + "/" + topic
+	lock.Lock()
+	defer lock.Unlock()
+	p, ok := producers[key]
+	if !ok {
+		p = &producer{brokers: list, topic: topic, conns: make(map[string]net.Conn)}
+		producers[key] = p
+	}
+	return p
+}
+
+// key returns the key of e by the strategy of config, nil if not keyed
+func key(e *log.Entry, config map[string]interface{}) []byte {
+	strategy, _ := config["key"].(string)
+	switch strategy {
+	case "":
+		return nil
+	case "level":
+		return []byte(log.Prefixes[e.Type])
+	case "id":
+		if e.ID == "" {
+			return nil
+		}
+		return []byte(e.ID)
+	}
+	v, ok := e.Fields[strategy]
+	if !ok {
+		return nil
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+func intConfig(config map[string]interface{}, name string, def int) int {
+	if n, ok := config[name].(int); ok {
+		return n
+	}
+	return def
+}
+
+func kafkaWrite(e *log.Entry, config map[string]interface{}) error {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return nil
+	}
+	r := record{
+		key:   key(e, config),
+		value: []byte(strings.TrimSuffix(log.JSONFormatter(e), "\n")),
+		time:  e.Time,
+	}
+	interval, ok := config["interval"].(time.Duration)
+	if !ok {
+		interval = time.Second
+	}
+	p := getProducer(config)
+	p.lock.Lock()
+	if len(p.pending) == 0 {
+		p.first = time.Now()
+	}
+	p.pending = append(p.pending, r)
+	if len(p.pending) < intConfig(config, "batchSize", 100) && time.Since(p.first) < interval {
+		p.lock.Unlock()
+		return nil
+	}
+	p.lock.Unlock()
+	return p.flush(config)
+}
+
+// flush sends the pending records, they are kept to be sent again if it
+// fails
+func (p *producer) flush(config map[string]interface{}) error {
+	p.lock.Lock()
+	records := p.pending
+	p.pending = nil
+	p.lock.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+	err := p.send(records, int16(intConfig(config, "acks", 1)), clientID(config))
+	if err == nil {
+		return nil
+	}
+	p.lock.Lock()
+	p.pending = append(records, p.pending...)
+	if over := len(p.pending) - intConfig(config, "bufferSize", 10000); over > 0 {
+		p.pending = p.pending[over:]
+	}
+	if len(p.pending) > 0 {
+		p.first = time.Now()
+	}
+	p.lock.Unlock()
+	return err
+}
+
+func clientID(config map[string]interface{}) string {
+	if id, ok := config["clientId"].(string); ok && id != "" {
+		return id
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// send publishes the records to the leaders of their partitions
+func (p *producer) send(records []record, acks int16, client string) error {
+	p.sendLock.Lock()
+	defer p.sendLock.Unlock()
+	md, err := p.metadata(client)
+	if err != nil {
+		return err
+	}
+
+	byPartition := make(map[int32][]record)
+	for _, r := range records {
+		var part int32
+		if r.key != nil {
+			part = (murmur2(r.key) & 0x7fffffff) % int32(len(md.leaders))
+		} else {
+			part = int32(p.next % len(md.leaders))
+			p.next++
+		}
+		byPartition[part] = append(byPartition[part], r)
+	}
+	byLeader := make(map[string]map[int32][]byte)
+	for part, rs := range byPartition {
+		addr, ok := md.brokers[md.leaders[part]]
+		if !ok {
+			p.md = nil
+			return fmt.Errorf("kafka: partition %s/%d has no leader", p.topic, part)
+		}
+		if byLeader[addr] == nil {
+			byLeader[addr] = make(map[int32][]byte)
+		}
+		byLeader[addr][part] = recordBatch(rs)
+	}
+
+	for addr, batches := range byLeader {
+		body := produceRequest(p.topic, acks, timeout, batches)
+		resp, err := p.request(addr, apiProduce, produceVersion, client, body, acks != 0)
+		if err == nil && acks != 0 {
+			err = parseProduce(resp)
+		}
+		if err != nil {
+			// the leaders may have changed
+			p.md = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// metadata returns the metadata of the topic, requested to the bootstrap
+// brokers if it is not known
+func (p *producer) metadata(client string) (*metadata, error) {
+	if p.md != nil {
+		return p.md, nil
+	}
+	var err error
+	for _, addr := range p.brokers {
+		var resp []byte
+		resp, err = p.request(addr, apiMetadata, metadataVersion, client, metadataRequest(p.topic), true)
+		if err != nil {
+			continue
+		}
+		if p.md, err = parseMetadata(resp, p.topic); err == nil {
+			return p.md, nil
+		}
+	}
+	return nil, err
+}
+
+// request sends the request to the broker at addr, connecting if needed,
+// and returns the response
+func (p *producer) request(addr string, apiKey, version int16, client string, body []byte, response bool) ([]byte, error) {
+	c, ok := p.conns[addr]
+	if !ok {
+		var err error
+		if c, err = dial("tcp", addr, timeout); err != nil {
+			return nil, err
+		}
+		p.conns[addr] = c
+	}
+	p.correlation++
+	_ = c.SetDeadline(time.Now().Add(timeout + 5*time.Second))
+	resp, err := request(c, apiKey, version, p.correlation, client, body, response)
+	if err != nil {
+		_ = c.Close()
+		delete(p.conns, addr)
+	}
+	return resp, err
+}
+
+func (p *producer) close() error {
+	p.sendLock.Lock()
+	defer p.sendLock.Unlock()
+	var err error
+	for addr, c := range p.conns {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(p.conns, addr)
+	}
+	return err
+}
+
+func allProducers() []*producer {
+	lock.Lock()
+	defer lock.Unlock()
+	list := make([]*producer, 0, len(producers))
+	for _, p := range producers {
+		list = append(list, p)
+	}
+	return list
+}
+
+// flushProducers sends the records waiting in every batch
+func flushProducers(config map[string]interface{}) error {
+	var err error
+	for _, p := range allProducers() {
+		if e := p.flush(config); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// closeProducers sends the records waiting and closes the connections
+func closeProducers(config map[string]interface{}) error {
+	err := flushProducers(config)
+	for _, p := range allProducers() {
+		if e := p.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// checkBrokers verifies that the brokers know the topic
+func checkBrokers(config map[string]interface{}) error {
+	p := getProducer(config)
+	p.sendLock.Lock()
+	defer p.sendLock.Unlock()
+	p.md = nil
+	_, err := p.metadata(clientID(config))
+	return err
+}
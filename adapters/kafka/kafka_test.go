@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// fakeBroker is a single broker cluster with a topic of two partitions
+type fakeBroker struct {
+	l        net.Listener
+	lock     sync.Mutex
+	produced map[int32][]record
+	requests int
+	// fail is the error code of the next produce responses
+	fail int16
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	b := &fakeBroker{l: l, produced: make(map[int32][]record)}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(c)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(c net.Conn) {
+	defer c.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(c, msg); err != nil {
+			return
+		}
+		d := &decoder{b: msg}
+		apiKey := d.int16()
+		d.int16()
+		correlation := d.int32()
+		d.string()
+
+		var resp encoder
+		resp.int32(correlation)
+		switch apiKey {
+		case apiMetadata:
+			host, port, _ := net.SplitHostPort(b.l.Addr().String())
+			p, _ := strconv.Atoi(port)
+			resp.int32(1)
+			resp.int32(0)
+			resp.string(host)
+			resp.int32(int32(p))
+			resp.int32(1)
+			resp.int16(0)
+			resp.string("logs")
+			resp.int32(2)
+			for part := int32(0); part < 2; part++ {
+				resp.int16(0)
+				resp.int32(part)
+				resp.int32(0)
+				resp.int32(0)
+				resp.int32(0)
+			}
+		case apiProduce:
+			d.int16()
+			d.int16()
+			d.int32()
+			d.array()
+			topic := d.string()
+			b.lock.Lock()
+			b.requests++
+			code := b.fail
+			resp.int32(1)
+			resp.string(topic)
+			n := d.array()
+			resp.int32(int32(n))
+			for i := 0; i < n; i++ {
+				part := d.int32()
+				batch := d.next(int(d.int32()))
+				records, err := decodeRecords(batch)
+				if err != nil {
+					b.lock.Unlock()
+					return
+				}
+				if code == 0 {
+					b.produced[part] = append(b.produced[part], records...)
+				}
+				resp.int32(part)
+				resp.int16(code)
+				resp.int64(0)
+				resp.int64(-1)
+			}
+			b.lock.Unlock()
+			resp.int32(0)
+		}
+		var out encoder
+		out.int32(int32(resp.Len()))
+		out.Write(resp.Bytes())
+		if _, err := c.Write(out.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func entry(msg string, fields log.Fields) *log.Entry {
+	return &log.Entry{Time: time.Unix(1498405744, 0), Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{msg}, Fields: fields}
+}
+
+func TestKafkaWrite(t *testing.T) {
+	b := newFakeBroker(t)
+	defer b.l.Close()
+	config := map[string]interface{}{"brokers": b.l.Addr().String(), "key": "user", "batchSize": 3, "interval": time.Hour}
+	defer closeProducers(config)
+
+	for _, e := range []*log.Entry{
+		entry("first", log.Fields{"user": "ana"}),
+		entry("second", nil),
+		entry("third", log.Fields{"user": "ana"}),
+	} {
+		if err := kafkaWrite(e, config); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	_ = kafkaWrite(entry("fourth", nil), config)
+	if b.requests != 1 {
+		t.Fatalf("Error, %d produce requests for a batch", b.requests)
+	}
+	if err := flushProducers(config); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the keyed records in the partition of murmur2, the others in turn
+	part := (murmur2([]byte("ana")) & 0x7fffffff) % 2
+	var keyed []string
+	for _, r := range b.produced[part] {
+		if string(r.key) == "ana" {
+			var v map[string]interface{}
+			if err := json.Unmarshal(r.value, &v); err != nil {
+				t.Fatal(err.Error())
+			}
+			keyed = append(keyed, v["message"].(string))
+		}
+	}
+	if len(keyed) != 2 || keyed[0] != "first" || keyed[1] != "third" {
+		t.Fatalf("Error, partition %d got %v", part, keyed)
+	}
+	if n := len(b.produced[0]) + len(b.produced[1]); n != 4 {
+		t.Fatalf("Error, %d records produced", n)
+	}
+}
+
+func TestKafkaRetry(t *testing.T) {
+	b := newFakeBroker(t)
+	defer b.l.Close()
+	b.fail = 6 // NOT_LEADER_FOR_PARTITION
+	config := map[string]interface{}{"brokers": []string{"127.0.0.1:1", b.l.Addr().String()}, "batchSize": 1, "bufferSize": 2}
+	defer closeProducers(config)
+
+	for _, msg := range []string{"first", "second", "third"} {
+		if err := kafkaWrite(entry(msg, nil), config); err == nil {
+			t.Fatal("Error, expected the error of the broker")
+		}
+	}
+	b.lock.Lock()
+	b.fail = 0
+	b.lock.Unlock()
+	if err := flushProducers(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	var got []string
+	for _, part := range []int32{0, 1} {
+		for _, r := range b.produced[part] {
+			var v map[string]interface{}
+			_ = json.Unmarshal(r.value, &v)
+			got = append(got, v["message"].(string))
+		}
+	}
+	// the buffer of two records kept the last ones
+	if len(got) != 2 {
+		t.Fatalf("Error, produced %v", got)
+	}
+	for _, msg := range got {
+		if msg == "first" {
+			t.Fatalf("Error, produced %v", got)
+		}
+	}
+}
+
+func TestCheckBrokers(t *testing.T) {
+	b := newFakeBroker(t)
+	if err := checkBrokers(map[string]interface{}{"brokers": b.l.Addr().String()}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := checkBrokers(map[string]interface{}{"brokers": b.l.Addr().String(), "topic": "other"}); err == nil {
+		t.Fatal("Error, expected error of the unknown topic")
+	}
+	b.l.Close()
+	config := map[string]interface{}{"brokers": "127.0.0.1:1"}
+	if err := checkBrokers(config); err == nil {
+		t.Fatal("Error, expected error without brokers")
+	}
+	_ = closeProducers(config)
+}
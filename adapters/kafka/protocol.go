@@ -0,0 +1,332 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// API keys and versions of the requests, the oldest versions with the
+// record batches of Kafka 0.11, accepted by the brokers since then
+const (
+	apiProduce      = 0
+	apiMetadata     = 3
+	produceVersion  = 3
+	metadataVersion = 0
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// encoder writes the primitive types of the Kafka protocol
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) int8(v int8)   { e.WriteByte(byte(v)) }
+func (e *encoder) int16(v int16) { _ = binary.Write(e, binary.BigEndian, v) }
+func (e *encoder) int32(v int32) { _ = binary.Write(e, binary.BigEndian, v) }
+func (e *encoder) int64(v int64) { _ = binary.Write(e, binary.BigEndian, v) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.WriteString(s)
+}
+
+// varint writes v zigzag encoded, as the fields of the records
+func (e *encoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+// varbytes writes b prefixed by its varint length, -1 if it is nil
+func (e *encoder) varbytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.Write(b)
+}
+
+// decoder reads the primitive types of the Kafka protocol, the first
+// error is kept in err and the following reads return zero
+type decoder struct {
+	b   []byte
+	err error
+}
+
+var errShort = errors.New("kafka: short response")
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil || n < 0 || len(d.b) < n {
+		if d.err == nil {
+			d.err = errShort
+		}
+		if n < 0 || n > 8 {
+			return nil
+		}
+		// zeros for the integers
+		return make([]byte, n)
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) int16() int16 { return int16(binary.BigEndian.Uint16(d.next(2))) }
+func (d *decoder) int32() int32 { return int32(binary.BigEndian.Uint32(d.next(4))) }
+func (d *decoder) int64() int64 { return int64(binary.BigEndian.Uint64(d.next(8))) }
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+// array returns the length of an array, checked against the remaining
+// bytes so a corrupt length doesn't allocate a huge slice
+func (d *decoder) array() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.b) {
+		d.err = errShort
+		return 0
+	}
+	return int(n)
+}
+
+// record is a message of the topic
+type record struct {
+	key, value []byte
+	time       time.Time
+}
+
+// recordBatch returns the records as a record batch of the message format
+// v2, uncompressed
+func recordBatch(records []record) []byte {
+	first, last := records[0].time, records[0].time
+	for _, r := range records {
+		if r.time.Before(first) {
+			first = r.time
+		}
+		if r.time.After(last) {
+			last = r.time
+		}
+	}
+	var body encoder
+	body.int16(0) // attributes
+	body.int32(int32(len(records) - 1))
+	body.int64(millis(first))
+	body.int64(millis(last))
+	body.int64(-1) // producer id
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(records)))
+	for i, r := range records {
+		var rec encoder
+		rec.int8(0) // attributes
+		rec.varint(millis(r.time) - millis(first))
+		rec.varint(int64(i))
+		rec.varbytes(r.key)
+		rec.varbytes(r.value)
+		rec.varint(0) // headers
+		body.varint(int64(rec.Len()))
+		body.Write(rec.Bytes())
+	}
+
+	var b encoder
+	b.int64(0) // base offset
+	b.int32(int32(4 + 1 + 4 + body.Len()))
+	b.int32(-1) // partition leader epoch
+	b.int8(2)   // magic
+	b.int32(int32(crc32.Checksum(body.Bytes(), castagnoli)))
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// murmur2 is the hash of the keys of the default partitioner of the Java
+// client, so the entries with the same key go to the same partition as
+// the messages of other producers
+func murmur2(data []byte) int32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	n := len(data)
+	h := uint32(seed) ^ uint32(n)
+	for i := 0; i+4 <= n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := data[n&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+// request writes the request of the API key with its header and returns
+// the body of the response with the correlation ID
+func request(rw io.ReadWriter, apiKey, version int16, correlation int32, clientID string, body []byte, response bool) ([]byte, error) {
+	var h encoder
+	h.int16(apiKey)
+	h.int16(version)
+	h.int32(correlation)
+	h.string(clientID)
+	var msg encoder
+	msg.int32(int32(h.Len() + len(body)))
+	msg.Write(h.Bytes())
+	msg.Write(body)
+	if _, err := rw.Write(msg.Bytes()); err != nil {
+		return nil, err
+	}
+	if !response {
+		return nil, nil
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(rw, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > 64<<20 {
+		return nil, fmt.Errorf("kafka: invalid response size %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rw, b); err != nil {
+		return nil, err
+	}
+	if got := int32(binary.BigEndian.Uint32(b)); got != correlation {
+		return nil, fmt.Errorf("kafka: response %d to request %d", got, correlation)
+	}
+	return b[4:], nil
+}
+
+// metadata of a topic, the leader of each partition
+type metadata struct {
+	brokers map[int32]string
+	leaders []int32
+}
+
+func metadataRequest(topic string) []byte {
+	var b encoder
+	b.int32(1)
+	b.string(topic)
+	return b.Bytes()
+}
+
+func parseMetadata(b []byte, topic string) (*metadata, error) {
+	d := &decoder{b: b}
+	md := &metadata{brokers: make(map[int32]string)}
+	for i, n := 0, d.array(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		md.brokers[id] = fmt.Sprintf("%s:%d", host, port)
+	}
+	for i, n := 0, d.array(); i < n; i++ {
+		code := d.int16()
+		name := d.string()
+		partitions := d.array()
+		leaders := make([]int32, partitions)
+		for j := 0; j < partitions; j++ {
+			perr := d.int16()
+			id := d.int32()
+			leader := d.int32()
+			for k, n := 0, d.array(); k < n; k++ {
+				d.int32()
+			}
+			for k, n := 0, d.array(); k < n; k++ {
+				d.int32()
+			}
+			if perr != 0 {
+				leader = -1
+			}
+			if id < 0 || int(id) >= partitions {
+				return nil, fmt.Errorf("kafka: metadata of topic %s: invalid partition %d", name, id)
+			}
+			leaders[id] = leader
+		}
+		if name != topic {
+			continue
+		}
+		if code != 0 {
+			return nil, fmt.Errorf("kafka: metadata of topic %s: error code %d", topic, code)
+		}
+		md.leaders = leaders
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(md.leaders) == 0 {
+		return nil, fmt.Errorf("kafka: topic %s has no partitions", topic)
+	}
+	return md, nil
+}
+
+// produceRequest returns the request of the batches of the partitions of
+// the topic
+func produceRequest(topic string, acks int16, timeout time.Duration, batches map[int32][]byte) []byte {
+	var b encoder
+	b.int16(-1) // transactional id
+	b.int16(acks)
+	b.int32(int32(timeout / time.Millisecond))
+	b.int32(1)
+	b.string(topic)
+	b.int32(int32(len(batches)))
+	for p, batch := range batches {
+		b.int32(p)
+		b.int32(int32(len(batch)))
+		b.Write(batch)
+	}
+	return b.Bytes()
+}
+
+// parseProduce returns the first error of the partitions in the response
+func parseProduce(b []byte) error {
+	d := &decoder{b: b}
+	var err error
+	for i, n := 0, d.array(); i < n; i++ {
+		topic := d.string()
+		for j, n := 0, d.array(); j < n; j++ {
+			p := d.int32()
+			code := d.int16()
+			d.int64() // base offset
+			d.int64() // log append time
+			if code != 0 && err == nil {
+				err = fmt.Errorf("kafka: produce to %s/%d: error code %d", topic, p, code)
+			}
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"time"
+)
+
+func TestMurmur2(t *testing.T) {
+	// values of org.apache.kafka.common.utils.Utils.murmur2
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+	for s, expected := range cases {
+		if h := murmur2([]byte(s)); h != expected {
+			t.Errorf("Error, murmur2(%q) = %d, expected %d", s, h, expected)
+		}
+	}
+}
+
+func TestRecordBatch(t *testing.T) {
+	start := time.Unix(1498405744, 0)
+	b := recordBatch([]record{
+		{value: []byte("first"), time: start},
+		{key: []byte("k"), value: []byte("second"), time: start.Add(5 * time.Millisecond)},
+	})
+	if n := int(binary.BigEndian.Uint32(b[8:])); n != len(b)-12 {
+		t.Fatalf("Error, batch length %d of %d bytes", n, len(b))
+	}
+	if b[16] != 2 {
+		t.Fatalf("Error, magic %d", b[16])
+	}
+	if crc := binary.BigEndian.Uint32(b[17:]); crc != crc32.Checksum(b[21:], castagnoli) {
+		t.Fatal("Error, invalid CRC")
+	}
+	records, err := decodeRecords(b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 2 || records[0].key != nil || string(records[0].value) != "first" ||
+		string(records[1].key) != "k" || string(records[1].value) != "second" || !records[1].time.Equal(start.Add(5*time.Millisecond)) {
+		t.Fatalf("Error, decoded %+v", records)
+	}
+}
+
+// decodeRecords returns the records of a record batch, as the brokers do
+func decodeRecords(b []byte) ([]record, error) {
+	d := &decoder{b: b[21:]}
+	d.int16()
+	d.int32()
+	first := d.int64()
+	d.int64()
+	d.int64()
+	d.int16()
+	d.int32()
+	n := d.array()
+	var records []record
+	varint := func() int64 {
+		v, k := binary.Varint(d.b)
+		d.b = d.b[k:]
+		return v
+	}
+	varbytes := func() []byte {
+		n := varint()
+		if n < 0 {
+			return nil
+		}
+		return d.next(int(n))
+	}
+	for i := 0; i < n; i++ {
+		varint()
+		d.next(1)
+		delta := varint()
+		varint()
+		r := record{key: varbytes(), value: varbytes()}
+		r.time = time.Unix(0, (first+delta)*int64(time.Millisecond))
+		varint()
+		records = append(records, r)
+	}
+	return records, d.err
+}
@@ -0,0 +1,291 @@
+// Package network implements an adapter that streams the entries as JSON
+// lines, formatted by log.JSONFormatter, to a TCP or UDP endpoint, e.g.
+// the tcp input of Logstash with the json_lines codec or the tcp input of
+// Fluent Bit.
+//
+// The "network" config is "tcp", the default, or "udp" and "address" the
+// host:port of the endpoint, localhost:5170 by default. Every UDP
+// datagram is one entry.
+//
+// The entries are sent by a background goroutine, so the logging doesn't
+// wait for the network. While the endpoint can't be reached they are kept
+// in a buffer of "bufferSize" entries (an int, 1024 by default), the
+// oldest are dropped when it is full, and the connection is retried
+// waiting from 100ms doubled up to "maxBackoff" (a time.Duration, 30
+// seconds by default). The entries written when the connection fails are
+// sent again, so the endpoint may receive them twice. Built with the
+// writev tag on Linux the lines waiting are sent with writev(2), without
+// copying them to a single buffer. log.Flush and
+// log.Close wait up to "flushTimeout" (a time.Duration, 5 seconds by
+// default) for the buffered entries.
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// DefaultAddress is the address of the endpoint if not configured
+const DefaultAddress = "localhost:5170"
+
+const minBackoff = 100 * time.Millisecond
+
+// shipper sends the buffered lines to an endpoint
+type shipper struct {
+	network, address string
+	size             int
+	maxBackoff       time.Duration
+
+	lock    sync.Mutex
+	cond    *sync.Cond
+	lines   [][]byte
+	sending bool
+	closed  bool
+	conn    net.Conn
+	// stop is closed by Close, done when run returns
+	stop chan struct{}
+	done chan struct{}
+}
+
+var (
+	shippers = make(map[string]*shipper)
+	lock     = sync.Mutex{}
+	dial     = net.DialTimeout
+)
+
+func init() {
+	log.AddAdapter("network", log.AdapterPod{
+		Write: networkWrite,
+		Config: map[string]interface{}{
+			"network": "tcp",
+			"address": DefaultAddress,
+		},
+		Flush: flushShippers,
+		Close: closeShippers,
+		Check: checkEndpoint,
+	})
+}
+
+// endpoint returns the network and the address of config
+func endpoint(config map[string]interface{}) (string, string) {
+	network, _ := config["network"].(string)
+	if network == "" {
+		network = "tcp"
+	}
+	address, _ := config["address"].(string)
+	if address == "" {
+		address = DefaultAddress
+	}
+	return network, address
+}
+
+func networkWrite(e *log.Entry, config map[string]interface{}) error {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return nil
+	}
+	s := getShipper(config)
+	if s.push([]byte(log.JSONFormatter(e))) {
+		return fmt.Errorf("%s %s unreachable, the buffer is full and the oldest entry was dropped", s.network, s.address)
+	}
+	return nil
+}
+
+// getShipper returns the shipper of the endpoint of config, started on
+// the first use
+func getShipper(config map[string]interface{}) *shipper {
+	network, address := endpoint(config)
+	key := network + "://" + address
+	lock.Lock()
+	defer lock.Unlock()
+	if s, ok := shippers[key]; ok {
+		return s
+	}
+	s := &shipper{
+		network:    network,
+		address:    address,
+		size:       1024,
+		maxBackoff: 30 * time.Second,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if n, ok := config["bufferSize"].(int); ok && n > 0 {
+		s.size = n
+	}
+	if d, ok := config["maxBackoff"].(time.Duration); ok && d >= minBackoff {
+		s.maxBackoff = d
+	}
+	s.cond = sync.NewCond(&s.lock)
+	shippers[key] = s
+	go s.run()
+	return s
+}
+
+// push buffers line, it reports if the oldest line was dropped as the
+// buffer is full
+func (s *shipper) push(line []byte) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	full := len(s.lines) >= s.size
+	if full {
+		s.lines = s.lines[1:]
+	}
+	s.lines = append(s.lines, line)
+	s.cond.Broadcast()
+	return full
+}
+
+// run sends the buffered lines until the shipper is closed, connecting
+// again with backoff when the connection fails
+func (s *shipper) run() {
+	defer close(s.done)
+	backoff := minBackoff
+	for {
+		s.lock.Lock()
+		for len(s.lines) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.lock.Unlock()
+			return
+		}
+		n := len(s.lines)
+		if strings.HasPrefix(s.network, "udp") {
+			// a datagram for each entry
+			n = 1
+		}
+		lines := s.lines[:n:n]
+		s.lines = s.lines[n:]
+		s.sending = true
+		conn := s.conn
+		s.lock.Unlock()
+
+		var err error
+		if conn == nil {
+			conn, err = dial(s.network, s.address, 5*time.Second)
+		}
+		if err == nil {
+			_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			err = writeLines(conn, lines)
+		}
+
+		s.lock.Lock()
+		s.sending = false
+		if err == nil {
+			s.conn = conn
+			backoff = minBackoff
+			s.cond.Broadcast()
+			s.lock.Unlock()
+			continue
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+		s.conn = nil
+		// the lines are sent again, before the ones pushed meanwhile,
+		// a part of them may have been received already
+		s.lines = append(lines, s.lines...)
+		if over := len(s.lines) - s.size; over > 0 {
+			s.lines = s.lines[over:]
+		}
+		s.cond.Broadcast()
+		s.lock.Unlock()
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stop:
+			return
+		}
+		if backoff *= 2; backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// flush waits up to timeout for the buffered lines to be sent
+func (s *shipper) flush(timeout time.Duration) error {
+	deadline := time.AfterFunc(timeout, func() {
+		s.lock.Lock()
+		s.cond.Broadcast()
+		s.lock.Unlock()
+	})
+	defer deadline.Stop()
+	end := time.Now().Add(timeout)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for len(s.lines) > 0 || s.sending {
+		if !time.Now().Before(end) {
+			return fmt.Errorf("%s %s unreachable, %d entries not sent", s.network, s.address, len(s.lines))
+		}
+		s.cond.Wait()
+	}
+	return nil
+}
+
+func flushTimeout(config map[string]interface{}) time.Duration {
+	if d, ok := config["flushTimeout"].(time.Duration); ok {
+		return d
+	}
+	return 5 * time.Second
+}
+
+func flushShippers(config map[string]interface{}) error {
+	lock.Lock()
+	list := make([]*shipper, 0, len(shippers))
+	for _, s := range shippers {
+		list = append(list, s)
+	}
+	lock.Unlock()
+	var err error
+	for _, s := range list {
+		if e := s.flush(flushTimeout(config)); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// closeShippers flushes and stops the shippers, the entries not sent are
+// dropped
+func closeShippers(config map[string]interface{}) error {
+	err := flushShippers(config)
+	lock.Lock()
+	list := shippers
+	shippers = make(map[string]*shipper)
+	lock.Unlock()
+	for _, s := range list {
+		s.lock.Lock()
+		s.closed = true
+		s.lines = nil
+		if s.conn != nil {
+			_ = s.conn.Close()
+			s.conn = nil
+		}
+		s.cond.Broadcast()
+		s.lock.Unlock()
+		close(s.stop)
+		<-s.done
+	}
+	return err
+}
+
+// checkEndpoint verifies that the endpoint accepts connections, UDP
+// endpoints can't be checked and only the address is validated
+func checkEndpoint(config map[string]interface{}) error {
+	network, address := endpoint(config)
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return errors.New("unsupported network " + network)
+	}
+	c, err := dial(network, address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
@@ -0,0 +1,158 @@
+package network
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func entry(seq uint64, msg string) *log.Entry {
+	return &log.Entry{Seq: seq, Time: time.Unix(1498405744, 0), Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{msg}}
+}
+
+func TestNetworkWriteTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer l.Close()
+	lines := make(chan string, 10)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		s := bufio.NewScanner(c)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+
+	config := map[string]interface{}{"address": l.Addr().String()}
+	defer closeShippers(config)
+	for i, msg := range []string{"first", "second"} {
+		if err := networkWrite(entry(uint64(i), msg), config); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if err := flushShippers(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, msg := range []string{"first", "second"} {
+		select {
+		case line := <-lines:
+			var v map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &v); err != nil {
+				t.Fatal(err.Error())
+			}
+			if v["message"] != msg || v["level"] != "error" {
+				t.Fatalf("Error, received %s", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Error, %s not received", msg)
+		}
+	}
+}
+
+func TestNetworkWriteUDP(t *testing.T) {
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer c.Close()
+
+	config := map[string]interface{}{"network": "udp", "address": c.LocalAddr().String()}
+	defer closeShippers(config)
+	_ = networkWrite(entry(1, "first"), config)
+	_ = networkWrite(entry(2, "second"), config)
+
+	buf := make([]byte, 64*1024)
+	for _, msg := range []string{"first", "second"} {
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := c.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !strings.Contains(string(buf[:n]), `"message":"`+msg+`"`) || strings.Count(string(buf[:n]), "\n") != 1 {
+			t.Fatalf("Error, received datagram %q", buf[:n])
+		}
+	}
+}
+
+func TestNetworkReconnect(t *testing.T) {
+	// the endpoint is down for the first two dials
+	var server net.Conn
+	received := make(chan string, 10)
+	dials := 0
+	dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		if dials++; dials <= 2 {
+			return nil, errors.New("connection refused")
+		}
+		client, srv := net.Pipe()
+		server = srv
+		go func() {
+			s := bufio.NewScanner(server)
+			for s.Scan() {
+				received <- s.Text()
+			}
+		}()
+		return client, nil
+	}
+	defer func() { dial = net.DialTimeout }()
+
+	config := map[string]interface{}{"address": "logs:5170", "bufferSize": 2, "flushTimeout": 10 * time.Millisecond}
+	defer closeShippers(config)
+	for i, msg := range []string{"first", "second", "third"} {
+		err := networkWrite(entry(uint64(i), msg), config)
+		if i < 2 && err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if err := flushShippers(config); err == nil {
+		t.Fatal("Error, Flush didn't report the unreachable endpoint")
+	}
+	config["flushTimeout"] = 5 * time.Second
+	if err := flushShippers(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	var got []string
+	for len(got) < 2 {
+		select {
+		case line := <-received:
+			var v map[string]interface{}
+			_ = json.Unmarshal([]byte(line), &v)
+			got = append(got, v["message"].(string))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Error, received %q", got)
+		}
+	}
+	// the buffer of two entries kept the last ones
+	if got[0] == "first" || got[len(got)-1] != "third" {
+		t.Fatalf("Error, received %q", got)
+	}
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	address := l.Addr().String()
+	if err := checkEndpoint(map[string]interface{}{"address": address}); err != nil {
+		t.Fatal(err.Error())
+	}
+	l.Close()
+	if err := checkEndpoint(map[string]interface{}{"address": address}); err == nil {
+		t.Fatal("Error, expected error without endpoint")
+	}
+	if err := checkEndpoint(map[string]interface{}{"network": "unix"}); err == nil {
+		t.Fatal("Error, expected error of the network")
+	}
+}
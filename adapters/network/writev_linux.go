@@ -0,0 +1,14 @@
+//go:build linux && writev
+// +build linux,writev
+
+package network
+
+import "net"
+
+// writeLines writes the lines to c with writev(2), without copying them
+func writeLines(c net.Conn, lines [][]byte) error {
+	// WriteTo consumes the buffers, the lines are kept to be sent again
+	bufs := append(net.Buffers(nil), lines...)
+	_, err := bufs.WriteTo(c)
+	return err
+}
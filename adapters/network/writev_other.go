@@ -0,0 +1,15 @@
+//go:build !linux || !writev
+// +build !linux !writev
+
+package network
+
+import (
+	"bytes"
+	"net"
+)
+
+// writeLines writes the lines to c with a single write call
+func writeLines(c net.Conn, lines [][]byte) error {
+	_, err := c.Write(bytes.Join(lines, nil))
+	return err
+}
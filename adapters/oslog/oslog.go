@@ -0,0 +1,34 @@
+// Package oslog implements an adapter that writes the log messages to the
+// macOS unified logging system (os_log), so they can be filtered by
+// subsystem and category in Console.app or with `log stream`. The adapter
+// is only registered on macOS and requires cgo.
+//
+// The "subsystem" config is usually the reverse DNS name of the program
+// (e.g. "com.example.daemon") and "category" a part of it. Messages are
+// logged as public, they are not redacted by the system.
+package oslog
+
+import "github.com/nuveo/log"
+
+// os_log_type_t values
+const (
+	typeDefault uint8 = 0x00
+	typeInfo    uint8 = 0x01
+	typeDebug   uint8 = 0x02
+	typeError   uint8 = 0x10
+	typeFault   uint8 = 0x11
+)
+
+func logType(m log.MsgType) uint8 {
+	switch m {
+	case log.DebugLog:
+		return typeDebug
+	case log.Message2Log:
+		return typeInfo
+	case log.ErrorLog:
+		return typeError
+	case log.FatalLog, log.PanicLog:
+		return typeFault
+	}
+	return typeDefault
+}
@@ -0,0 +1,64 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package oslog
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static void logsys_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/nuveo/log"
+)
+
+var (
+	logs = make(map[string]C.os_log_t)
+	lock = sync.Mutex{}
+)
+
+func init() {
+	log.AddAdapter("oslog", log.AdapterPod{
+		Adapter: osLog,
+		Config: map[string]interface{}{
+			"subsystem": "com.github.nuveo.log",
+			"category":  "default",
+		},
+	})
+}
+
+func osLog(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+
+	l := handle(config["subsystem"].(string), config["category"].(string))
+
+	msg := C.CString(e.Message())
+	defer C.free(unsafe.Pointer(msg))
+	C.logsys_os_log(l, C.os_log_type_t(logType(e.Type)), msg)
+}
+
+func handle(subsystem, category string) C.os_log_t {
+	key := subsystem + "\x00" + category
+	lock.Lock()
+	defer lock.Unlock()
+	if l, ok := logs[key]; ok {
+		return l
+	}
+	s := C.CString(subsystem)
+	defer C.free(unsafe.Pointer(s))
+	c := C.CString(category)
+	defer C.free(unsafe.Pointer(c))
+	l := C.os_log_create(s, c)
+	logs[key] = l
+	return l
+}
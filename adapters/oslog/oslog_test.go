@@ -0,0 +1,27 @@
+package oslog
+
+import (
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestLogType(t *testing.T) {
+	testCases := []struct {
+		m        log.MsgType
+		expected uint8
+	}{
+		{log.MessageLog, typeDefault},
+		{log.Message2Log, typeInfo},
+		{log.WarningLog, typeDefault},
+		{log.DebugLog, typeDebug},
+		{log.ErrorLog, typeError},
+		{log.FatalLog, typeFault},
+		{log.PanicLog, typeFault},
+	}
+	for _, tc := range testCases {
+		if got := logType(tc.m); got != tc.expected {
+			t.Errorf("expected %v, but got %v", tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultGRPCEndpoint is the address of a local collector for the "grpc"
+// protocol
+const DefaultGRPCEndpoint = "http://localhost:4317"
+
+// Methods of the collector services
+const (
+	traceMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+	logsMethod  = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+)
+
+// grpcClient speaks HTTP/2 only, unencrypted to the http:// endpoints,
+// as gRPC requires
+var grpcClient = &http.Client{Timeout: 10 * time.Second, Transport: grpcTransport()}
+
+func grpcTransport() *http.Transport {
+	p := new(http.Protocols)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(true)
+	return &http.Transport{Protocols: p, Proxy: http.ProxyFromEnvironment}
+}
+
+// sendGRPC calls the Export method of the collector at endpoint with the
+// spans or the log records of b in the protobuf encoding
+func sendGRPC(endpoint string, b *batch) error {
+	var msg []byte
+	if len(b.spans) > 0 {
+		msg = appendTraceRequest(msg, b)
+	} else {
+		msg = appendLogsRequest(msg, b)
+	}
+	body := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	body = append(body, msg...)
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := grpcClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("otlp: " + endpoint + ": " + resp.Status)
+	}
+	// the status is in the header of the responses without a message
+	code, text := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if code == "" {
+		code, text = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if code != "0" {
+		if m, err := url.PathUnescape(text); err == nil {
+			text = m
+		}
+		return errors.New("otlp: " + endpoint + ": grpc status " + code + ": " + text)
+	}
+	return nil
+}
+
+// The protobuf encoding of the messages of opentelemetry-proto, only the
+// fields set by the adapters are written.
+
+func appendTraceRequest(b []byte, bt *batch) []byte {
+	var ss []byte
+	ss = appendMessage(ss, 1, appendScope(nil))
+	for _, s := range bt.spans {
+		ss = appendMessage(ss, 2, appendSpan(nil, s))
+	}
+	var rs []byte
+	rs = appendMessage(rs, 1, appendResource(nil, bt.resource))
+	rs = appendMessage(rs, 2, ss)
+	return appendMessage(b, 1, rs)
+}
+
+func appendLogsRequest(b []byte, bt *batch) []byte {
+	var sl []byte
+	sl = appendMessage(sl, 1, appendScope(nil))
+	for _, r := range bt.records {
+		sl = appendMessage(sl, 2, appendLogRecord(nil, r))
+	}
+	var rl []byte
+	rl = appendMessage(rl, 1, appendResource(nil, bt.resource))
+	rl = appendMessage(rl, 2, sl)
+	return appendMessage(b, 1, rl)
+}
+
+func appendScope(b []byte) []byte {
+	return appendString(b, 1, "github.com/nuveo/log")
+}
+
+func appendResource(b []byte, attrs []keyValue) []byte {
+	return appendAttributes(b, 1, attrs)
+}
+
+func appendSpan(b []byte, s span) []byte {
+	b = appendID(b, 1, s.TraceID)
+	b = appendID(b, 2, s.SpanID)
+	b = appendID(b, 4, s.ParentSpanID)
+	b = appendString(b, 5, s.Name)
+	b = appendVarint(b, 6, uint64(s.Kind))
+	b = appendFixed64(b, 7, parseNano(s.StartTimeUnixNano))
+	b = appendFixed64(b, 8, parseNano(s.EndTimeUnixNano))
+	b = appendAttributes(b, 9, s.Attributes)
+	var st []byte
+	st = appendString(st, 2, s.Status.Message)
+	st = appendVarint(st, 3, uint64(s.Status.Code))
+	return appendMessage(b, 15, st)
+}
+
+func appendLogRecord(b []byte, r logRecord) []byte {
+	b = appendFixed64(b, 1, parseNano(r.TimeUnixNano))
+	b = appendVarint(b, 2, uint64(r.SeverityNumber))
+	b = appendString(b, 3, r.SeverityText)
+	b = appendMessage(b, 5, appendAnyValue(nil, r.Body))
+	b = appendAttributes(b, 6, r.Attributes)
+	b = appendID(b, 9, r.TraceID)
+	b = appendID(b, 10, r.SpanID)
+	return appendFixed64(b, 11, parseNano(r.ObservedTimeUnixNano))
+}
+
+func appendAttributes(b []byte, num int, attrs []keyValue) []byte {
+	for _, kv := range attrs {
+		var m []byte
+		m = appendString(m, 1, kv.Key)
+		m = appendMessage(m, 2, appendAnyValue(nil, kv.Value))
+		b = appendMessage(b, num, m)
+	}
+	return b
+}
+
+func appendAnyValue(b []byte, v anyValue) []byte {
+	switch {
+	case v.StringValue != nil:
+		return appendMessage(b, 1, []byte(*v.StringValue))
+	case v.BoolValue != nil:
+		n := uint64(0)
+		if *v.BoolValue {
+			n = 1
+		}
+		return binary.AppendUvarint(appendKey(b, 2, 0), n)
+	case v.IntValue != "":
+		n, _ := strconv.ParseInt(v.IntValue, 10, 64)
+		return binary.AppendUvarint(appendKey(b, 3, 0), uint64(n))
+	case v.DoubleValue != nil:
+		b = appendKey(b, 4, 1)
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(*v.DoubleValue))
+	}
+	return b
+}
+
+// appendKey appends the key of the field num with the wire type typ
+func appendKey(b []byte, num, typ int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+// appendVarint, appendFixed64 and appendString skip the zero values, as
+// proto3 does
+func appendVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendKey(b, num, 0), v)
+}
+
+func appendFixed64(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(appendKey(b, num, 1), v)
+}
+
+func appendMessage(b []byte, num int, m []byte) []byte {
+	b = appendKey(b, num, 2)
+	b = binary.AppendUvarint(b, uint64(len(m)))
+	return append(b, m...)
+}
+
+func appendString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendMessage(b, num, []byte(s))
+}
+
+// appendID appends the bytes of the hex trace or span id
+func appendID(b []byte, num int, id string) []byte {
+	v, err := hex.DecodeString(id)
+	if err != nil || len(v) == 0 {
+		return b
+	}
+	return appendMessage(b, num, v)
+}
+
+func parseNano(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
@@ -0,0 +1,136 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// protoField returns the values of the length delimited field at the path
+// of field numbers in the protobuf message b
+func protoField(b []byte, path ...int) [][]byte {
+	var values [][]byte
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+		var v []byte
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(b)
+			b = b[n:]
+			continue
+		case 1:
+			v, b = b[:8], b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			v, b = b[n:n+int(l)], b[n+int(l):]
+		}
+		if int(key>>3) != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			values = append(values, v)
+		} else {
+			values = append(values, protoField(v, path[1:]...)...)
+		}
+	}
+	return values
+}
+
+func newGRPCServer(t *testing.T, status string, requests chan<- *http.Request, bodies chan<- []byte) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if len(b) < 5 || int(binary.BigEndian.Uint32(b[1:5])) != len(b)-5 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- r
+		bodies <- b[5:]
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", status)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "bad%20request")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGRPCLogs(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := newGRPCServer(t, "0", requests, bodies)
+
+	config := map[string]interface{}{"protocol": "grpc", "endpoint": srv.URL, "serviceName": "shop"}
+	otlpLogsWrite(&log.Entry{Time: time.Unix(1498405744, 0), Type: log.ErrorLog, Out: log.LineOut,
+		Msg: []interface{}{"charge failed"}, Fields: log.Fields{"order": 7},
+		Span: &log.Span{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "00f067aa0ba902b7"}}, config)
+	if err := flushBatches(config); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	r := <-requests
+	if r.ProtoMajor != 2 || r.URL.Path != logsMethod || r.Header.Get("Content-Type") != "application/grpc" {
+		t.Fatalf("Error, unexpected request %s %s %v", r.Proto, r.URL.Path, r.Header)
+	}
+	body := <-bodies
+	if s := protoField(body, 1, 1, 1, 2, 1); len(s) != 1 || string(s[0]) != "shop" {
+		t.Fatalf("Error, unexpected service name %q", s)
+	}
+	records := protoField(body, 1, 2, 2)
+	if len(records) != 1 {
+		t.Fatalf("Error, expected 1 record, got %d", len(records))
+	}
+	rec := records[0]
+	if v := protoField(rec, 1); len(v) != 1 || binary.LittleEndian.Uint64(v[0]) != 1498405744000000000 {
+		t.Fatalf("Error, unexpected time %v", v)
+	}
+	if v := protoField(rec, 5, 1); len(v) != 1 || string(v[0]) != "charge failed" {
+		t.Fatalf("Error, unexpected body %q", v)
+	}
+	if v := protoField(rec, 6, 1); len(v) != 1 || string(v[0]) != "order" {
+		t.Fatalf("Error, unexpected attributes %q", v)
+	}
+	if v := protoField(rec, 9); len(v) != 1 || hex.EncodeToString(v[0]) != "0af7651916cd43dd8448eb211c80319c" {
+		t.Fatalf("Error, unexpected trace id %x", v)
+	}
+}
+
+func TestGRPCSpans(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := newGRPCServer(t, "3", requests, bodies)
+
+	config := map[string]interface{}{"protocol": "grpc", "endpoint": srv.URL + "/"}
+	start := time.Unix(1498405744, 0)
+	otlpWrite(&log.Entry{Time: start, Type: log.MessageLog, Out: log.LineOut, Msg: []interface{}{"done"},
+		Span: &log.Span{Name: "checkout", TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331",
+			Start: start, End: start.Add(time.Second)}}, config)
+	err := flushBatches(config)
+	if err == nil || !strings.HasSuffix(err.Error(), "grpc status 3: bad request") {
+		t.Fatalf("Error, expected the grpc status, got %v", err)
+	}
+
+	if r := <-requests; r.URL.Path != traceMethod {
+		t.Fatalf("Error, unexpected method %s", r.URL.Path)
+	}
+	spans := protoField(<-bodies, 1, 2, 2)
+	if len(spans) != 1 {
+		t.Fatalf("Error, expected 1 span, got %d", len(spans))
+	}
+	if v := protoField(spans[0], 5); len(v) != 1 || string(v[0]) != "checkout" {
+		t.Fatalf("Error, unexpected name %q", v)
+	}
+	if v := protoField(spans[0], 8); len(v) != 1 || binary.LittleEndian.Uint64(v[0]) != uint64(start.Add(time.Second).UnixNano()) {
+		t.Fatalf("Error, unexpected end time %v", v)
+	}
+}
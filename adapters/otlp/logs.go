@@ -0,0 +1,108 @@
+package otlp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nuveo/log"
+)
+
+// Severity numbers of the OpenTelemetry log data model
+const (
+	severityDebug  = 5
+	severityInfo   = 9
+	severityInfo2  = 10
+	severityWarn   = 13
+	severityError  = 17
+	severityFatal  = 21
+	severityFatal2 = 22
+)
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+func init() {
+	log.AddAdapter("otlp-logs", log.AdapterPod{
+		Adapter: otlpLogsWrite,
+		Config:  map[string]interface{}{},
+		Flush:   flushBatches,
+		Close:   flushBatches,
+	})
+}
+
+func severity(m log.MsgType) int {
+	switch m {
+	case log.DebugLog:
+		return severityDebug
+	case log.Message2Log:
+		return severityInfo2
+	case log.WarningLog:
+		return severityWarn
+	case log.ErrorLog:
+		return severityError
+	case log.FatalLog:
+		return severityFatal
+	case log.PanicLog:
+		return severityFatal2
+	}
+	return severityInfo
+}
+
+func otlpLogsWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+	r := newLogRecord(e)
+	enqueue(DefaultLogsEndpoint, logsMethod, config, func(b *batch) int {
+		b.records = append(b.records, r)
+		return len(b.records)
+	})
+}
+
+func newLogRecord(e *log.Entry) logRecord {
+	t := strconv.FormatInt(e.Time.UnixNano(), 10)
+	msg := strings.TrimSuffix(e.Message(), "\n")
+	r := logRecord{
+		TimeUnixNano:         t,
+		ObservedTimeUnixNano: t,
+		SeverityNumber:       severity(e.Type),
+		SeverityText:         log.Prefixes[e.Type],
+		Body:                 anyValue{StringValue: &msg},
+	}
+	if e.Span != nil {
+		r.TraceID, r.SpanID = e.Span.TraceID, e.Span.SpanID
+	}
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.Attributes = append(r.Attributes, attribute(k, e.Fields[k]))
+	}
+	if e.Type == log.EventLog {
+		r.Attributes = append(r.Attributes, attribute("event.name", msg))
+	}
+	if e.ID != "" {
+		r.Attributes = append(r.Attributes, attribute("log.record.uid", e.ID))
+	}
+	if i := strings.LastIndexByte(e.Caller, ':'); i > 0 {
+		r.Attributes = append(r.Attributes, attribute("code.filepath", e.Caller[:i]))
+		if line, err := strconv.Atoi(e.Caller[i+1:]); err == nil {
+			r.Attributes = append(r.Attributes, attribute("code.lineno", line))
+		}
+	}
+	if e.Ref != "" {
+		r.Attributes = append(r.Attributes, attribute("error.ref", e.Ref))
+	}
+	return r
+}
@@ -0,0 +1,73 @@
+package otlp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestOTLPLogsWrite(t *testing.T) {
+	requests := make(chan exportRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- req
+	}))
+	defer srv.Close()
+
+	config := map[string]interface{}{
+		"endpoint":    srv.URL,
+		"serviceName": "shop",
+		"resource":    map[string]string{"deployment.environment": "test"},
+	}
+	otlpLogsWrite(&log.Entry{Time: time.Unix(1498405744, 0), Type: log.ErrorLog, Out: log.LineOut,
+		Msg: []interface{}{"charge failed"}, Fields: log.Fields{"order": 7}, Caller: "shop.go:42",
+		Span: &log.Span{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "00f067aa0ba902b7"}}, config)
+	otlpLogsWrite(&log.Entry{Time: time.Unix(1498405744, 0), Type: log.DebugLog, Out: log.LineOut,
+		Msg: []interface{}{"hidden"}}, config)
+	if err := flushBatches(config); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var req exportRequest
+	select {
+	case req = <-requests:
+	case <-time.After(time.Second):
+		t.Fatal("Error, log records not sent")
+	}
+	if len(req.ResourceSpans) != 0 || len(req.ResourceLogs) != 1 {
+		t.Fatalf("Error, unexpected request %+v", req)
+	}
+	attrs := req.ResourceLogs[0].Resource.Attributes
+	if len(attrs) != 2 || *attrs[0].Value.StringValue != "shop" || attrs[1].Key != "deployment.environment" {
+		t.Fatalf("Error, unexpected resource %+v", attrs)
+	}
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 1 {
+		t.Fatalf("Error, expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.TimeUnixNano != "1498405744000000000" || r.SeverityNumber != severityError || r.SeverityText != "error" ||
+		*r.Body.StringValue != "charge failed" || r.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+		t.Fatalf("Error, unexpected record %+v", r)
+	}
+	expected := map[string]string{"order": "7", "code.filepath": "shop.go", "code.lineno": "42"}
+	if len(r.Attributes) != len(expected) {
+		t.Fatalf("Error, unexpected attributes %+v", r.Attributes)
+	}
+	for _, a := range r.Attributes {
+		if a.Value.IntValue == "" {
+			a.Value.IntValue = *a.Value.StringValue
+		}
+		if expected[a.Key] != a.Value.IntValue {
+			t.Fatalf("Error, unexpected attribute %s=%+v", a.Key, a.Value)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Package otlp implements adapters that send the entries to an
+// OpenTelemetry collector with OTLP over HTTP in the JSON encoding.
+//
+// The "otlp" adapter exports the operations logged with log.Begin and End
+// as spans, so the existing log call sites can bootstrap distributed
+// tracing. The fields of the entries are the attributes of the spans and
+// the operations ended with an error have the error status.
+//
+// The "otlp-logs" adapter exports every entry as a LogRecord, with the
+// fields as attributes and the trace and span of the entries logged by
+// an operation.
+//
+// The "endpoint" config is the URL of the collector, by default
+// http://localhost:4318/v1/traces and http://localhost:4318/v1/logs, and
+// "serviceName" the service.name of the resource, the name of the program
+// by default. Other attributes of the resource can be given in "resource"
+// (a map[string]string). The entries are sent in batches of "batchSize"
+// (an int, 64 by default), when the oldest entry waiting is older than
+// "interval" (a time.Duration, 5 seconds by default) and by log.Flush.
+//
+// With the "protocol" config set to "grpc" the entries are sent with
+// OTLP/gRPC, in the protobuf encoding, to the Export methods of the
+// collector at "endpoint", http://localhost:4317 by default; https://
+// endpoints use TLS. The default "http/json" is OTLP over HTTP in the JSON
+// encoding.
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// DefaultEndpoint is the traces URL of a local collector
+const DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+// DefaultLogsEndpoint is the logs URL of a local collector
+const DefaultLogsEndpoint = "http://localhost:4318/v1/logs"
+
+// Status codes of the spans
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// spanKindInternal is the kind of all spans, the adapter can't tell
+// servers from clients
+const spanKindInternal = 1
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    string   `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+// exportRequest is a ExportTraceServiceRequest or a
+// ExportLogsServiceRequest
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans,omitempty"`
+	ResourceLogs  []resourceLogs  `json:"resourceLogs,omitempty"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+// batch of spans or log records waiting to be sent to an endpoint
+type batch struct {
+	grpc     bool
+	resource []keyValue
+	first    time.Time
+	spans    []span
+	records  []logRecord
+}
+
+var (
+	batches   = make(map[string]*batch)
+	batchLock = sync.Mutex{}
+	client    = &http.Client{Timeout: 10 * time.Second}
+)
+
+func init() {
+	log.AddAdapter("otlp", log.AdapterPod{
+		Adapter: otlpWrite,
+		Config:  map[string]interface{}{},
+		Flush:   flushBatches,
+		Close:   flushBatches,
+	})
+}
+
+func otlpWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Span == nil || e.Span.End.IsZero() {
+		return
+	}
+	s := newSpan(e)
+	enqueue(DefaultEndpoint, traceMethod, config, func(b *batch) int {
+		b.spans = append(b.spans, s)
+		return len(b.spans)
+	})
+}
+
+// enqueue adds an item to the batch of the endpoint of config with add,
+// which returns the size of the batch, and sends the batch if it is full
+// or its oldest item is older than the interval. With the "grpc" protocol
+// the batch is sent to the method of the endpoint.
+func enqueue(defaultEndpoint, method string, config map[string]interface{}, add func(b *batch) int) {
+	endpoint, _ := config["endpoint"].(string)
+	grpc := config["protocol"] == "grpc"
+	if grpc {
+		if endpoint == "" {
+			endpoint = DefaultGRPCEndpoint
+		}
+		endpoint = strings.TrimSuffix(endpoint, "/") + method
+	} else if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	size, ok := config["batchSize"].(int)
+	if !ok || size <= 0 {
+		size = 64
+	}
+	interval, ok := config["interval"].(time.Duration)
+	if !ok {
+		interval = 5 * time.Second
+	}
+
+	batchLock.Lock()
+	b, ok := batches[endpoint]
+	if !ok {
+		b = &batch{grpc: grpc, resource: resourceConfig(config), first: time.Now()}
+		batches[endpoint] = b
+	}
+	if add(b) < size && time.Since(b.first) < interval {
+		batchLock.Unlock()
+		return
+	}
+	delete(batches, endpoint)
+	batchLock.Unlock()
+
+	if err := send(endpoint, b); err != nil {
+		panic(err)
+	}
+}
+
+// resourceConfig returns the attributes of the resource of config
+func resourceConfig(config map[string]interface{}) []keyValue {
+	service, _ := config["serviceName"].(string)
+	if service == "" {
+		service = filepath.Base(os.Args[0])
+	}
+	attrs := []keyValue{attribute("service.name", service)}
+	extra, _ := config["resource"].(map[string]string)
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		if k != "service.name" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute(k, extra[k]))
+	}
+	return attrs
+}
+
+func newSpan(e *log.Entry) span {
+	s := span{
+		TraceID:           e.Span.TraceID,
+		SpanID:            e.Span.SpanID,
+		ParentSpanID:      e.Span.ParentID,
+		Name:              e.Span.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(e.Span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(e.Span.End.UnixNano(), 10),
+		Status:            status{Code: statusUnset},
+	}
+	for _, k := range e.Keys {
+		s.Attributes = append(s.Attributes, attribute(k, e.Fields[k]))
+	}
+	if e.Type.IsError() {
+		s.Status = status{Code: statusError, Message: strings.TrimSuffix(e.Message(), "\n")}
+	}
+	return s
+}
+
+func attribute(k string, v interface{}) keyValue {
+	kv := keyValue{Key: k}
+	switch n := v.(type) {
+	case bool:
+		kv.Value.BoolValue = &n
+	case int:
+		kv.Value.IntValue = strconv.FormatInt(int64(n), 10)
+	case int32:
+		kv.Value.IntValue = strconv.FormatInt(int64(n), 10)
+	case int64:
+		kv.Value.IntValue = strconv.FormatInt(n, 10)
+	case float64:
+		kv.Value.DoubleValue = &n
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
+
+// send posts the spans and the log records of b to the endpoint
+func send(endpoint string, b *batch) error {
+	if b.grpc {
+		return sendGRPC(endpoint, b)
+	}
+	var req exportRequest
+	res := resource{Attributes: b.resource}
+	sc := scope{Name: "github.com/nuveo/log"}
+	if len(b.spans) > 0 {
+		req.ResourceSpans = []resourceSpans{{Resource: res, ScopeSpans: []scopeSpans{{Scope: sc, Spans: b.spans}}}}
+	}
+	if len(b.records) > 0 {
+		req.ResourceLogs = []resourceLogs{{Resource: res, ScopeLogs: []scopeLogs{{Scope: sc, LogRecords: b.records}}}}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return errors.New("otlp: " + endpoint + ": " + resp.Status)
+	}
+	return nil
+}
+
+// flushBatches sends the spans and the log records waiting in every batch
+func flushBatches(config map[string]interface{}) error {
+	batchLock.Lock()
+	pending := batches
+	batches = make(map[string]*batch)
+	batchLock.Unlock()
+
+	var err error
+	for endpoint, b := range pending {
+		if e := send(endpoint, b); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
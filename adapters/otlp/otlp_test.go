@@ -0,0 +1,78 @@
+package otlp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestOTLPWrite(t *testing.T) {
+	requests := make(chan exportRequest, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- req
+	}))
+	defer srv.Close()
+
+	config := map[string]interface{}{"endpoint": srv.URL, "serviceName": "shop", "batchSize": 2}
+	start := time.Unix(1498405744, 0)
+	end := start.Add(250 * time.Millisecond)
+	begin := &log.Entry{Time: start, Type: log.MessageLog, Out: log.LineOut, Msg: []interface{}{"begin checkout"},
+		Span: &log.Span{Name: "checkout", TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331", Start: start}}
+	otlpWrite(begin, config)
+
+	child := &log.Entry{Time: end, Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"charge failed"},
+		Fields: log.Fields{"order": 7}, Keys: []string{"order"},
+		Span: &log.Span{Name: "charge", TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "00f067aa0ba902b7",
+			ParentID: "b7ad6b7169203331", Start: start, End: end}}
+	otlpWrite(child, config)
+	select {
+	case <-requests:
+		t.Fatal("Error, batch sent before it was full")
+	default:
+	}
+
+	parent := *begin.Span
+	parent.End = end
+	otlpWrite(&log.Entry{Time: end, Type: log.MessageLog, Out: log.LineOut, Msg: []interface{}{"end checkout"}, Span: &parent}, config)
+
+	var req exportRequest
+	select {
+	case req = <-requests:
+	case <-time.After(time.Second):
+		t.Fatal("Error, spans not sent")
+	}
+	rs := req.ResourceSpans[0]
+	if *rs.Resource.Attributes[0].Value.StringValue != "shop" {
+		t.Fatalf("Error, unexpected resource %+v", rs.Resource)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("Error, expected 2 spans, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "charge" || s.ParentSpanID != "b7ad6b7169203331" || s.Status.Code != statusError ||
+		s.StartTimeUnixNano != "1498405744000000000" || s.EndTimeUnixNano != "1498405744250000000" ||
+		len(s.Attributes) != 1 || s.Attributes[0].Value.IntValue != "7" {
+		t.Fatalf("Error, unexpected span %+v", s)
+	}
+	if spans[1].Name != "checkout" || spans[1].Status.Code != statusUnset {
+		t.Fatalf("Error, unexpected span %+v", spans[1])
+	}
+
+	otlpWrite(child, config)
+	if err := flushBatches(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	if req = <-requests; len(req.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatal("Error, flush did not send the waiting span")
+	}
+}
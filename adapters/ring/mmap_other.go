@@ -0,0 +1,32 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package ring
+
+import "os"
+
+// mapFile reads the file to memory where mmap is not available, the
+// changes are written to the file by flush.
+func mapFile(f *os.File, length int) ([]byte, error) {
+	data := make([]byte, length)
+	_, err := f.ReadAt(data, 0)
+	return data, err
+}
+
+func (r *ring) flush() error {
+	for _, d := range r.dirty {
+		if _, err := r.f.WriteAt(r.data[d[0]:d[1]], int64(d[0])); err != nil {
+			return err
+		}
+	}
+	r.dirty = r.dirty[:0]
+	return nil
+}
+
+func (r *ring) sync() error {
+	return r.f.Sync()
+}
+
+func (r *ring) close() error {
+	return r.f.Close()
+}
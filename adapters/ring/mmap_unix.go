@@ -0,0 +1,35 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package ring
+
+import (
+	"os"
+	"syscall"
+)
+
+func mapFile(f *os.File, length int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+// flush only forgets the changes, the pages of the mapping are written
+// back by the operating system even if the process crashes.
+func (r *ring) flush() error {
+	r.dirty = r.dirty[:0]
+	return nil
+}
+
+// sync writes the pages of the mapping to the disk, msync is not
+// available in the syscall package of every system and the dirty pages of
+// a shared mapping are in the page cache written by fsync.
+func (r *ring) sync() error {
+	return r.f.Sync()
+}
+
+func (r *ring) close() error {
+	err := syscall.Munmap(r.data)
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
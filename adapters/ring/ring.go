@@ -0,0 +1,251 @@
+// Package ring implements an adapter that keeps the most recent log
+// entries in a fixed size file, like a flight recorder: when the file is
+// full the oldest entries are overwritten. On unix systems the file is
+// memory mapped, so the entries written before a crash of the process are
+// kept by the operating system and can be read later with Read.
+//
+// The file starts with a header of the magic "NLOGRING" and the big
+// endian uint64 values of the size of the data area, the position of the
+// oldest entry and the position of the end of the newest one. Positions
+// grow forever, the offset in the data area is the position modulo the
+// size. Each entry is its 4 bytes big endian length followed by its JSON
+// encoding.
+//
+// Only one process must write to a ring file at the same time.
+package ring
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nuveo/log"
+)
+
+const (
+	magic      = "NLOGRING"
+	headerSize = 32
+)
+
+// ErrFormat is returned when the file is not a ring file
+var ErrFormat = errors.New("ring: invalid format")
+
+// ring is an open ring file
+type ring struct {
+	f    *os.File
+	data []byte // header and data area
+	size uint64
+	// dirty are the ranges of data changed since the last flush
+	dirty [][2]int
+}
+
+var (
+	rings = make(map[string]*ring)
+	lock  = sync.Mutex{}
+)
+
+func init() {
+	log.AddAdapter("ring", log.AdapterPod{
+		Adapter: ringWrite,
+		Config: map[string]interface{}{
+			"fileName": "log.ring",
+			"size":     8 << 20,
+		},
+		Flush: syncRings,
+		Close: closeRings,
+		Check: checkRing,
+	})
+}
+
+func ringWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		fmt.Println("error try to encode entry", err)
+		return
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	r, err := openRing(config)
+	if err != nil {
+		fmt.Println("error try to open ring file", err)
+		return
+	}
+	r.append(b)
+}
+
+// openRing returns the ring of the configured file, creating it with the
+// configured size if it doesn't exist.
+func openRing(config map[string]interface{}) (*ring, error) {
+	fileName, ok := config["fileName"].(string)
+	if !ok {
+		return nil, errors.New("fileName not configured")
+	}
+	if r, ok := rings[fileName]; ok {
+		return r, nil
+	}
+	size, _ := config["size"].(int)
+	if size <= 0 {
+		return nil, errors.New("invalid size")
+	}
+
+	f, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	length := int64(headerSize + size)
+	if fi.Size() >= headerSize {
+		// keep the size of an existing file
+		h := make([]byte, headerSize)
+		if _, err = f.ReadAt(h, 0); err != nil || string(h[:8]) != magic {
+			f.Close()
+			return nil, ErrFormat
+		}
+		length = int64(headerSize + binary.BigEndian.Uint64(h[8:]))
+	} else if err = f.Truncate(length); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	data, err := mapFile(f, int(length))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &ring{f: f, data: data, size: uint64(length - headerSize)}
+	if string(data[:8]) != magic {
+		copy(data, magic)
+		binary.BigEndian.PutUint64(data[8:], r.size)
+		r.dirty = append(r.dirty, [2]int{0, headerSize})
+	}
+	rings[fileName] = r
+	return r, nil
+}
+
+// append writes the entry b, discarding the oldest entries to make room
+// for it. Entries bigger than the ring are discarded.
+func (r *ring) append(b []byte) {
+	need := uint64(4 + len(b))
+	if need > r.size {
+		return
+	}
+	tail := binary.BigEndian.Uint64(r.data[16:])
+	head := binary.BigEndian.Uint64(r.data[24:])
+	for head+need-tail > r.size {
+		var l [4]byte
+		r.read(tail, l[:])
+		tail += 4 + uint64(binary.BigEndian.Uint32(l[:]))
+	}
+	binary.BigEndian.PutUint64(r.data[16:], tail)
+
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(b)))
+	r.write(head, l[:])
+	r.write(head+4, b)
+	binary.BigEndian.PutUint64(r.data[24:], head+need)
+	r.dirty = append(r.dirty, [2]int{0, headerSize})
+	if err := r.flush(); err != nil {
+		fmt.Println("error try to write ring file", err)
+	}
+}
+
+func (r *ring) write(pos uint64, b []byte) {
+	area := r.data[headerSize:]
+	off := pos % r.size
+	n := copy(area[off:], b)
+	copy(area, b[n:])
+	r.dirty = append(r.dirty, [2]int{headerSize + int(off), headerSize + int(off) + n})
+	if n < len(b) {
+		r.dirty = append(r.dirty, [2]int{headerSize, headerSize + len(b) - n})
+	}
+}
+
+func (r *ring) read(pos uint64, b []byte) {
+	area := r.data[headerSize:]
+	off := pos % r.size
+	n := copy(b, area[off:])
+	copy(b[n:], area)
+}
+
+func syncRings(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	var err error
+	for _, r := range rings {
+		if e := r.sync(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func closeRings(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	var err error
+	for name, r := range rings {
+		if e := r.close(); e != nil && err == nil {
+			err = e
+		}
+		delete(rings, name)
+	}
+	return err
+}
+
+// checkRing verifies that the ring file can be opened
+func checkRing(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	_, err := openRing(config)
+	return err
+}
+
+// Read returns the entries kept in the ring file name, from the oldest to
+// the newest.
+func Read(name string) ([]*log.Entry, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerSize || string(data[:8]) != magic ||
+		binary.BigEndian.Uint64(data[8:]) != uint64(len(data)-headerSize) {
+		return nil, ErrFormat
+	}
+	r := &ring{data: data, size: uint64(len(data) - headerSize)}
+	tail := binary.BigEndian.Uint64(data[16:])
+	head := binary.BigEndian.Uint64(data[24:])
+	if head < tail || head-tail > r.size {
+		return nil, ErrFormat
+	}
+
+	var entries []*log.Entry
+	for tail < head {
+		var l [4]byte
+		r.read(tail, l[:])
+		n := uint64(binary.BigEndian.Uint32(l[:]))
+		if tail+4+n > head {
+			return entries, ErrFormat
+		}
+		b := make([]byte, n)
+		r.read(tail+4, b)
+		e := &log.Entry{}
+		if err = json.Unmarshal(b, e); err != nil {
+			return entries, err
+		}
+		entries = append(entries, e)
+		tail += 4 + n
+	}
+	return entries, nil
+}
@@ -0,0 +1,58 @@
+package ring
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestRing(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.ring")
+	config := map[string]interface{}{"fileName": name, "size": 4096}
+	defer closeRings(config)
+
+	for i := 0; i < 200; i++ {
+		ringWrite(&log.Entry{
+			Seq:  uint64(i),
+			Time: time.Unix(1498405744, 0),
+			Type: log.MessageLog,
+			Out:  log.LineOut,
+			Msg:  []interface{}{"message " + strconv.Itoa(i)},
+		}, config)
+	}
+	if err := syncRings(config); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// read while the file is still open, as after a crash
+	entries, err := Read(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(entries) == 0 || len(entries) >= 200 {
+		t.Fatalf("Error, expected the most recent entries, got %d", len(entries))
+	}
+	first := 200 - len(entries)
+	for i, e := range entries {
+		if e.Message() != "message "+strconv.Itoa(first+i) {
+			t.Fatalf("Error, entry %d is %q", first+i, e.Message())
+		}
+	}
+
+	// reopening keeps the entries and the size of the file
+	if err = closeRings(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	config["size"] = 1 << 20
+	ringWrite(&log.Entry{Seq: 200, Type: log.MessageLog, Out: log.LineOut, Msg: []interface{}{"message 200"}}, config)
+	entries, err = Read(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if last := entries[len(entries)-1]; last.Message() != "message 200" || entries[0].Seq <= uint64(first) {
+		t.Fatalf("Error, unexpected entries after reopen, first %d last %q", entries[0].Seq, last.Message())
+	}
+}
@@ -1,3 +1,13 @@
+// Package sentry implements an adapter that reports the errors, fatal and
+// panic messages to Sentry.
+//
+// The "dsn" config is the DSN of the Sentry project, "tags" (a
+// map[string]string) the tags added to every event and "enableMsgTypes"
+// (a []log.MsgType) the types of the entries reported. The fields of the
+// entries are tags of the events too, so the events can be searched and
+// grouped by them, the fields with values that aren't strings, numbers or
+// booleans are only extra data. The events have the stack trace of the
+// panics logged by log.Panicked and of the logging call otherwise.
 package sentry
 
 import (
@@ -5,22 +15,31 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
-	"time"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/getsentry/raven-go"
 	"github.com/nuveo/log"
 )
 
-var now = time.Now
+// maxTagValue is the longest tag value accepted by Sentry
+const maxTagValue = 200
+
+var (
+	lock = sync.Mutex{}
+	dsn  string
+)
 
 func init() {
 	log.AddAdapter("sentry", log.AdapterPod{
-		Adapter: sentryLog,
+		Write: sentryWrite,
 		Config: map[string]interface{}{
 			"dsn":            "",
 			"tags":           map[string]string{},
-			"enableMsgTypes": []log.MsgType{log.ErrorLog},
+			"enableMsgTypes": []log.MsgType{log.ErrorLog, log.FatalLog, log.PanicLog},
 		},
+		Check: checkDSN,
 	})
 }
 
@@ -33,47 +52,177 @@ func containsType(m log.MsgType, ts []log.MsgType) bool {
 	return false
 }
 
-func sentryLog(m log.MsgType, o log.OutType, config map[string]interface{}, msg ...interface{}) {
-	ts := config["enableMsgTypes"].([]log.MsgType)
+// setDSN configures the client with d if it changed
+func setDSN(d string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if d == dsn {
+		return nil
+	}
+	if err := raven.SetDSN(d); err != nil {
+		return err
+	}
+	dsn = d
+	return nil
+}
 
-	if !containsType(m, ts) {
-		return
+// tagValue returns v as the value of a tag, false if it isn't a scalar
+func tagValue(v interface{}) (string, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case fmt.Stringer:
+		s = v.String()
+	case error:
+		s = v.Error()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		s = fmt.Sprint(v)
+	default:
+		return "", false
 	}
+	if len(s) > maxTagValue {
+		s = s[:maxTagValue]
+	}
+	return s, true
+}
 
-	if m == log.DebugLog && !log.DebugMode {
-		return
+// tags returns the tags of config with the fields of e
+func tags(e *log.Entry, config map[string]interface{}) map[string]string {
+	t := make(map[string]string)
+	if ct, ok := config["tags"].(map[string]string); ok {
+		for k, v := range ct {
+			t[k] = v
+		}
+	}
+	for k, v := range e.Fields {
+		if s, ok := tagValue(v); ok {
+			t[k] = s
+		}
 	}
+	return t
+}
 
-	var debugInfo, lineBreak, output string
+func level(m log.MsgType) raven.Severity {
+	switch m {
+	case log.FatalLog, log.PanicLog:
+		return raven.FATAL
+	case log.WarningLog:
+		return raven.WARNING
+	case log.DebugLog:
+		return raven.DEBUG
+	}
+	if m.IsError() {
+		return raven.ERROR
+	}
+	return raven.INFO
+}
 
-	if log.DebugMode {
-		_, fn, line, _ := runtime.Caller(5)
-		fn = filepath.Base(fn)
-		debugInfo = fmt.Sprintf("%s:%d ", fn, line)
+// stacktrace returns the stack of e, oldest call first as Sentry expects
+func stacktrace(e *log.Entry) *raven.Stacktrace {
+	st := &raven.Stacktrace{}
+	if len(e.Stack) > 0 {
+		for i := len(e.Stack) - 1; i >= 0; i-- {
+			f := e.Stack[i]
+			st.Frames = append(st.Frames, frame(f.Function, f.File, f.Line))
+		}
+		return st
+	}
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		// the frames of the log package, its adapters and the runtime
+		if !strings.HasPrefix(f.Function, "github.com/nuveo/log.") &&
+			!strings.HasPrefix(f.Function, "github.com/nuveo/log/adapters/") &&
+			!strings.HasPrefix(f.Function, "runtime.") {
+			st.Frames = append([]*raven.StacktraceFrame{frame(f.Function, f.File, f.Line)}, st.Frames...)
+		}
+		if !more {
+			break
+		}
+	}
+	if len(st.Frames) == 0 && e.Caller != "" {
+		// delivered by a worker, only the caller is known
+		file, line := e.Caller, 0
+		if i := strings.LastIndex(file, ":"); i > 0 {
+			line, _ = strconv.Atoi(file[i+1:])
+			file = file[:i]
+		}
+		st.Frames = append(st.Frames, frame("", file, line))
+	}
+	return st
+}
+
+func frame(function, file string, line int) *raven.StacktraceFrame {
+	module := ""
+	slash := strings.LastIndex(function, "/")
+	if i := strings.Index(function[slash+1:], "."); i > 0 {
+		module, function = function[:slash+1+i], function[slash+2+i:]
+	}
+	return &raven.StacktraceFrame{
+		Filename:     filepath.Base(file),
+		AbsolutePath: file,
+		Function:     function,
+		Module:       module,
+		Lineno:       line,
+		InApp:        !strings.HasPrefix(module, "runtime"),
+	}
+}
+
+func sentryWrite(e *log.Entry, config map[string]interface{}) error {
+	ts, _ := config["enableMsgTypes"].([]log.MsgType)
+
+	if !containsType(e.Type, ts) {
+		return nil
 	}
 
-	if o == log.FormattedOut {
-		output = fmt.Sprintf(msg[0].(string), msg[1:]...)
-	} else {
-		output = fmt.Sprint(msg...)
-		lineBreak = "\n"
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return nil
+	}
+	// events are analytics, not diagnostics
+	if e.Type == log.EventLog {
+		return nil
+	}
+
+	d, _ := config["dsn"].(string)
+	if err := setDSN(d); err != nil {
+		return err
 	}
 
-	output = fmt.Sprintf("%s [%s] %s%s",
-		now().UTC().Format(log.TimeFormat),
-		log.Prefixes[m],
-		debugInfo,
-		output)
+	msg := strings.TrimSuffix(e.Message(), "\n")
+	if info := e.ContextInfo(); info != "" {
+		msg += " (" + info + ")"
+	}
+	if size := log.GetMaxLineSize(); len(msg) > size {
+		msg = msg[:size] + "..."
+	}
 
-	if len(output) > log.MaxLineSize {
-		output = output[:log.MaxLineSize] + "..."
+	packet := &raven.Packet{
+		Message:    msg,
+		Level:      level(e.Type),
+		Culprit:    e.Caller,
+		Interfaces: []raven.Interface{raven.NewException(errors.New(msg), stacktrace(e))},
 	}
-	output = output + lineBreak
+	packet.Extra = raven.Extra{"seq": e.Seq}
+	if e.Env != nil {
+		packet.Extra["env"] = e.Env
+	}
+	for k, v := range e.Fields {
+		packet.Extra[k] = v
+	}
+	if e.Ref != "" {
+		packet.Extra["ref"] = e.Ref
+	}
+	_, ch := raven.Capture(packet, tags(e, config))
+	return <-ch
+}
 
-	raven.SetDSN(config["dsn"].(string))
-	packet := &raven.Packet{Message: "err", Interfaces: []raven.Interface{raven.NewException(errors.New(output), raven.NewStacktrace(0, 5, nil)), nil}}
-	_, ch := raven.Capture(packet, config["tags"].(map[string]string))
-	if err := <-ch; err != nil {
-		fmt.Println("error try to send", err)
+// checkDSN verifies that a valid DSN is configured
+func checkDSN(config map[string]interface{}) error {
+	dsn, _ := config["dsn"].(string)
+	if dsn == "" {
+		return errors.New("dsn not configured")
 	}
+	return raven.SetDSN(dsn)
 }
@@ -30,3 +30,51 @@ func TestSentryLog(t *testing.T) {
 		})
 	}
 }
+
+func TestSentryEvent(t *testing.T) {
+	m := &MockTransport{}
+	raven.DefaultClient.Transport = m
+
+	e := &log.Entry{
+		Type:   log.FatalLog,
+		Out:    log.LineOut,
+		Msg:    []interface{}{"job failed"},
+		Caller: "main.go:12",
+		Fields: log.Fields{"user": "ann", "attempt": 3, "ids": []int{1, 2}},
+		Stack: []log.Frame{
+			{Function: "main.(*job).run", File: "/src/main.go", Line: 12},
+			{Function: "main.main", File: "/src/main.go", Line: 5},
+		},
+	}
+	if err := sentryWrite(e, map[string]interface{}{
+		"tags":           map[string]string{"env": "prod"},
+		"enableMsgTypes": []log.MsgType{log.FatalLog},
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if m.Count != 1 || m.Packet.Message != "job failed" || m.Packet.Level != raven.FATAL || m.Packet.Culprit != "main.go:12" {
+		t.Fatalf("unexpected packet %+v", m.Packet)
+	}
+	tags := tags(e, map[string]interface{}{"tags": map[string]string{"env": "prod"}})
+	if len(tags) != 3 || tags["user"] != "ann" || tags["attempt"] != "3" || tags["env"] != "prod" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if m.Packet.Extra["ids"] == nil {
+		t.Errorf("expected the ids in the extra data, got %v", m.Packet.Extra)
+	}
+	ex := m.Packet.Interfaces[0].(*raven.Exception)
+	frames := ex.Stacktrace.Frames
+	if len(frames) != 2 || frames[0].Function != "main" || frames[1].Function != "(*job).run" ||
+		frames[1].Module != "main" || frames[1].Lineno != 12 || frames[1].Filename != "main.go" {
+		t.Errorf("unexpected frames %+v %+v", frames[0], frames[1])
+	}
+}
+
+func TestSentryCallerStack(t *testing.T) {
+	// the frames of the adapters are skipped, this test too
+	st := stacktrace(&log.Entry{Type: log.ErrorLog})
+	last := st.Frames[len(st.Frames)-1]
+	if last.Module != "testing" || last.Function != "tRunner" {
+		t.Fatalf("expected testing.tRunner as the last frame, got %+v", last)
+	}
+}
@@ -3,10 +3,12 @@ package sentry
 import "github.com/getsentry/raven-go"
 
 type MockTransport struct {
-	Count int
+	Count  int
+	Packet *raven.Packet
 }
 
 func (m *MockTransport) Send(url, authHeader string, packet *raven.Packet) error {
 	m.Count++
+	m.Packet = packet
 	return nil
 }
@@ -0,0 +1,255 @@
+// Package syslog implements an adapter that sends the log messages to a
+// local or remote syslog daemon with the RFC 5424 format.
+//
+// The "network" config is "udp", "tcp", "unix", "unixgram" or empty, the
+// default, for the local daemon at "address", or at /dev/log,
+// /var/run/syslog or /var/run/log if no address is given. Over tcp and
+// unix stream sockets the messages are framed by octet counting (RFC
+// 6587). The "facility" is a name, e.g. "local0", user by default, and
+// "tag" the APP-NAME of the messages, the name of the program by default.
+//
+// The fields given with WithFields are sent as the structured data
+// element fields@32473 of the message.
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nuveo/log"
+)
+
+// Severities of RFC 5424
+const (
+	severityCritical = 2
+	severityError    = 3
+	severityWarning  = 4
+	severityNotice   = 5
+	severityInfo     = 6
+	severityDebug    = 7
+)
+
+// RFC5424TimeFormat is the timestamp of the messages
+const RFC5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3,
+	"auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// localPaths of the syslog socket, tried in order
+var localPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+type conn struct {
+	net.Conn
+	// stream connections frame the messages with their length
+	stream bool
+}
+
+var (
+	conns    = make(map[string]*conn)
+	lock     = sync.Mutex{}
+	hostname = "-"
+)
+
+func init() {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		hostname = h
+	}
+	log.AddAdapter("syslog", log.AdapterPod{
+		Adapter: syslogWrite,
+		Config:  map[string]interface{}{"facility": "user"},
+		Close:   closeConns,
+		Check:   checkSyslog,
+	})
+}
+
+func severity(m log.MsgType) int {
+	switch m {
+	case log.FatalLog, log.PanicLog:
+		return severityCritical
+	case log.ErrorLog:
+		return severityError
+	case log.WarningLog:
+		return severityWarning
+	case log.DebugLog:
+		return severityDebug
+	case log.EventLog:
+		return severityNotice
+	}
+	return severityInfo
+}
+
+func syslogWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+	msg, err := format(e, config)
+	if err != nil {
+		panic(err)
+	}
+	network, _ := config["network"].(string)
+	address, _ := config["address"].(string)
+	key := network + " " + address
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// try again once with a new connection, the daemon may have been
+	// restarted
+	for i := 0; i < 2; i++ {
+		c, ok := conns[key]
+		if !ok {
+			if c, err = dial(network, address); err != nil {
+				break
+			}
+			conns[key] = c
+		}
+		b := msg
+		if c.stream {
+			b = strconv.Itoa(len(msg)) + " " + msg
+		}
+		if _, err = c.Write([]byte(b)); err == nil {
+			return
+		}
+		_ = c.Close()
+		delete(conns, key)
+	}
+	panic(err)
+}
+
+// format returns e as a RFC 5424 message
+func format(e *log.Entry, config map[string]interface{}) (string, error) {
+	facility := 1
+	if name, ok := config["facility"].(string); ok {
+		f, ok := facilities[name]
+		if !ok {
+			return "", fmt.Errorf("unknown facility %q", name)
+		}
+		facility = f
+	}
+	tag, _ := config["tag"].(string)
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %d - %s %s",
+		facility*8+severity(e.Type),
+		e.Time.Format(RFC5424TimeFormat),
+		hostname,
+		header(tag),
+		os.Getpid(),
+		structuredData(e),
+		strings.TrimSuffix(e.Message(), "\n")), nil
+}
+
+// header returns s as a header field, printable US-ASCII up to 48 chars
+func header(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < 48; i++ {
+		if s[i] > ' ' && s[i] < 0x7f {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+var paramEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// structuredData returns the fields of e as a structured data element
+func structuredData(e *log.Entry) string {
+	if len(e.Keys) == 0 {
+		return "-"
+	}
+	keys := append([]string(nil), e.Keys...)
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		name := strings.Map(func(r rune) rune {
+			if r <= ' ' || r >= 0x7f || r == '=' || r == ']' || r == '"' {
+				return -1
+			}
+			return r
+		}, k)
+		if name == "" {
+			continue
+		}
+		params = append(params, name+`="`+paramEscaper.Replace(fmt.Sprint(e.Fields[k]))+`"`)
+	}
+	return "[fields@32473 " + strings.Join(params, " ") + "]"
+}
+
+// dial connects to the syslog daemon
+func dial(network, address string) (*conn, error) {
+	switch network {
+	case "udp", "unixgram":
+		c, err := net.Dial(network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &conn{Conn: c}, nil
+	case "tcp", "unix":
+		c, err := net.Dial(network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &conn{Conn: c, stream: true}, nil
+	case "":
+		paths := localPaths
+		if address != "" {
+			paths = []string{address}
+		}
+		err := errors.New("syslog daemon not found")
+		for _, p := range paths {
+			for _, n := range []string{"unixgram", "unix"} {
+				var c *conn
+				if c, err = dial(n, p); err == nil {
+					return c, nil
+				}
+			}
+		}
+		return nil, err
+	}
+	return nil, fmt.Errorf("unknown network %q", network)
+}
+
+func closeConns(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	var err error
+	for key, c := range conns {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(conns, key)
+	}
+	return err
+}
+
+// checkSyslog verifies the config and that the daemon is reachable
+func checkSyslog(config map[string]interface{}) error {
+	if name, ok := config["facility"].(string); ok {
+		if _, ok := facilities[name]; !ok {
+			return fmt.Errorf("unknown facility %q", name)
+		}
+	}
+	network, _ := config["network"].(string)
+	address, _ := config["address"].(string)
+	c, err := dial(network, address)
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
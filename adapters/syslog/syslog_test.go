@@ -0,0 +1,107 @@
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func entry(m log.MsgType, msg string) *log.Entry {
+	return &log.Entry{Time: time.Date(2017, 6, 25, 15, 49, 4, 0, time.UTC), Type: m, Out: log.LineOut, Msg: []interface{}{msg}}
+}
+
+func TestFormat(t *testing.T) {
+	e := entry(log.WarningLog, "disk almost full")
+	e.Fields = log.Fields{"disk": "/dev/sda1", "note": `a "quoted] value`, "host": "web1"}
+	e.Keys = []string{"disk", "note"}
+
+	msg, err := format(e, map[string]interface{}{"facility": "local0", "tag": "my app"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := fmt.Sprintf(`<132>1 2017-06-25T15:49:04.000000Z %s myapp %d - [fields@32473 disk="/dev/sda1" note="a \"quoted\] value"] disk almost full`,
+		hostname, os.Getpid())
+	if msg != expected {
+		t.Fatalf("Error, expected %q, got %q", expected, msg)
+	}
+
+	if _, err = format(e, map[string]interface{}{"facility": "local9"}); err == nil {
+		t.Fatal("Error, expected error for unknown facility")
+	}
+}
+
+func TestSyslogWriteUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer pc.Close()
+
+	config := map[string]interface{}{"network": "udp", "address": pc.LocalAddr().String()}
+	defer closeConns(config)
+	syslogWrite(entry(log.ErrorLog, "test log"), config)
+
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+	b := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if msg := string(b[:n]); !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, " - - test log") {
+		t.Fatalf("Error, unexpected message %q", msg)
+	}
+}
+
+func TestSyslogWriteTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer l.Close()
+
+	msgs := make(chan string, 2)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		r := bufio.NewReader(c)
+		for {
+			s, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(s))
+			b := make([]byte, n)
+			if _, err = io.ReadFull(r, b); err != nil {
+				return
+			}
+			msgs <- string(b)
+		}
+	}()
+
+	config := map[string]interface{}{"network": "tcp", "address": l.Addr().String(), "facility": "daemon"}
+	defer closeConns(config)
+	syslogWrite(entry(log.MessageLog, "first"), config)
+	syslogWrite(entry(log.EventLog, "second"), config)
+
+	for _, expected := range []string{"<30>1 ", "<29>1 "} {
+		select {
+		case msg := <-msgs:
+			if !strings.HasPrefix(msg, expected) {
+				t.Fatalf("Error, expected prefix %q, got %q", expected, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Error, message not received")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package unix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/nuveo/log"
+)
+
+var (
+	conns = make(map[string]net.Conn)
+	lock  = sync.Mutex{}
+)
+
+func init() {
+	log.AddAdapter("unix", log.AdapterPod{
+		Adapter: unixWrite,
+		Config:  map[string]interface{}{"path": "/tmp/logsys.sock"},
+		Close:   closeConns,
+		Check:   checkSocket,
+	})
+}
+
+func unixWrite(e *log.Entry, config map[string]interface{}) {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		fmt.Println("error try to encode entry", err)
+		return
+	}
+	b = append(b, '\n')
+
+	path := config["path"].(string)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// try again once with a new connection, the receiver may have been
+	// restarted
+	for i := 0; i < 2; i++ {
+		c, ok := conns[path]
+		if !ok {
+			c, err = net.Dial("unix", path)
+			if err != nil {
+				break
+			}
+			conns[path] = c
+		}
+		if _, err = c.Write(b); err == nil {
+			return
+		}
+		_ = c.Close()
+		delete(conns, path)
+	}
+	fmt.Println("error try to send", err)
+}
+
+func closeConns(config map[string]interface{}) error {
+	lock.Lock()
+	defer lock.Unlock()
+	var err error
+	for path, c := range conns {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(conns, path)
+	}
+	return err
+}
+
+// checkSocket verifies that there is a receiver listening on the socket
+func checkSocket(config map[string]interface{}) error {
+	path, ok := config["path"].(string)
+	if !ok {
+		return errors.New("path not configured")
+	}
+	c, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
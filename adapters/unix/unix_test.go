@@ -0,0 +1,66 @@
+package unix
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestUnixWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer l.Close()
+
+	lines := make(chan []byte, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		s := bufio.NewScanner(c)
+		if s.Scan() {
+			lines <- s.Bytes()
+		}
+	}()
+
+	config := map[string]interface{}{"path": path}
+	unixWrite(&log.Entry{Seq: 7, Time: time.Unix(1498405744, 0), Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{"test log"}}, config)
+	defer closeConns(config)
+
+	select {
+	case b := <-lines:
+		e := &log.Entry{}
+		if err := json.Unmarshal(b, e); err != nil {
+			t.Fatal(err.Error())
+		}
+		if e.Seq != 7 || e.Type != log.ErrorLog || e.Message() != "test log" {
+			t.Fatalf("Error, unexpected entry %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error, entry not received")
+	}
+}
+
+func TestCheckSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	if err := checkSocket(map[string]interface{}{"path": path}); err == nil {
+		t.Fatal("Error, expected error without receiver")
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer l.Close()
+	if err := checkSocket(map[string]interface{}{"path": path}); err != nil {
+		t.Fatal(err.Error())
+	}
+}
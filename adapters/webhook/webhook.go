@@ -0,0 +1,265 @@
+// Package webhook implements adapters that notify the errors, fatal and
+// panic messages to a Slack incoming webhook, the "slack" adapter, or to
+// any HTTP endpoint, the "webhook" adapter, so the on-call engineers get
+// them without a separate alerting layer.
+//
+// The "url" config is the URL of the webhook. The "slack" adapter posts a
+// message with a line for each entry and the "webhook" adapter posts a
+// JSON object with the "source", the program and the host, the count of
+// the "dropped" entries and the "entries" formatted by log.JSONFormatter.
+// Both get only the errors, fatal and panic messages by default, change
+// the MinLevel of their AdapterPod for others.
+//
+// The entries are batched for "interval" (a time.Duration, 5 seconds by
+// default) after the first one and sent at most "maxPerMinute" times a
+// minute (an int, 6 by default), the entries that arrive meanwhile wait
+// for the next post. At most "bufferSize" entries (an int, 100 by
+// default) are kept, the count of the dropped ones is posted with the
+// next batch. log.Flush, called by log.Fatal, posts the batch waiting
+// right away. A failed post is retried with the next batch, its error is
+// returned by the next write or log.Flush.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// render returns the body of a post of the entries, with the count of the
+// entries dropped before them
+type render func(entries []*log.Entry, dropped int) ([]byte, error)
+
+// notifier batches the entries posted to a webhook
+type notifier struct {
+	url    string
+	render render
+
+	lock     sync.Mutex
+	entries  []*log.Entry
+	dropped  int
+	timer    *time.Timer
+	lastPost time.Time
+	err      error
+	// posting serializes the posts
+	posting sync.Mutex
+}
+
+var (
+	notifiers = make(map[string]*notifier)
+	lock      = sync.Mutex{}
+	client    = &http.Client{Timeout: 10 * time.Second}
+)
+
+func init() {
+	log.AddAdapter("slack", log.AdapterPod{
+		Write:    slackWrite,
+		Config:   map[string]interface{}{},
+		Flush:    flushNotifiers,
+		Close:    flushNotifiers,
+		Check:    checkURL,
+		MinLevel: log.ErrorLevel,
+	})
+	log.AddAdapter("webhook", log.AdapterPod{
+		Write:    webhookWrite,
+		Config:   map[string]interface{}{},
+		Flush:    flushNotifiers,
+		Close:    flushNotifiers,
+		Check:    checkURL,
+		MinLevel: log.ErrorLevel,
+	})
+}
+
+func intConfig(config map[string]interface{}, name string, def int) int {
+	if n, ok := config[name].(int); ok && n > 0 {
+		return n
+	}
+	return def
+}
+
+func slackWrite(e *log.Entry, config map[string]interface{}) error {
+	return write("slack", slackBody, e, config)
+}
+
+func webhookWrite(e *log.Entry, config map[string]interface{}) error {
+	return write("webhook", webhookBody, e, config)
+}
+
+// write adds e to the batch of the notifier of the kind and the url of
+// config, it returns the error of the last post that failed
+func write(kind string, r render, e *log.Entry, config map[string]interface{}) error {
+	if e.Type == log.DebugLog && !e.DebugEnabled() {
+		return nil
+	}
+	url, _ := config["url"].(string)
+	if url == "" {
+		return errors.New("webhook: url not configured")
+	}
+	lock.Lock()
+	n, ok := notifiers[kind+" "+url]
+	if !ok {
+		n = &notifier{url: url, render: r}
+		notifiers[kind+" "+url] = n
+	}
+	lock.Unlock()
+
+	interval, ok := config["interval"].(time.Duration)
+	if !ok {
+		interval = 5 * time.Second
+	}
+	spacing := time.Minute / time.Duration(intConfig(config, "maxPerMinute", 6))
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if len(n.entries) >= intConfig(config, "bufferSize", 100) {
+		n.entries = n.entries[1:]
+		n.dropped++
+	}
+	n.entries = append(n.entries, e)
+	if n.timer == nil {
+		wait := interval
+		if next := time.Until(n.lastPost.Add(spacing)); next > wait {
+			wait = next
+		}
+		n.timer = time.AfterFunc(wait, func() { _ = n.post() })
+	}
+	err := n.err
+	n.err = nil
+	return err
+}
+
+// post sends the entries waiting, they are kept for the next post if it
+// fails
+func (n *notifier) post() error {
+	n.posting.Lock()
+	defer n.posting.Unlock()
+	n.lock.Lock()
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+	entries, dropped := n.entries, n.dropped
+	n.entries, n.dropped = nil, 0
+	n.lastPost = time.Now()
+	n.lock.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := n.send(entries, dropped)
+	if err != nil {
+		n.lock.Lock()
+		n.entries = append(entries, n.entries...)
+		n.dropped += dropped
+		n.err = err
+		n.lock.Unlock()
+	}
+	return err
+}
+
+func (n *notifier) send(entries []*log.Entry, dropped int) error {
+	body, err := n.render(entries, dropped)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return errors.New("webhook: " + n.url + ": " + resp.Status)
+	}
+	return nil
+}
+
+// flushNotifiers posts the entries waiting in every notifier
+func flushNotifiers(config map[string]interface{}) error {
+	lock.Lock()
+	list := make([]*notifier, 0, len(notifiers))
+	for _, n := range notifiers {
+		list = append(list, n)
+	}
+	lock.Unlock()
+	var err error
+	for _, n := range list {
+		if e := n.post(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// checkURL validates the url config, nothing is posted
+func checkURL(config map[string]interface{}) error {
+	url, _ := config["url"].(string)
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return errors.New("webhook: url not configured")
+	}
+	return nil
+}
+
+// source is the program and the host of the notifications
+func source() string {
+	host, _ := os.Hostname()
+	return filepath.Base(os.Args[0]) + " on " + host
+}
+
+// slackLine returns the line of the Slack message of e
+func slackLine(e *log.Entry) string {
+	var b strings.Builder
+	b.WriteString("*[" + log.Prefixes[e.Type] + "]* ")
+	if e.Caller != "" {
+		b.WriteString("`" + e.Caller + "` ")
+	}
+	b.WriteString(strings.TrimSuffix(e.Message(), "\n"))
+	if kv := e.KeyValues(); kv != "" {
+		b.WriteString(" " + kv)
+	}
+	if e.Ref != "" {
+		b.WriteString(" (ref " + e.Ref + ")")
+	}
+	return b.String()
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackBody(entries []*log.Entry, dropped int) ([]byte, error) {
+	lines := []string{fmt.Sprintf("*%s*: %d new messages", slackEscaper.Replace(source()), len(entries)+dropped)}
+	for _, e := range entries {
+		lines = append(lines, slackEscaper.Replace(slackLine(e)))
+	}
+	if dropped > 0 {
+		lines = append(lines, fmt.Sprintf("_%d more messages dropped_", dropped))
+	}
+	return json.Marshal(map[string]string{"text": strings.Join(lines, "\n")})
+}
+
+func webhookBody(entries []*log.Entry, dropped int) ([]byte, error) {
+	src, err := json.Marshal(source())
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `{"source":%s,"dropped":%d,"entries":[`, src, dropped)
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strings.TrimSuffix(log.JSONFormatter(e), "\n"))
+	}
+	b.WriteString("]}")
+	return b.Bytes(), nil
+}
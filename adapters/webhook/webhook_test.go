@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+type server struct {
+	*httptest.Server
+	lock   sync.Mutex
+	bodies [][]byte
+	status int
+}
+
+func newServer() *server {
+	s := &server{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if s.status == http.StatusOK {
+			s.bodies = append(s.bodies, b)
+		}
+		w.WriteHeader(s.status)
+	}))
+	return s
+}
+
+func (s *server) posts() [][]byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([][]byte(nil), s.bodies...)
+}
+
+func entry(msg string) *log.Entry {
+	return &log.Entry{Time: time.Unix(1498405744, 0), Type: log.ErrorLog, Out: log.LineOut, Msg: []interface{}{msg}, Caller: "main.go:12"}
+}
+
+func TestSlack(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	config := map[string]interface{}{"url": s.URL, "interval": time.Hour, "bufferSize": 2}
+	for _, msg := range []string{"first", "second", "<third>"} {
+		if err := write("slack", slackBody, entry(msg), config); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if len(s.posts()) != 0 {
+		t.Fatal("Error, posted before the interval")
+	}
+	if err := flushNotifiers(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	posts := s.posts()
+	if len(posts) != 1 {
+		t.Fatalf("Error, %d posts", len(posts))
+	}
+	var msg map[string]string
+	if err := json.Unmarshal(posts[0], &msg); err != nil {
+		t.Fatal(err.Error())
+	}
+	lines := strings.Split(msg["text"], "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], ": 3 new messages") ||
+		lines[1] != "*[error]* `main.go:12` second" || lines[2] != "*[error]* `main.go:12` &lt;third&gt;" ||
+		lines[3] != "_1 more messages dropped_" {
+		t.Fatalf("Error, posted %q", msg["text"])
+	}
+}
+
+func TestWebhookBatching(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	config := map[string]interface{}{"url": s.URL, "interval": 10 * time.Millisecond, "maxPerMinute": 60000}
+	_ = write("webhook", webhookBody, entry("first"), config)
+	_ = write("webhook", webhookBody, entry("second"), config)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.posts()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Error, batch not posted after the interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var body struct {
+		Dropped int
+		Entries []map[string]interface{}
+	}
+	if err := json.Unmarshal(s.posts()[0], &body); err != nil {
+		t.Fatal(err.Error())
+	}
+	if body.Dropped != 0 || len(body.Entries) != 2 || body.Entries[0]["message"] != "first" || body.Entries[1]["message"] != "second" {
+		t.Fatalf("Error, posted %s", s.posts()[0])
+	}
+}
+
+func TestWebhookFailure(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	s.status = http.StatusInternalServerError
+	config := map[string]interface{}{"url": s.URL, "interval": time.Hour}
+	_ = write("webhook", webhookBody, entry("first"), config)
+	if err := flushNotifiers(config); err == nil {
+		t.Fatal("Error, expected the error of the endpoint")
+	}
+	if err := write("webhook", webhookBody, entry("second"), config); err == nil {
+		t.Fatal("Error, the next write didn't return the error of the post")
+	}
+	s.lock.Lock()
+	s.status = http.StatusOK
+	s.lock.Unlock()
+	if err := flushNotifiers(config); err != nil {
+		t.Fatal(err.Error())
+	}
+	posts := s.posts()
+	if len(posts) != 1 || !strings.Contains(string(posts[0]), `"message":"first"`) || !strings.Contains(string(posts[0]), `"message":"second"`) {
+		t.Fatalf("Error, posted %q", posts)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	if err := checkURL(map[string]interface{}{}); err == nil {
+		t.Fatal("Error, expected error without url")
+	}
+	if err := checkURL(map[string]interface{}{"url": "https://hooks.slack.com/services/T/B/X"}); err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+)
+
+var (
+	// AnonymizeKey enables the anonymization mode when it is not empty:
+	// IPs, emails and the values of UserIDFields are replaced in every
+	// entry by pseudonyms derived from the key, so the logs can be shared
+	// without the user data while the same value still has the same
+	// pseudonym in all entries. Keep the key secret, anyone who knows it
+	// can verify a guess of the original value.
+	AnonymizeKey []byte
+
+	// UserIDFields are the names of the fields whose values are user IDs
+	UserIDFields = []string{"user", "user.id", "user_id", "uid"}
+)
+
+var (
+	emailRE = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipv4RE  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6RE  = regexp.MustCompile(`(?i)(?:^|[^\w:])((?:[0-9a-f]{0,4}:){2,7}(?:\d{1,3}(?:\.\d{1,3}){3}|[0-9a-f]{0,4}))(?:$|[^\w:])`)
+)
+
+// Pseudonym returns the pseudonym of value, the kind followed by a keyed
+// hash of the value, e.g. "ip-1a2b3c4d5e6f".
+func Pseudonym(kind, value string) string {
+	mac := hmac.New(sha256.New, AnonymizeKey)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return kind + "-" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// Anonymize replaces the emails and IPs found in s by their pseudonyms
+func Anonymize(s string) string {
+	s = emailRE.ReplaceAllStringFunc(s, func(m string) string {
+		return Pseudonym("email", m)
+	})
+	out := make([]byte, 0, len(s))
+	last := 0
+	for _, m := range ipv6RE.FindAllStringSubmatchIndex(s, -1) {
+		ip := s[m[2]:m[3]]
+		if net.ParseIP(ip) == nil {
+			continue
+		}
+		out = append(out, s[last:m[2]]...)
+		out = append(out, Pseudonym("ip", ip)...)
+		last = m[3]
+	}
+	s = string(append(out, s[last:]...))
+	return ipv4RE.ReplaceAllStringFunc(s, func(m string) string {
+		if net.ParseIP(m) == nil {
+			return m
+		}
+		return Pseudonym("ip", m)
+	})
+}
+
+// anonError presents an error, and its causes, with the messages
+// anonymized.
+type anonError struct {
+	err error
+}
+
+func (e anonError) Error() string {
+	return Anonymize(e.err.Error())
+}
+
+func (e anonError) Unwrap() []error {
+	c := causes(e.err)
+	for i := range c {
+		c[i] = anonError{c[i]}
+	}
+	return c
+}
+
+// anonymize replaces the user data of the entry by pseudonyms
+func (e *Entry) anonymize() {
+	msg := make([]interface{}, len(e.Msg))
+	for i, m := range e.Msg {
+		switch v := m.(type) {
+		case string:
+			msg[i] = Anonymize(v)
+		case error:
+			msg[i] = anonError{v}
+		default:
+			if s := fmt.Sprint(v); Anonymize(s) != s {
+				msg[i] = Anonymize(s)
+			} else {
+				msg[i] = v
+			}
+		}
+	}
+	e.Msg = msg
+
+	for k, v := range e.Fields {
+		if isUserIDField(k) {
+			e.Fields[k] = Pseudonym("user", fmt.Sprint(v))
+		} else if s, ok := v.(string); ok {
+			e.Fields[k] = Anonymize(s)
+		}
+	}
+}
+
+func isUserIDField(name string) bool {
+	for _, f := range UserIDFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
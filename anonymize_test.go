@@ -0,0 +1,60 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAnonymize(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	AnonymizeKey = []byte("secret")
+
+	ip := Pseudonym("ip", "10.0.0.1")
+	if !regexp.MustCompile("^ip-[0-9a-f]{12}$").MatchString(ip) {
+		t.Fatalf("Error, pseudonym %q", ip)
+	}
+
+	testCases := []struct {
+		in       string
+		expected string
+	}{
+		{"login from 10.0.0.1 failed", "login from " + ip + " failed"},
+		{"addr=10.0.0.1:8080", "addr=" + ip + ":8080"},
+		{"mail to john.doe@example.com", "mail to " + Pseudonym("email", "john.doe@example.com")},
+		{"peer [2001:db8::1]:443", "peer [" + Pseudonym("ip", "2001:db8::1") + "]:443"},
+		{"at 15:04:05 version 1.2 std::vector", "at 15:04:05 version 1.2 std::vector"},
+	}
+	for _, tc := range testCases {
+		if got := Anonymize(tc.in); got != tc.expected {
+			t.Errorf("Error, Anonymize(%q) = %q, expected %q", tc.in, got, tc.expected)
+		}
+	}
+
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		return Fields{"user_id": 42, "client": "10.0.0.1"}, nil
+	}), 0)
+	err := fmt.Errorf("request from 10.0.0.1: %w", errors.New("user a@b.io denied"))
+	e := newEntry(ErrorLog, FormattedOut, "%v %v", net.ParseIP("10.0.0.1"), err)
+	msg := e.Message()
+	if strings.Contains(msg, "10.0.0.1") || !strings.Contains(msg, ip) {
+		t.Fatalf("Error, message %q not anonymized", msg)
+	}
+	for _, l := range errorLines(e.Out, e.Msg...) {
+		if strings.Contains(l, "a@b.io") {
+			t.Fatalf("Error, cause %q not anonymized", l)
+		}
+	}
+	if e.Fields["user_id"] != Pseudonym("user", "42") || e.Fields["client"] != ip {
+		t.Fatalf("Error, fields %v not anonymized", e.Fields)
+	}
+
+	AnonymizeKey = []byte("other")
+	if Pseudonym("ip", "10.0.0.1") == ip {
+		t.Fatal("Error, pseudonym doesn't depend on the key")
+	}
+}
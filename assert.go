@@ -0,0 +1,92 @@
+package log
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// StrictAssertions makes the failed assertions fatal: Assert and
+// AssertNoErr log a FatalLog, flush the adapters and exit with status 1.
+// Enable it in tests and staging, where a broken invariant should stop
+// the program.
+var StrictAssertions bool
+
+// Assert logs an error with the caller and the stack if cond is false,
+// msg describes the violated condition. The program goes on, unless
+// StrictAssertions is set.
+//
+//	log.Assert(len(queue) <= max, "queue over its limit:", len(queue))
+func Assert(cond bool, msg ...interface{}) {
+	if !cond {
+		std.assert(nil, assertText(msg))
+	}
+}
+
+// AssertNoErr logs err like Assert if it is not nil, for the errors that
+// should never happen.
+func AssertNoErr(err error) {
+	if err != nil {
+		std.assert(nil, ": unexpected error: ", err)
+	}
+}
+
+// Assert works like log.Assert logging to the adapters of l
+func (l *Logger) Assert(cond bool, msg ...interface{}) {
+	if !cond {
+		l.assert(nil, assertText(msg))
+	}
+}
+
+// AssertNoErr works like log.AssertNoErr logging to the adapters of l
+func (l *Logger) AssertNoErr(err error) {
+	if err != nil {
+		l.assert(nil, ": unexpected error: ", err)
+	}
+}
+
+// Assert works like log.Assert adding the fields of l
+func (l *FieldLogger) Assert(cond bool, msg ...interface{}) {
+	if !cond {
+		l.logger.assert(l.fields, assertText(msg))
+	}
+}
+
+// AssertNoErr works like log.AssertNoErr adding the fields of l
+func (l *FieldLogger) AssertNoErr(err error) {
+	if err != nil {
+		l.logger.assert(l.fields, ": unexpected error: ", err)
+	}
+}
+
+// assertText returns the text of the messages of Assert spaced as by
+// Println
+func assertText(msg []interface{}) string {
+	if len(msg) == 0 {
+		return ""
+	}
+	return ": " + strings.TrimSuffix(fmt.Sprintln(msg...), "\n")
+}
+
+func (l *Logger) assert(fields []field, msg ...interface{}) {
+	m := ErrorLog
+	if StrictAssertions {
+		m = FatalLog
+	}
+	e := l.newEntry(fields, m, LineOut, append([]interface{}{"assertion failed"}, msg...)...)
+	// the frames of debug.Stack, assert and the function called
+	frames := parseStack(debug.Stack())
+	if len(frames) > 3 {
+		frames = frames[3:]
+	}
+	if MaxStackFrames > 0 && len(frames) > MaxStackFrames {
+		frames = frames[:MaxStackFrames]
+	}
+	e.Stack = frames
+	dispatch(e)
+	if StrictAssertions {
+		runExitHandlers()
+		_ = l.Flush()
+		exit(1)
+	}
+}
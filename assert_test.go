@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAssert(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	code := 0
+	exit = func(c int) { code = c }
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	Assert(true, "never logged")
+	AssertNoErr(nil)
+	Assert(1+1 == 3, "math is broken:", 2)
+	WithField("job", 7).AssertNoErr(fmt.Errorf("save: %w", errors.New("disk full")))
+	if len(entries) != 2 {
+		t.Fatalf("Error, got %d entries, expected 2", len(entries))
+	}
+
+	e := entries[0]
+	if e.Type != ErrorLog || e.Message() != "assertion failed: math is broken: 2" || e.Caller != "assert_test.go:26" {
+		t.Fatalf("Error, got %v %q at %s", e.Type, e.Message(), e.Caller)
+	}
+	if len(e.Stack) == 0 || !strings.HasSuffix(e.Stack[0].Function, ".TestAssert") {
+		t.Fatalf("Error, expected the stack to start at the test, got %v", e.Stack)
+	}
+	e = entries[1]
+	if e.Message() != "assertion failed: unexpected error: save: disk full" || e.Fields["job"] != 7 {
+		t.Fatalf("Error, got %q with %v", e.Message(), e.Fields)
+	}
+	if causes := entryCauses(e); len(causes) != 1 || causes[0] != "disk full" {
+		t.Fatalf("Error, got the causes %q", causes)
+	}
+	if code != 0 {
+		t.Fatalf("Error, exited with %d", code)
+	}
+	if out := buf.String(); !strings.Contains(out, "[error] assertion failed: math is broken: 2") ||
+		!strings.Contains(out, "[error] assertion failed: unexpected error: save: disk full job=7") {
+		t.Fatalf("Error, printed %q", out)
+	}
+
+	StrictAssertions = true
+	Assert(false)
+	if len(entries) != 3 || entries[2].Type != FatalLog || entries[2].Message() != "assertion failed" || code != 1 {
+		t.Fatalf("Error, the strict assertion exited with %d", code)
+	}
+}
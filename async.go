@@ -0,0 +1,133 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// asyncBuffer is the bounded buffer of the entries of the default logger
+// in the async mode, written to the adapters by a background goroutine
+type asyncBuffer struct {
+	entries chan *Entry
+	// lock guards closed, push holds it for reading so entries are not
+	// sent to the closed channel
+	lock    sync.RWMutex
+	closed  bool
+	stopped chan struct{}
+
+	pending     int
+	pendingLock sync.Mutex
+	done        *sync.Cond
+}
+
+// async is not nil while the async mode is started, guarded by lock
+var async *asyncBuffer
+
+// StartAsync makes the logging functions of the default logger return
+// without waiting for the adapters: the entries are queued in a buffer of
+// bufferSize entries and written to the adapters, or to the workers of
+// StartWorkers, by a background goroutine. The caller blocks only while
+// the buffer is full, or drops the entry below the error level if
+// DropOnFullQueue is set. Flush waits for the buffered entries and Close
+// also stops the async mode, call one of them before the program exits.
+func StartAsync(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	b := &asyncBuffer{
+		entries: make(chan *Entry, bufferSize),
+		stopped: make(chan struct{}),
+	}
+	b.done = sync.NewCond(&b.pendingLock)
+	go b.run()
+	lock.Lock()
+	old := async
+	async = b
+	lock.Unlock()
+	if old != nil {
+		old.stop()
+	}
+}
+
+// StopAsync writes the buffered entries and makes the logging functions
+// wait for the adapters again.
+func StopAsync() {
+	lock.Lock()
+	b := async
+	async = nil
+	lock.Unlock()
+	if b != nil {
+		b.stop()
+	}
+}
+
+// asyncBuffered returns the buffer of the async mode, nil if it is stopped
+func asyncBuffered() *asyncBuffer {
+	lock.RLock()
+	defer lock.RUnlock()
+	return async
+}
+
+// waitAsync waits for the entries buffered when it is called
+func waitAsync() {
+	if b := asyncBuffered(); b != nil {
+		b.wait()
+	}
+}
+
+// push adds e to the buffer, it reports false if the buffer was stopped
+func (b *asyncBuffer) push(e *Entry) bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		return false
+	}
+	b.pendingLock.Lock()
+	b.pending++
+	b.pendingLock.Unlock()
+	if DropOnFullQueue && !e.Type.IsError() {
+		select {
+		case b.entries <- e:
+		default:
+			atomic.AddUint64(&dropped, 1)
+			b.finish()
+		}
+		return true
+	}
+	b.entries <- e
+	return true
+}
+
+func (b *asyncBuffer) finish() {
+	b.pendingLock.Lock()
+	if b.pending--; b.pending == 0 {
+		b.done.Broadcast()
+	}
+	b.pendingLock.Unlock()
+}
+
+func (b *asyncBuffer) wait() {
+	b.pendingLock.Lock()
+	for b.pending > 0 {
+		b.done.Wait()
+	}
+	b.pendingLock.Unlock()
+}
+
+func (b *asyncBuffer) stop() {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.entries)
+	}
+	b.lock.Unlock()
+	<-b.stopped
+}
+
+func (b *asyncBuffer) run() {
+	defer close(b.stopped)
+	for e := range b.entries {
+		deliver(std, e)
+		b.finish()
+	}
+}
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	release := make(chan struct{})
+	AddAdapter("slow", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		<-release
+		mu.Lock()
+		got = append(got, e.Message())
+		mu.Unlock()
+	}})
+
+	StartAsync(10)
+	defer StopAsync()
+
+	done := make(chan struct{})
+	go func() {
+		for _, msg := range []string{"a", "b", "c"} {
+			Println(msg)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Error, the logging functions wait for the slow adapter")
+	}
+
+	close(release)
+	if err := Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+	mu.Lock()
+	if strings.Join(got, " ") != "a b c" {
+		t.Fatalf("Error, written %q after Flush, expected \"a b c\"", strings.Join(got, " "))
+	}
+	mu.Unlock()
+
+	if err := Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if asyncBuffered() != nil {
+		t.Fatal("Error, Close must stop the async mode")
+	}
+	Println("d")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 4 {
+		t.Fatalf("Error, entry logged after Close not written, got %q", got)
+	}
+}
+
+func TestAsyncDrop(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+	DropOnFullQueue = true
+	defer func() { DropOnFullQueue = false }()
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	release := make(chan struct{})
+	AddAdapter("slow", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		<-release
+		mu.Lock()
+		got = append(got, e.Message())
+		mu.Unlock()
+	}})
+
+	StartAsync(2)
+	defer StopAsync()
+
+	before := Dropped()
+	Println("first") // taken by the goroutine, blocked in the adapter
+	time.Sleep(10 * time.Millisecond)
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		Println(msg)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	Errorln("e1") // waits for room in the buffer
+	if err := Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if d := Dropped() - before; d != 2 {
+		t.Fatalf("Error, expected 2 dropped entries, got %d", d)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	expected := "first a b e1"
+	if strings.Join(got, " ") != expected {
+		t.Fatalf("Error, written %q, expected %q", strings.Join(got, " "), expected)
+	}
+}
+
+func TestAsyncCaller(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	DebugMode = true
+	timeFormated := now().Format(TimeFormat)
+
+	StartAsync(1)
+	defer StopAsync()
+	err := validate("Println", func(msg ...interface{}) {
+		Println(msg...)
+		_ = Flush()
+	}, "\x1b\\[37m"+timeFormated+" \\[msg\\] async_test.go:\\d+ text\x1b\\[0;00m\n", "text")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,31 @@
+package log
+
+import "strings"
+
+// BlockPrefix marks the lines of the text of Block in TextFormatter
+var BlockPrefix = "| "
+
+// Block logs the multiline text, e.g. YAML or SQL, as a message of type
+// m. TextFormatter writes every line with the timestamp and the level,
+// marked by BlockPrefix and not truncated by MaxLineSize, and the
+// structured formatters keep the text as a single message. The trailing
+// line break of text is removed.
+func Block(m MsgType, text string) {
+	std.block(nil, m, text)
+}
+
+// Block works like log.Block logging to the adapters of l
+func (l *Logger) Block(m MsgType, text string) {
+	l.block(nil, m, text)
+}
+
+// Block works like log.Block adding the fields of l
+func (l *FieldLogger) Block(m MsgType, text string) {
+	l.logger.block(l.fields, m, text)
+}
+
+func (l *Logger) block(fields []field, m MsgType, text string) {
+	e := l.newEntry(fields, m, LineOut, strings.TrimSuffix(text, "\n"))
+	e.block = true
+	dispatch(e)
+}
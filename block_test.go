@@ -0,0 +1,46 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMultiline(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetMaxLineSize(59)
+
+	WithField("table", "users").Warningln("slow query:\nSELECT *\nFROM users")
+	WithField("table", "users").Block(DebugLog, "SELECT *\nFROM users WHERE name = 'a very long name'\n")
+	DebugMode = true
+	WithField("table", "users").Block(DebugLog, "SELECT *\nFROM users WHERE name = 'a very long name'\n")
+
+	ts := now().Format(TimeFormat)
+	expected := ts + " [warning] slow query: table=users\n" +
+		ts + " [warning] SELECT *\n" +
+		ts + " [warning] FROM users\n" +
+		ts + " [debug] block_test.go:21 | SELECT * table=users\n" +
+		ts + " [debug] | FROM users WHERE name = 'a very long name'\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	OutputFormat = JSONFormatter
+	Block(MessageLog, "a: 1\nb: 2\n")
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("Error, expected a single line, got %q", buf.String())
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err.Error())
+	}
+	if line["message"] != "a: 1\nb: 2" {
+		t.Fatalf("Error, message %q", line["message"])
+	}
+}
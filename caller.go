@@ -0,0 +1,86 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// CallerStyle is how the callers of the entries are shown
+type CallerStyle int
+
+// Caller styles
+const (
+	// ShortCaller is the name of the file and the line, "log.go:12"
+	ShortCaller CallerStyle = iota
+	// PackageCaller adds the import path of the package of the function,
+	// "github.com/nuveo/log/log.go:12"
+	PackageCaller
+	// FullPathCaller is the path of the file where it was compiled,
+	// "/home/user/src/log/log.go:12"
+	FullPathCaller
+)
+
+var (
+	// ReportCaller shows the callers of the messages of all levels, by
+	// default TextFormatter and the file adapter only show them in the
+	// debug mode.
+	ReportCaller bool
+
+	// CallerSkip is the number of frames skipped above the caller of the
+	// log functions, e.g. 1 for the functions of a package that wraps
+	// this one, so the callers are the real call sites.
+	CallerSkip int
+
+	// CallerFormat is the style of the callers, ShortCaller by default
+	CallerFormat = ShortCaller
+)
+
+// ShowCaller reports if the caller should be shown with the message of
+// e, in the debug mode or if ReportCaller is set
+func (e *Entry) ShowCaller() bool {
+	return ReportCaller || e.DebugEnabled()
+}
+
+// caller returns the caller of the function skip frames above the caller
+// of caller, plus CallerSkip frames, formatted with CallerFormat, and the
+// path of its file
+func caller(skip int) (string, string) {
+	pc, fn, line, ok := runtime.Caller(skip + 1 + CallerSkip)
+	if !ok {
+		return "", ""
+	}
+	switch CallerFormat {
+	case PackageCaller:
+		if f := runtime.FuncForPC(pc); f != nil {
+			return fmt.Sprintf("%s/%s:%d", funcPackage(f.Name()), filepath.Base(fn), line), fn
+		}
+	case FullPathCaller:
+		return fmt.Sprintf("%s:%d", fn, line), fn
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(fn), line), fn
+}
+
+// funcPackage returns the import path of the package of the function
+// name, e.g. "github.com/nuveo/log" for "github.com/nuveo/log.(*Logger).Println".
+// The dots of the last element of the path are escaped as %2e in the name.
+func funcPackage(name string) string {
+	slash := strings.LastIndexByte(name, '/') + 1
+	if dot := strings.IndexByte(name[slash:], '.'); dot >= 0 {
+		name = name[:slash+dot]
+	}
+	return strings.Replace(name, "%2e", ".", -1)
+}
+
+// frameCaller returns the caller of the frame of a stack formatted with
+// CallerFormat, and the path of its file
+func frameCaller(f Frame) (string, string) {
+	switch CallerFormat {
+	case PackageCaller:
+		return fmt.Sprintf("%s/%s:%d", funcPackage(f.Function), filepath.Base(f.File), f.Line), f.File
+	case FullPathCaller:
+		return fmt.Sprintf("%s:%d", f.File, f.Line), f.File
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line), f.File
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+// wrappedPrintln is a wrapper of Println, as in the packages built on
+// this one
+func wrappedPrintln(msg ...interface{}) {
+	Println(msg...)
+}
+
+func TestReportCaller(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	testCases := []struct {
+		name     string
+		setup    func()
+		logFunc  func(msg ...interface{})
+		expected string
+	}{
+		{"default", func() {}, Println, `^\S+ \S+ \[msg\] log test\n$`},
+		{"ReportCaller", func() { ReportCaller = true }, Warningln, `^\S+ \S+ \[warning\] caller_test.go:\d+ log test\n$`},
+		{"wrapper", func() {}, wrappedPrintln, `^\S+ \S+ \[msg\] caller_test.go:12 log test\n$`},
+		{"CallerSkip", func() { CallerSkip = 1 }, wrappedPrintln, `^\S+ \S+ \[msg\] caller_test.go:3\d log test\n$`},
+		{"PackageCaller", func() { CallerSkip, CallerFormat = 0, PackageCaller }, Println, `^\S+ \S+ \[msg\] github.com/nuveo/log/caller_test.go:\d+ log test\n$`},
+		{"FullPathCaller", func() { CallerFormat = FullPathCaller }, Errorln, `^\S+ \S+ \[error\] \S+/caller_test.go:\d+ log test\n$`},
+	}
+	for _, tc := range testCases {
+		tc.setup()
+		buf.Reset()
+		tc.logFunc("log test")
+		if !regexp.MustCompile(tc.expected).MatchString(buf.String()) {
+			t.Fatalf("Error, %s printed %q, expected %q", tc.name, buf.String(), tc.expected)
+		}
+	}
+}
+
+func TestFuncPackage(t *testing.T) {
+	testCases := map[string]string{
+		"github.com/nuveo/log.(*Logger).Println": "github.com/nuveo/log",
+		"github.com/nuveo/log.TestX.func1":       "github.com/nuveo/log",
+		"main.main":                              "main",
+		"gopkg.in/yaml%2ev3.Marshal":             "gopkg.in/yaml.v3",
+	}
+	for name, expected := range testCases {
+		if p := funcPackage(name); p != expected {
+			t.Fatalf("Error, funcPackage(%q) = %q, expected %q", name, p, expected)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+var (
+	tcEscaper = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]",
+		"\u0085", "|x", "\u2028", "|l", "\u2029", "|p")
+	mdEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+		"<", "&lt;", ">", "&gt;")
+)
+
+// TeamCityFormatter formats the entries as service messages of TeamCity,
+// e.g.
+//
+//	##teamcity[message text='copy failed' errorDetails='disk full' status='ERROR']
+//
+// The errors, fatal and panic messages have the ERROR status, shown in red
+// in the build log, and the warnings the WARNING status. The causes of
+// the errors are the details.
+func TeamCityFormatter(e *Entry) string {
+	status := "NORMAL"
+	switch {
+	case e.Type.IsError():
+		status = "ERROR"
+	case e.Type == WarningLog:
+		status = "WARNING"
+	}
+	msg := strings.TrimSuffix(e.Message(), "\n")
+	if kv := e.KeyValues(); kv != "" {
+		msg += " " + kv
+	}
+	if e.Ref != "" {
+		msg += " (ref " + e.Ref + ")"
+	}
+	if e.Caller != "" && e.ShowCaller() {
+		msg = e.Caller + " " + msg
+	}
+	details := ""
+	if causes := entryCauses(e); len(causes) > 0 {
+		details = " errorDetails='" + tcEscaper.Replace(strings.Join(causes, "\n")) + "'"
+	}
+	return "##teamcity[message text='" + tcEscaper.Replace(msg) + "'" + details + " status='" + status + "']\n"
+}
+
+// BuildkiteFormatter formats the entries with TextFormatter, expanding the
+// collapsed section of the Buildkite log with the errors, fatal and panic
+// messages so they are seen without opening it. See AnnotateBuildkite to
+// show the collected diagnostics at the top of the build page.
+func BuildkiteFormatter(e *Entry) string {
+	s := TextFormatter(e)
+	if e.Type.IsError() {
+		s += "^^^ +++\n"
+	}
+	return s
+}
+
+// AutoCI sets OutputFormat to the formatter of the CI system the program
+// runs in, detected by its environment variables: GitHubActionsFormatter
+// in GitHub Actions, TeamCityFormatter in TeamCity and BuildkiteFormatter
+// in Buildkite. It returns false, leaving OutputFormat untouched, out of
+// them.
+func AutoCI() bool {
+	switch {
+	case AutoGitHubActions():
+	case os.Getenv("TEAMCITY_VERSION") != "":
+		OutputFormat = TeamCityFormatter
+	case os.Getenv("BUILDKITE") == "true":
+		OutputFormat = BuildkiteFormatter
+	default:
+		return false
+	}
+	return true
+}
+
+// WriteBuildkiteAnnotation writes the collected diagnostics to w as the
+// Markdown of a Buildkite annotation, grouped by file like
+// WriteDiagnostics.
+func WriteBuildkiteAnnotation(w io.Writer) error {
+	var b strings.Builder
+	errs, warnings := 0, 0
+	file := ""
+	for i, d := range Diagnostics() {
+		if d.Type == ErrorLog {
+			errs++
+		} else {
+			warnings++
+		}
+		if i == 0 || d.File != file {
+			file = d.File
+			if file == "" {
+				b.WriteString("\n#### (no file)\n\n")
+			} else {
+				b.WriteString("\n#### " + mdEscaper.Replace(file) + "\n\n")
+			}
+		}
+		b.WriteString("- ")
+		if pos := d.position(); pos != "" {
+			b.WriteString("`" + pos + "` ")
+		}
+		b.WriteString("**" + Prefixes[d.Type] + "** " + mdEscaper.Replace(d.Message))
+		if d.Rule != "" {
+			b.WriteString(" (" + mdEscaper.Replace(d.Rule) + ")")
+		}
+		b.WriteString("\n")
+	}
+	if errs+warnings == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "**%d errors, %d warnings**\n%s", errs, warnings, b.String())
+	return err
+}
+
+// buildkiteAgent is the command that creates the annotations
+var buildkiteAgent = "buildkite-agent"
+
+// AnnotateBuildkite shows the collected diagnostics in an annotation of
+// the Buildkite build, with the error style if there are errors and the
+// warning style otherwise, created with buildkite-agent. The annotations
+// with the same context are replaced, use one context for every step
+// that annotates. It does nothing if there are no diagnostics.
+func AnnotateBuildkite(context string) error {
+	var body bytes.Buffer
+	if err := WriteBuildkiteAnnotation(&body); err != nil || body.Len() == 0 {
+		return err
+	}
+	style := "warning"
+	for _, d := range Diagnostics() {
+		if d.Type == ErrorLog {
+			style = "error"
+			break
+		}
+	}
+	cmd := exec.Command(buildkiteAgent, "annotate", "--style", style, "--context", context)
+	cmd.Stdin = &body
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
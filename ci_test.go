@@ -0,0 +1,131 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+)
+
+func TestTeamCityFormatter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	OutputFormat = TeamCityFormatter
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	Warningln("it's [deprecated]|old")
+	Errorln("copy failed: ", fmt.Errorf("write: %w", errors.New("disk full")))
+	Println("done")
+
+	expected := "##teamcity[message text='it|'s |[deprecated|]||old' status='WARNING']\n" +
+		"##teamcity[message text='copy failed: write: disk full' errorDetails='disk full' status='ERROR']\n" +
+		"##teamcity[message text='done' status='NORMAL']\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote\n%s", buf.String())
+	}
+}
+
+func TestBuildkiteFormatter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	OutputFormat = BuildkiteFormatter
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	Println("done")
+	Errorln("failed")
+
+	expected := regexp.MustCompile(`^\S+ \S+ \[msg\] done
+\S+ \S+ \[error\] failed
+\^\^\^ \+\+\+
+$`)
+	if !expected.MatchString(buf.String()) {
+		t.Fatalf("Error, wrote\n%s", buf.String())
+	}
+}
+
+func TestAutoCI(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	for _, v := range []string{"GITHUB_ACTIONS", "TEAMCITY_VERSION", "BUILDKITE"} {
+		defer os.Setenv(v, os.Getenv(v))
+		os.Setenv(v, "")
+	}
+	if AutoCI() {
+		t.Fatal("Error, detected a CI system without its variables")
+	}
+	os.Setenv("BUILDKITE", "true")
+	if !AutoCI() || fmt.Sprintf("%p", OutputFormat) != fmt.Sprintf("%p", BuildkiteFormatter) {
+		t.Fatal("Error, BuildkiteFormatter not set in Buildkite")
+	}
+	os.Setenv("TEAMCITY_VERSION", "2023.05")
+	if !AutoCI() || fmt.Sprintf("%p", OutputFormat) != fmt.Sprintf("%p", TeamCityFormatter) {
+		t.Fatal("Error, TeamCityFormatter not set in TeamCity")
+	}
+}
+
+func TestAnnotateBuildkite(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	defer ResetDiagnostics()
+	ResetDiagnostics()
+	CollectDiagnostics(true)
+	defer CollectDiagnostics(false)
+
+	if err := AnnotateBuildkite("lint"); err != nil {
+		t.Fatalf("Error, annotated without diagnostics: %v", err)
+	}
+
+	At("main.go", 12, 3).WithField(RuleKey, "unused").Warningln("unused variable *x*")
+	At("main.go", 4, 0).Errorln("missing <return>")
+	Errorln("build failed")
+
+	var buf bytes.Buffer
+	if err := WriteBuildkiteAnnotation(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "**2 errors, 1 warnings**\n" +
+		"\n#### (no file)\n\n" +
+		"- **error** build failed\n" +
+		"\n#### main.go\n\n" +
+		"- `4` **error** missing &lt;return&gt;\n" +
+		"- `12:3` **warning** unused variable \\*x\\* (unused)\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	dir, err := ioutil.TempDir("", "buildkite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "annotation")
+	agent := filepath.Join(dir, "buildkite-agent")
+	script := "#!/bin/sh\necho \"$@\" > " + out + "\ncat >> " + out + "\n"
+	if err = ioutil.WriteFile(agent, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	buildkiteAgent = agent
+	defer func() { buildkiteAgent = "buildkite-agent" }()
+	if err = AnnotateBuildkite("lint"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "annotate --style error --context lint\n"+expected {
+		t.Fatalf("Error, buildkite-agent got %q", b)
+	}
+}
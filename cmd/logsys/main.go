@@ -0,0 +1,75 @@
+// Command logsys provides tools to work with the logs of
+// github.com/nuveo/log.
+//
+// Usage:
+//
+//	logsys tail [file]
+//	logsys query [-since t] [-until t] [-level l] [-tag t] [-text s] file...
+//
+// tail follows the file, or stdin if no file or "-" is given, in an
+// interactive viewer. The lines can be JSON entries, as written by the
+// unix adapter or JSONFormatter, or text lines as written by the stdout
+// and file adapters. Keys:
+//
+//	1-5        show levels from debug (1) to fatal (5)
+//	/          search, Enter applies and Esc clears the search
+//	space      pause and resume following the file
+//	up, down   move the selection, pausing (also k, j, PgUp and PgDn)
+//	g, G       go to the first entry, go to the last one and resume
+//	enter      show or hide the fields of the selected entry
+//	q          quit
+//
+// query writes the entries of the files that match in the console
+// format, file by file. The files can be binlog files, the ones written
+// by the binlog adapter, or have JSON entries or text messages, as
+// written by the file adapter, plain or gzip compressed. -since and
+// -until are times in RFC 3339 or log.TimeFormat, in the local time, or
+// durations before now, e.g. -since 1h; the binlog files are searched by
+// their time index. -level is the minimum level, -tag the tag of the
+// entries, see log.Tagged, and -text a text searched ignoring the case.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: logsys tail [file]")
+	fmt.Fprintln(os.Stderr, "       logsys query [-since t] [-until t] [-level l] [-tag t] [-text s] file...")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "tail":
+		name := "-"
+		if len(os.Args) > 2 {
+			name = os.Args[2]
+		}
+		if err := tail(name); err != nil {
+			fmt.Fprintln(os.Stderr, "logsys:", err)
+			os.Exit(1)
+		}
+	case "query":
+		q, names, err := parseQuery(os.Args[2:], time.Now())
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logsys:", err)
+			os.Exit(2)
+		}
+		if err = q.run(os.Stdout, names); err != nil {
+			fmt.Fprintln(os.Stderr, "logsys:", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
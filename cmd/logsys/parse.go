@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// item is a line of the followed file
+type item struct {
+	// text of the line as shown in the list
+	text   string
+	msg    log.MsgType
+	fields []string
+}
+
+// line is the subset of the JSON entries shown by the viewer, both
+// Entry.MarshalJSON and JSONFormatter write these keys
+type line struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Caller  string                 `json:"caller"`
+	Context string                 `json:"context"`
+	Ref     string                 `json:"ref"`
+	ID      string                 `json:"id"`
+	Fields  map[string]interface{} `json:"fields"`
+}
+
+var levelTag = regexp.MustCompile(`\[([a-z]+)\]`)
+
+// parseLine parses a JSON entry or a text line
+func parseLine(s string) item {
+	s = strings.TrimRight(s, "\r\n")
+	var l line
+	if strings.HasPrefix(s, "{") && json.Unmarshal([]byte(s), &l) == nil && l.Level != "" {
+		it := item{msg: msgType(l.Level)}
+		it.text = fmt.Sprintf("%s [%s] %s", l.Time.Local().Format(log.TimeFormat), l.Level, l.Message)
+		if l.Caller != "" {
+			it.fields = append(it.fields, "caller="+l.Caller)
+		}
+		if l.Context != "" {
+			it.fields = append(it.fields, "context="+l.Context)
+		}
+		if l.Ref != "" {
+			it.fields = append(it.fields, "ref="+l.Ref)
+		}
+		if l.ID != "" {
+			it.fields = append(it.fields, "id="+l.ID)
+		}
+		keys := make([]string, 0, len(l.Fields))
+		for k := range l.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			it.fields = append(it.fields, fmt.Sprintf("%s=%v", k, l.Fields[k]))
+		}
+		return it
+	}
+	it := item{text: s, msg: log.MessageLog}
+	if m := levelTag.FindStringSubmatch(s); m != nil {
+		it.msg = msgType(m[1])
+	}
+	return it
+}
+
+// msgType returns the type of the level name, MessageLog if unknown
+func msgType(level string) log.MsgType {
+	for t, p := range log.Prefixes {
+		if p == level {
+			return log.MsgType(t)
+		}
+	}
+	return log.MessageLog
+}
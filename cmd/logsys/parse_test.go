@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestParseLine(t *testing.T) {
+	it := parseLine(`{"time":"2026-01-02T03:04:05Z","level":"warning","message":"disk full","caller":"main.go:10","fields":{"path":"/tmp","free":0}}` + "\n")
+	if it.msg != log.WarningLog {
+		t.Fatalf("Error, type %v, expected %v", it.msg, log.WarningLog)
+	}
+	if !strings.HasSuffix(it.text, "[warning] disk full") {
+		t.Fatalf("Error, text %q", it.text)
+	}
+	expected := []string{"caller=main.go:10", "free=0", "path=/tmp"}
+	if strings.Join(it.fields, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Error, fields %q, expected %q", it.fields, expected)
+	}
+
+	it = parseLine("2026/01/02 03:04:05 [error] main.go:12 failed\n")
+	if it.msg != log.ErrorLog || it.text != "2026/01/02 03:04:05 [error] main.go:12 failed" {
+		t.Fatalf("Error, parsed %+v", it)
+	}
+
+	it = parseLine("{not json")
+	if it.msg != log.MessageLog || it.text != "{not json" {
+		t.Fatalf("Error, parsed %+v", it)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nuveo/log"
+	"github.com/nuveo/log/adapters/binlog"
+)
+
+// binlogHeader starts the files of the binlog adapter
+const binlogHeader = "NLOGBIN1"
+
+// query selects the entries of the log files
+type query struct {
+	since, until time.Time
+	level        log.Level
+	tag          string
+	// text is the text searched, lower case
+	text string
+}
+
+var textTag = regexp.MustCompile(`(?:^| )` + log.TagKey + `=(\S+)`)
+
+// parseQuery parses the flags of the query subcommand, it returns the
+// query and the files
+func parseQuery(args []string, now time.Time) (*query, []string, error) {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	since := fs.String("since", "", "show the entries from this time or for this duration until now, e.g. 1h")
+	until := fs.String("until", "", "show the entries before this time or older than this duration")
+	level := fs.String("level", "debug", "show the entries of this level or above")
+	tag := fs.String("tag", "", "show the entries of this tag")
+	text := fs.String("text", "", "show the entries with this text, ignoring the case")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if fs.NArg() == 0 {
+		return nil, nil, errors.New("no files given")
+	}
+	q := &query{tag: *tag, text: strings.ToLower(*text)}
+	var err error
+	if q.level, err = log.ParseLevel(*level); err != nil {
+		return nil, nil, err
+	}
+	if q.since, err = parseTime(*since, now); err != nil {
+		return nil, nil, err
+	}
+	if q.until, err = parseTime(*until, now); err != nil {
+		return nil, nil, err
+	}
+	return q, fs.Args(), nil
+}
+
+// parseTime parses s as a time in RFC 3339 or log.TimeFormat, in the
+// local time, or as a duration before now. Empty is the zero time.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	// SecondTimeFormat also parses the fractional seconds
+	t, err := time.ParseInLocation(log.SecondTimeFormat, s, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %q, expected RFC 3339, %q or a duration", s, log.TimeFormat)
+	}
+	return t, nil
+}
+
+// lineTime parses the timestamp at the start of a text line, in
+// log.TimeFormat or in log.SecondTimeFormat of the older versions
+func lineTime(s string) (time.Time, bool) {
+	for _, f := range []string{log.TimeFormat, log.SecondTimeFormat} {
+		if len(s) >= len(f) {
+			if t, err := time.ParseInLocation(f, s[:len(f)], time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// match reports if a message of type m, tag and time t, zero if unknown,
+// with the text matches q
+func (q *query) match(t time.Time, m log.MsgType, tag, text string) bool {
+	if !q.since.IsZero() && (t.IsZero() || t.Before(q.since)) {
+		return false
+	}
+	if !q.until.IsZero() && (t.IsZero() || !t.Before(q.until)) {
+		return false
+	}
+	if m.Level() < q.level || (q.tag != "" && tag != q.tag) {
+		return false
+	}
+	return q.text == "" || strings.Contains(strings.ToLower(text), q.text)
+}
+
+// matchEntry writes e in the console format to w if it matches q
+func (q *query) matchEntry(w io.Writer, e *log.Entry) error {
+	text := log.TextFormatter(e)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	tag, _ := e.Fields[log.TagKey].(string)
+	if !q.match(e.Time, e.Type, tag, text) {
+		return nil
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// run writes to w the entries of the files that match q, in the order of
+// the files
+func (q *query) run(w io.Writer, names []string) error {
+	for _, name := range names {
+		if err := q.file(w, name); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// file writes the entries of the file name that match q. The file is a
+// binlog file, or has JSON entries or text messages, as written by the
+// file adapter, plain or gzip compressed.
+func (q *query) file(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	if h, _ := br.Peek(len(binlogHeader)); string(h) == binlogHeader {
+		return q.binlog(w, name)
+	}
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return q.lines(w, r)
+}
+
+func (q *query) binlog(w io.Writer, name string) error {
+	r, err := binlog.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if !q.since.IsZero() {
+		if err = r.SeekToTime(q.since); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !q.until.IsZero() && !e.Time.Before(q.until) {
+			// the entries are in time order
+			return nil
+		}
+		if err = q.matchEntry(w, e); err != nil {
+			return err
+		}
+	}
+}
+
+// lines writes the messages of r that match q, with their continuation
+// lines, or the JSON entries
+func (q *query) lines(w io.Writer, r io.Reader) error {
+	var (
+		text string
+		t    time.Time
+	)
+	flush := func() error {
+		if text == "" {
+			return nil
+		}
+		defer func() { text = "" }()
+		m := log.MessageLog
+		if l := levelTag.FindStringSubmatch(text); l != nil {
+			m = msgType(l[1])
+		}
+		tag := ""
+		if l := textTag.FindStringSubmatch(strings.SplitN(text, "\n", 2)[0]); l != nil {
+			tag = l[1]
+		}
+		if !q.match(t, m, tag, text) {
+			return nil
+		}
+		_, err := io.WriteString(w, text)
+		return err
+	}
+
+	lr := bufio.NewReader(r)
+	for {
+		s, err := lr.ReadString('\n')
+		if s != "" {
+			if strings.HasPrefix(s, "{") {
+				e := &log.Entry{}
+				if e.UnmarshalJSON([]byte(strings.TrimSpace(s))) == nil {
+					if ferr := flush(); ferr != nil {
+						return ferr
+					}
+					if ferr := q.matchEntry(w, e); ferr != nil {
+						return ferr
+					}
+					continue
+				}
+			}
+			if !strings.HasSuffix(s, "\n") {
+				s += "\n"
+			}
+			if lt, ok := lineTime(s); ok {
+				if ferr := flush(); ferr != nil {
+					return ferr
+				}
+				t, text = lt, s
+				continue
+			}
+			if text == "" {
+				t = time.Time{}
+			}
+			text += s
+		}
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
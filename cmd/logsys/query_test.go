@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+func TestQuery(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "app.log")
+	err := ioutil.WriteFile(text, []byte("stray line\n"+
+		"2017/07/01 00:00:00 [msg] started\n"+
+		"2017/07/01 00:00:01 [error] copy failed tag=db\n    disk full\n"+
+		"2017/07/01 00:00:02 [warning] slow tag=http\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2017, 7, 1, 0, 0, 0, 0, time.Local)
+	bin := filepath.Join(dir, "app.bin")
+	var b bytes.Buffer
+	b.WriteString(binlogHeader)
+	for i, m := range []string{"first", "query timeout", "last"} {
+		e := &log.Entry{Time: start.Add(time.Duration(i) * time.Second), Type: log.ErrorLog, Out: log.LineOut,
+			Msg: []interface{}{m}, Fields: log.Fields{log.TagKey: "db"}, Keys: []string{log.TagKey}}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h [8]byte
+		copy(h[:], "\xff\xfeEN")
+		binary.BigEndian.PutUint32(h[4:], uint32(len(payload)))
+		b.Write(h[:])
+		b.Write(payload)
+	}
+	if err = ioutil.WriteFile(bin, b.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		q, names, err := parseQuery(append(args, text, bin), start.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err = q.run(&out, names); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	out := run("-level", "error", "-tag", "db")
+	if !strings.Contains(out, "[error] copy failed tag=db\n    disk full\n") || strings.Count(out, "tag=db") != 4 ||
+		strings.Contains(out, "slow") || strings.Contains(out, "started") {
+		t.Fatalf("Error, query by level and tag %q", out)
+	}
+	out = run("-since", "2017/07/01 00:00:01", "-until", "2017/07/01 00:00:02")
+	if strings.Count(out, "\n") != 3 || strings.Count(out, "2017/07/01 00:00:01") != 2 || strings.Contains(out, "stray") {
+		t.Fatalf("Error, query by time %q", out)
+	}
+	if out = run("-text", "TIMEOUT"); !strings.Contains(out, "query timeout") || strings.Count(out, "\n") != 1 {
+		t.Fatalf("Error, query by text %q", out)
+	}
+	if out = run("-since", "2h"); strings.Count(out, "\n") != 7 {
+		t.Fatalf("Error, query by duration %q", out)
+	}
+
+	if _, _, err = parseQuery([]string{"-level", "loud", text}, start); err == nil {
+		t.Fatal("Error, expected an error for an invalid level")
+	}
+	if _, _, err = parseQuery(nil, start); err == nil {
+		t.Fatal("Error, expected an error without files")
+	}
+}
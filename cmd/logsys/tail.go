@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// pollInterval of the followed file
+const pollInterval = 250 * time.Millisecond
+
+// tail shows the file name, or stdin if name is "-", in the viewer until
+// the user quits
+func tail(name string) error {
+	lines := make(chan string, 1024)
+	errs := make(chan error, 1)
+	if name == "-" {
+		go readStream(os.Stdin, lines)
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		go func() { errs <- follow(f, name, pollInterval, lines) }()
+	}
+
+	t, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	_, _ = t.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		_, _ = t.WriteString("\x1b[?25h\x1b[?1049l")
+		_ = t.restore()
+	}()
+
+	keys := make(chan rune, 64)
+	go readKeys(t, keys)
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+
+	v := newViewer()
+	v.width, v.height = t.size()
+	for {
+		draw(t, v)
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				lines = nil
+				continue
+			}
+			v.add(parseLine(l))
+			// add the pending lines before drawing again
+			for n := len(lines); n > 0; n-- {
+				v.add(parseLine(<-lines))
+			}
+		case k := <-keys:
+			if v.key(k) {
+				return nil
+			}
+		case <-resize:
+			v.width, v.height = t.size()
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+func draw(w io.Writer, v *viewer) {
+	_, _ = io.WriteString(w, "\x1b[H"+strings.Join(v.render(), "\x1b[K\r\n")+"\x1b[K")
+}
+
+// readStream sends the lines of r to lines, closing it at the end
+func readStream(r io.Reader, lines chan<- string) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		lines <- s.Text()
+	}
+	close(lines)
+}
+
+// follow sends the lines of f to lines as they are written, checking for
+// more every interval. The file is read again from the start if it is
+// truncated and reopened if name is replaced, as the rotation of the file
+// adapter does.
+func follow(f *os.File, name string, interval time.Duration, lines chan<- string) error {
+	r := bufio.NewReader(f)
+	var partial string
+	var offset int64
+	for {
+		s, err := r.ReadString('\n')
+		offset += int64(len(s))
+		if err == nil {
+			lines <- partial + s
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial += s
+		time.Sleep(interval)
+
+		cur, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if next, err := os.Stat(name); err == nil && !os.SameFile(cur, next) {
+			// rotated, the rest of the old file was already read
+			nf, err := os.Open(name)
+			if err != nil {
+				continue
+			}
+			_ = f.Close()
+			f, offset, partial = nf, 0, ""
+			r.Reset(f)
+			continue
+		}
+		if cur.Size() < offset {
+			if _, err = f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset, partial = 0, ""
+			r.Reset(f)
+		}
+	}
+}
+
+// readKeys sends the keys read from r to keys
+func readKeys(r io.Reader, keys chan<- rune) {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			keys <- keyQuit
+			return
+		}
+		for _, k := range decodeKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+var escapes = map[string]rune{
+	"\x1b[A":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1bOA":  keyUp,
+	"\x1bOB":  keyDown,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+}
+
+// decodeKeys returns the keys of the bytes read from the terminal in raw
+// mode. A lone escape is the Esc key and unknown sequences are ignored.
+func decodeKeys(b []byte) []rune {
+	var keys []rune
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b && len(b) == 1:
+			keys = append(keys, keyEscape)
+			b = b[1:]
+		case c == 0x1b:
+			n := 2
+			if b[1] == '[' || b[1] == 'O' {
+				// CSI and SS3 sequences end with a final byte in @ to ~
+				for n < len(b) && (b[n] < '@' || b[n] > '~') {
+					n++
+				}
+				if n < len(b) {
+					n++
+				}
+			}
+			if k, ok := escapes[string(b[:n])]; ok {
+				keys = append(keys, k)
+			}
+			b = b[n:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, keyEnter)
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, keyBackspace)
+			b = b[1:]
+		case c == 0x03 || c == 0x04:
+			keys = append(keys, keyQuit)
+			b = b[1:]
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, r)
+			b = b[n:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	keys := decodeKeys([]byte("a\x1b[A\x1b[6~\r\x7f\x1b[1;5C\x03é"))
+	expected := []rune{'a', keyUp, keyPageDown, keyEnter, keyBackspace, keyQuit, 'é'}
+	if string(keys) != string(expected) {
+		t.Fatalf("Error, keys %q, expected %q", keys, expected)
+	}
+	if keys = decodeKeys([]byte{0x1b}); len(keys) != 1 || keys[0] != keyEscape {
+		t.Fatalf("Error, keys %q, expected escape", keys)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logsys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app.log")
+	if err = ioutil.WriteFile(name, []byte("one\ntw"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string, 10)
+	go func() { _ = follow(f, name, 10*time.Millisecond, lines) }()
+
+	next := func(expected string) {
+		t.Helper()
+		select {
+		case l := <-lines:
+			if l != expected {
+				t.Fatalf("Error, line %q, expected %q", l, expected)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Error, timeout waiting %q", expected)
+		}
+	}
+	next("one\n")
+
+	a, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = a.WriteString("o\n")
+	_ = a.Close()
+	next("two\n")
+
+	// rotation
+	if err = os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(name, []byte("three\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	next("three\n")
+}
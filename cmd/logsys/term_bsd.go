@@ -0,0 +1,11 @@
+//go:build darwin || freebsd || netbsd || dragonfly
+// +build darwin freebsd netbsd dragonfly
+
+package main
+
+import "syscall"
+
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
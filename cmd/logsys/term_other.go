@@ -0,0 +1,27 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!dragonfly
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+type terminal struct {
+	*os.File
+}
+
+func openTerminal() (*terminal, error) {
+	return nil, errors.New("tail is not supported on this platform")
+}
+
+func (t *terminal) restore() error {
+	return nil
+}
+
+func (t *terminal) size() (int, int) {
+	return 80, 24
+}
+
+func notifyResize(c chan os.Signal) {}
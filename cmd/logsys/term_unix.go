@@ -0,0 +1,78 @@
+//go:build linux || darwin || freebsd || netbsd || dragonfly
+// +build linux darwin freebsd netbsd dragonfly
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// terminal is the controlling terminal in raw mode
+type terminal struct {
+	*os.File
+	old syscall.Termios
+}
+
+type winsize struct {
+	row, col, xpixel, ypixel uint16
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// openTerminal opens the controlling terminal and puts it in raw mode, so
+// the keys are read as typed, even if stdin is the followed stream
+func openTerminal() (*terminal, error) {
+	f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{File: f}
+	if err = ioctl(f.Fd(), ioctlGetTermios, unsafe.Pointer(&t.old)); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	raw := t.old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err = ioctl(f.Fd(), ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// restore restores the terminal mode and closes it
+func (t *terminal) restore() error {
+	err := ioctl(t.Fd(), ioctlSetTermios, unsafe.Pointer(&t.old))
+	if e := t.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// size returns the width and height of the terminal, 80x24 if unknown
+func (t *terminal) size() (int, int) {
+	ws := winsize{}
+	if ioctl(t.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)) != nil || ws.col == 0 || ws.row == 0 {
+		return 80, 24
+	}
+	return int(ws.col), int(ws.row)
+}
+
+func notifyResize(c chan os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
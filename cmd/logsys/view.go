@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nuveo/log"
+)
+
+// Keys of the viewer, the other keys are their characters
+const (
+	keyUp = iota + 0x110000
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyQuit
+)
+
+// viewer is the state of the tail viewer
+type viewer struct {
+	items    []item
+	minLevel log.Level
+	query    string
+	// searching is set while the query is typed in input
+	searching bool
+	input     string
+	paused    bool
+	// cursor is the selected item and top the first item shown, indexes
+	// of the filtered items
+	cursor   int
+	top      int
+	expanded map[int]bool
+	width    int
+	height   int
+}
+
+func newViewer() *viewer {
+	return &viewer{minLevel: log.DebugLevel, expanded: make(map[int]bool), width: 80, height: 24}
+}
+
+func (v *viewer) add(it item) {
+	v.items = append(v.items, it)
+}
+
+// shown reports if the item passes the level and the search filters
+func (v *viewer) shown(it item) bool {
+	if it.msg.Level() < v.minLevel {
+		return false
+	}
+	if v.query == "" {
+		return true
+	}
+	q := strings.ToLower(v.query)
+	if strings.Contains(strings.ToLower(it.text), q) {
+		return true
+	}
+	for _, f := range it.fields {
+		if strings.Contains(strings.ToLower(f), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// filtered returns the indexes of the items shown
+func (v *viewer) filtered() []int {
+	idx := make([]int, 0, len(v.items))
+	for i, it := range v.items {
+		if v.shown(it) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// key handles the key k and reports if the viewer should quit
+func (v *viewer) key(k rune) bool {
+	if v.searching {
+		switch k {
+		case keyEnter:
+			v.query, v.searching = v.input, false
+			v.cursor, v.top = 0, 0
+		case keyEscape:
+			v.searching = false
+		case keyBackspace:
+			if v.input != "" {
+				_, n := utf8.DecodeLastRuneInString(v.input)
+				v.input = v.input[:len(v.input)-n]
+			}
+		case keyQuit:
+			return true
+		default:
+			if k >= ' ' && k < keyUp {
+				v.input += string(k)
+			}
+		}
+		return false
+	}
+
+	n := len(v.filtered())
+	page := v.height - 2
+	if page < 1 {
+		page = 1
+	}
+	switch k {
+	case 'q', keyQuit:
+		return true
+	case '1', '2', '3', '4', '5':
+		v.minLevel = log.Level(k - '1')
+		v.cursor, v.top = 0, 0
+	case '/':
+		v.searching, v.input = true, v.query
+	case keyEscape:
+		v.query = ""
+		v.cursor, v.top = 0, 0
+	case ' ':
+		v.paused = !v.paused
+		if v.paused {
+			v.cursor = n - 1
+		}
+	case keyUp, 'k':
+		v.move(-1, n)
+	case keyDown, 'j':
+		v.move(1, n)
+	case keyPageUp:
+		v.move(-page, n)
+	case keyPageDown:
+		v.move(page, n)
+	case 'g':
+		v.paused = true
+		v.cursor, v.top = 0, 0
+	case 'G':
+		v.paused = false
+	case keyEnter:
+		if idx := v.filtered(); v.paused && v.cursor < len(idx) {
+			i := idx[v.cursor]
+			v.expanded[i] = !v.expanded[i]
+		}
+	}
+	return false
+}
+
+// move moves the selection n items, pausing the viewer
+func (v *viewer) move(d, n int) {
+	if !v.paused {
+		v.paused = true
+		v.cursor = n - 1
+	}
+	v.cursor += d
+	if v.cursor >= n {
+		v.cursor = n - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+// rows returns the lines of the item i of the filtered items
+func (v *viewer) rows(i int, selected bool) []string {
+	it := v.items[i]
+	text := truncate(it.text, v.width)
+	if selected {
+		text = "\x1b[7m" + text + "\x1b[0m"
+	} else {
+		text = log.Colors[it.msg] + text + "\x1b[0m"
+	}
+	rows := []string{text}
+	if v.expanded[i] {
+		for _, f := range it.fields {
+			rows = append(rows, truncate("    "+f, v.width))
+		}
+		if len(it.fields) == 0 {
+			rows = append(rows, "    (no fields)")
+		}
+	}
+	return rows
+}
+
+// render returns the lines of the screen, the list and the status bar
+func (v *viewer) render() []string {
+	idx := v.filtered()
+	height := v.height - 1
+	if height < 1 {
+		height = 1
+	}
+	if !v.paused {
+		v.cursor = len(idx) - 1
+	}
+	if v.cursor >= len(idx) {
+		v.cursor = len(idx) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	var lines []string
+	if !v.paused {
+		// the last items, following the file
+		for j := len(idx) - 1; j >= 0 && len(lines) < height; j-- {
+			lines = append(v.rows(idx[j], false), lines...)
+		}
+		if len(lines) > height {
+			lines = lines[len(lines)-height:]
+		}
+	} else {
+		if v.top > v.cursor {
+			v.top = v.cursor
+		}
+		for {
+			lines = lines[:0]
+			bottom := -1
+			for j := v.top; j < len(idx) && len(lines) < height; j++ {
+				lines = append(lines, v.rows(idx[j], j == v.cursor)...)
+				if len(lines) <= height {
+					bottom = j
+				}
+			}
+			if bottom >= v.cursor || v.top >= v.cursor {
+				break
+			}
+			v.top++
+		}
+		if len(lines) > height {
+			lines = lines[:height]
+		}
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return append(lines, v.status(len(idx)))
+}
+
+// status returns the status bar
+func (v *viewer) status(n int) string {
+	if v.searching {
+		return truncate("/"+v.input, v.width)
+	}
+	parts := []string{fmt.Sprintf("level>=%s", v.minLevel)}
+	if v.query != "" {
+		parts = append(parts, "/"+v.query)
+	}
+	if v.paused {
+		parts = append(parts, fmt.Sprintf("PAUSED %d/%d", v.cursor+1, n))
+	} else {
+		parts = append(parts, fmt.Sprintf("following %d", n))
+	}
+	parts = append(parts, "1-5:level /:search space:pause enter:fields q:quit")
+	return "\x1b[7m" + pad(truncate(strings.Join(parts, "  "), v.width), v.width) + "\x1b[0m"
+}
+
+// truncate cuts s to width runes
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width <= 3 {
+		return string(r[:width])
+	}
+	return string(r[:width-3]) + "..."
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
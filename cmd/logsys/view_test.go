@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func testViewer() *viewer {
+	v := newViewer()
+	v.width, v.height = 40, 5
+	v.add(item{text: "debug one", msg: log.DebugLog})
+	v.add(item{text: "info two", msg: log.MessageLog, fields: []string{"user=ana"}})
+	v.add(item{text: "warning three", msg: log.WarningLog})
+	v.add(item{text: "error four", msg: log.ErrorLog})
+	v.add(item{text: "info five", msg: log.MessageLog})
+	v.add(item{text: "info six", msg: log.MessageLog})
+	return v
+}
+
+func TestViewerFilter(t *testing.T) {
+	v := testViewer()
+	if n := len(v.filtered()); n != 6 {
+		t.Fatalf("Error, %d items shown, expected 6", n)
+	}
+	v.key('3')
+	if idx := v.filtered(); len(idx) != 2 || idx[0] != 2 || idx[1] != 3 {
+		t.Fatalf("Error, filtered %v, expected [2 3]", idx)
+	}
+
+	v.key('1')
+	for _, k := range "/ANA" {
+		v.key(k)
+	}
+	if v.query != "" || !v.searching {
+		t.Fatal("Error, the query must only be applied by enter")
+	}
+	v.key(keyEnter)
+	if idx := v.filtered(); len(idx) != 1 || idx[0] != 1 {
+		t.Fatalf("Error, search %q shows %v, expected [1]", v.query, idx)
+	}
+	v.key(keyEscape)
+	if v.query != "" || len(v.filtered()) != 6 {
+		t.Fatal("Error, escape must clear the search")
+	}
+}
+
+func TestViewerRender(t *testing.T) {
+	v := testViewer()
+	lines := v.render()
+	if len(lines) != 5 {
+		t.Fatalf("Error, %d lines, expected 5", len(lines))
+	}
+	// following shows the last entries
+	if !strings.Contains(lines[3], "info six") || !strings.Contains(lines[0], "warning three") {
+		t.Fatalf("Error, lines %q", lines)
+	}
+	if !strings.Contains(lines[4], "following 6") {
+		t.Fatalf("Error, status %q", lines[4])
+	}
+
+	v.key(keyUp)
+	if !v.paused || v.cursor != 4 {
+		t.Fatalf("Error, paused %v cursor %d, expected the fifth item selected", v.paused, v.cursor)
+	}
+	v.add(item{text: "info seven", msg: log.MessageLog})
+	lines = v.render()
+	if strings.Contains(strings.Join(lines, "\n"), "info seven") {
+		t.Fatal("Error, new entries must not scroll the paused viewer")
+	}
+
+	v.key('g')
+	v.key(keyDown)
+	v.key(keyEnter)
+	lines = v.render()
+	if !strings.Contains(lines[1], "\x1b[7minfo two") || lines[2] != "    user=ana" {
+		t.Fatalf("Error, expanded lines %q", lines)
+	}
+
+	v.key('G')
+	if lines = v.render(); !strings.Contains(lines[3], "info seven") {
+		t.Fatalf("Error, G must resume following, lines %q", lines)
+	}
+	if !v.key('q') {
+		t.Fatal("Error, q must quit")
+	}
+}
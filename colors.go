@@ -0,0 +1,61 @@
+package log
+
+import (
+	"strings"
+	"sync"
+)
+
+// ColorScheme maps message types to their ANSI colors, see SetColorScheme
+type ColorScheme map[MsgType]string
+
+var (
+	// colorsLock guards Colors for SetColor and the formatters
+	colorsLock = sync.RWMutex{}
+
+	defaultColors = append([]string(nil), Colors...)
+)
+
+// ansiColor returns code as an escape sequence, code is either an escape
+// sequence or its SGR parameters, e.g. "1;31" for bold red
+func ansiColor(code string) string {
+	if code == "" || strings.HasPrefix(code, "\x1b") {
+		return code
+	}
+	return "\x1b[" + code + "m"
+}
+
+// SetColor sets the color of the messages of type m in TextFormatter,
+// e.g. SetColor(log.ErrorLog, "1;31") for bold red. The code is the SGR
+// parameters or the whole escape sequence, empty for no color. It is safe
+// to call while other goroutines are logging, unlike changing Colors.
+func SetColor(m MsgType, code string) {
+	colorsLock.Lock()
+	defer colorsLock.Unlock()
+	if int(m) < len(Colors) {
+		Colors[m] = ansiColor(code)
+	}
+}
+
+// SetColorScheme sets the colors of the message types of s with SetColor,
+// the other types keep their colors.
+func SetColorScheme(s ColorScheme) {
+	for m, code := range s {
+		SetColor(m, code)
+	}
+}
+
+// DefaultColorScheme returns the default colors of the message types
+func DefaultColorScheme() ColorScheme {
+	s := make(ColorScheme, len(defaultColors))
+	for m, c := range defaultColors {
+		s[MsgType(m)] = c
+	}
+	return s
+}
+
+// levelColor returns the color of the messages of type m
+func levelColor(m MsgType) string {
+	colorsLock.RLock()
+	defer colorsLock.RUnlock()
+	return Colors[m]
+}
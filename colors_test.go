@@ -0,0 +1,75 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSetColor(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	defer SetColorScheme(DefaultColorScheme())
+	timeFormated := now().Format(TimeFormat)
+
+	SetColorScheme(ColorScheme{ErrorLog: "1;31", MessageLog: ""})
+	err := validate("Errorln", Errorln, "^\x1b\\[1;31m"+timeFormated+" \\[error\\] log test\x1b\\[0;00m\n$", "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = validate("Println", Println, "^"+timeFormated+" \\[msg\\] log test\x1b\\[0;00m\n$", "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	SetColor(WarningLog, "\x1b[33m")
+	err = validate("Warningln", Warningln, "^\x1b\\[33m"+timeFormated+" \\[warning\\] log test\x1b\\[0;00m\n$", "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	SetColorScheme(DefaultColorScheme())
+	if Colors[ErrorLog] != "\x1b[91m" || Colors[MessageLog] != "\x1b[37m" {
+		t.Fatalf("Error, colors %q after restoring the default scheme", Colors)
+	}
+}
+
+func TestColorsNotTerminal(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	ForceColors = false
+	timeFormated := now().Format(TimeFormat)
+
+	f, err := ioutil.TempFile("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if isTerminal(f.Fd()) {
+		t.Skip("terminals are not detected on this platform")
+	}
+	var buf bytes.Buffer
+	SetOutput(f)
+	SetErrorOutput(&buf)
+	Println("log test")
+	Errorln("log test")
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != timeFormated+" [msg] log test\n" {
+		t.Fatalf("Error, wrote %q to a file", b)
+	}
+	if buf.String() != timeFormated+" [error] log test\n" {
+		t.Fatalf("Error, wrote %q to a buffer", buf.String())
+	}
+
+	ForceColors = true
+	buf.Reset()
+	Errorln("log test")
+	if buf.String() != "\x1b[91m"+timeFormated+" [error] log test\x1b[0;00m\n" {
+		t.Fatalf("Error, wrote %q with ForceColors", buf.String())
+	}
+}
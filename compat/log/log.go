@@ -0,0 +1,246 @@
+// Package log is a drop-in replacement for the basic usage of the standard
+// library log package that forwards the messages to github.com/nuveo/log,
+// so programs can migrate by changing only the import path.
+//
+// The flags, prefix and output writer of the standard library are accepted
+// for compatibility, but formatting and output are done by the adapters of
+// github.com/nuveo/log; only the prefix is kept in the message.
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	stdlog "log"
+	"strings"
+	"sync"
+
+	"github.com/nuveo/log"
+)
+
+// Flags of the standard library log package
+const (
+	Ldate         = stdlog.Ldate
+	Ltime         = stdlog.Ltime
+	Lmicroseconds = stdlog.Lmicroseconds
+	Llongfile     = stdlog.Llongfile
+	Lshortfile    = stdlog.Lshortfile
+	LUTC          = stdlog.LUTC
+	Lmsgprefix    = stdlog.Lmsgprefix
+	LstdFlags     = stdlog.LstdFlags
+)
+
+// Logger forwards the messages to github.com/nuveo/log
+type Logger struct {
+	mu     sync.Mutex
+	prefix string
+	flag   int
+}
+
+var std = New(nil, "", LstdFlags)
+
+// New creates a Logger, out is ignored.
+func New(out io.Writer, prefix string, flag int) *Logger {
+	return &Logger{prefix: prefix, flag: flag}
+}
+
+// Default returns the standard logger used by the package functions
+func Default() *Logger {
+	return std
+}
+
+func (l *Logger) text(s string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.prefix + strings.TrimSuffix(s, "\n")
+}
+
+// Print logs the message as log.Println
+func (l *Logger) Print(v ...interface{}) {
+	log.Println(l.text(fmt.Sprint(v...)))
+}
+
+// Printf logs the message as log.Println
+func (l *Logger) Printf(format string, v ...interface{}) {
+	log.Println(l.text(fmt.Sprintf(format, v...)))
+}
+
+// Println logs the message as log.Println
+func (l *Logger) Println(v ...interface{}) {
+	log.Println(l.text(fmt.Sprintln(v...)))
+}
+
+// Fatal logs the message as log.Fatalln and exits to OS
+func (l *Logger) Fatal(v ...interface{}) {
+	log.Fatalln(l.text(fmt.Sprint(v...)))
+}
+
+// Fatalf logs the message as log.Fatalln and exits to OS
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	log.Fatalln(l.text(fmt.Sprintf(format, v...)))
+}
+
+// Fatalln logs the message as log.Fatalln and exits to OS
+func (l *Logger) Fatalln(v ...interface{}) {
+	log.Fatalln(l.text(fmt.Sprintln(v...)))
+}
+
+// Panic logs the message as log.Panicln and panics
+func (l *Logger) Panic(v ...interface{}) {
+	l.panic(fmt.Sprint(v...))
+}
+
+// Panicf logs the message as log.Panicln and panics
+func (l *Logger) Panicf(format string, v ...interface{}) {
+	l.panic(fmt.Sprintf(format, v...))
+}
+
+// Panicln logs the message as log.Panicln and panics
+func (l *Logger) Panicln(v ...interface{}) {
+	l.panic(fmt.Sprintln(v...))
+}
+
+// panic logs s with log.Panicln and panics with s, without the prefix, as
+// the standard library does
+func (l *Logger) panic(s string) {
+	defer func() {
+		recover()
+		panic(s)
+	}()
+	log.Panicln(l.text(s))
+}
+
+// Output logs s as log.Println, calldepth is ignored.
+func (l *Logger) Output(calldepth int, s string) error {
+	log.Println(l.text(s))
+	return nil
+}
+
+// Flags returns the flags of the logger
+func (l *Logger) Flags() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flag
+}
+
+// SetFlags sets the flags of the logger, they don't change the output
+func (l *Logger) SetFlags(flag int) {
+	l.mu.Lock()
+	l.flag = flag
+	l.mu.Unlock()
+}
+
+// Prefix returns the prefix of the logger
+func (l *Logger) Prefix() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.prefix
+}
+
+// SetPrefix sets the prefix added to the messages
+func (l *Logger) SetPrefix(prefix string) {
+	l.mu.Lock()
+	l.prefix = prefix
+	l.mu.Unlock()
+}
+
+// SetOutput is accepted for compatibility, the output is defined by the
+// adapters of github.com/nuveo/log.
+func (l *Logger) SetOutput(w io.Writer) {}
+
+// Writer returns a writer that logs every line written to it
+func (l *Logger) Writer() io.Writer {
+	return LineWriter(log.MessageLog)
+}
+
+// Print logs the message as log.Println
+func Print(v ...interface{}) { std.Print(v...) }
+
+// Printf logs the message as log.Println
+func Printf(format string, v ...interface{}) { std.Printf(format, v...) }
+
+// Println logs the message as log.Println
+func Println(v ...interface{}) { std.Println(v...) }
+
+// Fatal logs the message as log.Fatalln and exits to OS
+func Fatal(v ...interface{}) { std.Fatal(v...) }
+
+// Fatalf logs the message as log.Fatalln and exits to OS
+func Fatalf(format string, v ...interface{}) { std.Fatalf(format, v...) }
+
+// Fatalln logs the message as log.Fatalln and exits to OS
+func Fatalln(v ...interface{}) { std.Fatalln(v...) }
+
+// Panic logs the message as log.Panicln and panics
+func Panic(v ...interface{}) { std.Panic(v...) }
+
+// Panicf logs the message as log.Panicln and panics
+func Panicf(format string, v ...interface{}) { std.Panicf(format, v...) }
+
+// Panicln logs the message as log.Panicln and panics
+func Panicln(v ...interface{}) { std.Panicln(v...) }
+
+// Output logs s as log.Println, calldepth is ignored.
+func Output(calldepth int, s string) error { return std.Output(calldepth+1, s) }
+
+// Flags returns the flags of the standard logger
+func Flags() int { return std.Flags() }
+
+// SetFlags sets the flags of the standard logger
+func SetFlags(flag int) { std.SetFlags(flag) }
+
+// Prefix returns the prefix of the standard logger
+func Prefix() string { return std.Prefix() }
+
+// SetPrefix sets the prefix of the standard logger
+func SetPrefix(prefix string) { std.SetPrefix(prefix) }
+
+// SetOutput is accepted for compatibility, see Logger.SetOutput.
+func SetOutput(w io.Writer) {}
+
+// Writer returns a writer that logs every line written to it
+func Writer() io.Writer { return std.Writer() }
+
+type writer struct {
+	m   log.MsgType
+	mu  sync.Mutex
+	buf []byte
+}
+
+// LineWriter returns an io.Writer that logs every line written to it with
+// the message type m, e.g. for http.Server.ErrorLog.
+func LineWriter(m log.MsgType) io.Writer {
+	return &writer{m: m}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.buf[:i])
+		w.buf = w.buf[i+1:]
+		switch w.m {
+		case log.ErrorLog, log.FatalLog, log.PanicLog:
+			log.Errorln(line)
+		case log.WarningLog:
+			log.Warningln(line)
+		case log.DebugLog:
+			log.Debugln(line)
+		default:
+			log.Println(line)
+		}
+	}
+	return len(p), nil
+}
+
+// Redirect sends the messages of the standard library log package, also
+// used by third party packages, to github.com/nuveo/log.
+func Redirect() {
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(LineWriter(log.MessageLog))
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	stdlog "log"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func capture() *[]*log.Entry {
+	entries := &[]*log.Entry{}
+	log.RemoveAdapter("stdout")
+	log.AddAdapter("capture", log.AdapterPod{
+		Adapter: func(e *log.Entry, config map[string]interface{}) {
+			*entries = append(*entries, e)
+		},
+	})
+	return entries
+}
+
+func TestLogger(t *testing.T) {
+	entries := capture()
+	defer log.RemoveAdapter("capture")
+
+	SetPrefix("app: ")
+	defer SetPrefix("")
+	Printf("%d apples", 3)
+	Println("two", "words")
+
+	l := New(nil, "sub: ", LstdFlags)
+	l.Print("message")
+
+	func() {
+		defer func() {
+			if r := recover(); r != "panic\n" {
+				t.Errorf("expected panic value \"panic\\n\", but got %q", r)
+			}
+		}()
+		Panicln("panic")
+	}()
+
+	expected := []struct {
+		m   log.MsgType
+		msg string
+	}{
+		{log.MessageLog, "app: 3 apples"},
+		{log.MessageLog, "app: two words"},
+		{log.MessageLog, "sub: message"},
+		{log.PanicLog, "app: panic"},
+	}
+	if len(*entries) != len(expected) {
+		t.Fatalf("expected %d entries, but got %d", len(expected), len(*entries))
+	}
+	for i, e := range *entries {
+		if e.Type != expected[i].m || e.Message() != expected[i].msg {
+			t.Errorf("expected %v %q, but got %v %q", expected[i].m, expected[i].msg, e.Type, e.Message())
+		}
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	entries := capture()
+	defer log.RemoveAdapter("capture")
+
+	Redirect()
+	stdlog.Print("from the standard library")
+	stdlog.Print("part 1\npart 2")
+
+	expected := []string{"from the standard library", "part 1", "part 2"}
+	if len(*entries) != len(expected) {
+		t.Fatalf("expected %d entries, but got %d", len(expected), len(*entries))
+	}
+	for i, e := range *entries {
+		if e.Message() != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], e.Message())
+		}
+	}
+}
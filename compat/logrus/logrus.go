@@ -0,0 +1,300 @@
+// Package logrus implements the basic API of github.com/sirupsen/logrus
+// on top of github.com/nuveo/log, so programs can migrate by changing only
+// the import path. Fields are added to the entries with log.WithFields.
+package logrus
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nuveo/log"
+)
+
+// Level of the messages, as in logrus
+type Level uint32
+
+// Levels of logrus
+const (
+	PanicLevel Level = iota
+	FatalLevel
+	ErrorLevel
+	WarnLevel
+	InfoLevel
+	DebugLevel
+	TraceLevel
+)
+
+// Fields are the key/value pairs added to the messages
+type Fields map[string]interface{}
+
+// ErrorKey is the field name used by WithError
+var ErrorKey = "error"
+
+var level = uint32(InfoLevel)
+
+// SetLevel sets the logging level, DebugLevel and TraceLevel enable
+// log.DebugMode.
+func SetLevel(l Level) {
+	atomic.StoreUint32(&level, uint32(l))
+	log.SetDebugMode(l >= DebugLevel)
+}
+
+// GetLevel returns the logging level
+func GetLevel() Level {
+	return Level(atomic.LoadUint32(&level))
+}
+
+// IsLevelEnabled checks if messages of the level l are logged
+func IsLevelEnabled(l Level) bool {
+	return GetLevel() >= l
+}
+
+// Entry carries the fields added by WithField, WithFields and WithError
+type Entry struct {
+	Data Fields
+}
+
+// WithField creates an entry with the field key
+func WithField(key string, value interface{}) *Entry {
+	return WithFields(Fields{key: value})
+}
+
+// WithFields creates an entry with the fields
+func WithFields(fields Fields) *Entry {
+	return (&Entry{}).WithFields(fields)
+}
+
+// WithError creates an entry with the field ErrorKey set to err
+func WithError(err error) *Entry {
+	return WithField(ErrorKey, err)
+}
+
+// WithField returns a copy of the entry with the field key added
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return e.WithFields(Fields{key: value})
+}
+
+// WithFields returns a copy of the entry with the fields added
+func (e *Entry) WithFields(fields Fields) *Entry {
+	data := make(Fields, len(e.Data)+len(fields))
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	for k, v := range fields {
+		data[k] = v
+	}
+	return &Entry{Data: data}
+}
+
+// WithError returns a copy of the entry with the field ErrorKey set to err
+func (e *Entry) WithError(err error) *Entry {
+	return e.WithField(ErrorKey, err)
+}
+
+func (e *Entry) log(l Level, msg string) {
+	if !IsLevelEnabled(l) {
+		return
+	}
+	fl := log.WithFields(log.Fields(e.Data))
+	switch l {
+	case PanicLevel:
+		fl.Panicln(msg)
+	case FatalLevel:
+		fl.Fatalln(msg)
+	case ErrorLevel:
+		fl.Errorln(msg)
+	case WarnLevel:
+		fl.Warningln(msg)
+	case InfoLevel:
+		fl.Println(msg)
+	default:
+		fl.Debugln(msg)
+	}
+}
+
+// Trace logs the message as log.Debugln
+func (e *Entry) Trace(args ...interface{}) { e.log(TraceLevel, fmt.Sprint(args...)) }
+
+// Debug logs the message as log.Debugln
+func (e *Entry) Debug(args ...interface{}) { e.log(DebugLevel, fmt.Sprint(args...)) }
+
+// Info logs the message as log.Println
+func (e *Entry) Info(args ...interface{}) { e.log(InfoLevel, fmt.Sprint(args...)) }
+
+// Print logs the message as log.Println
+func (e *Entry) Print(args ...interface{}) { e.log(InfoLevel, fmt.Sprint(args...)) }
+
+// Warn logs the message as log.Warningln
+func (e *Entry) Warn(args ...interface{}) { e.log(WarnLevel, fmt.Sprint(args...)) }
+
+// Warning logs the message as log.Warningln
+func (e *Entry) Warning(args ...interface{}) { e.log(WarnLevel, fmt.Sprint(args...)) }
+
+// Error logs the message as log.Errorln
+func (e *Entry) Error(args ...interface{}) { e.log(ErrorLevel, fmt.Sprint(args...)) }
+
+// Fatal logs the message as log.Fatalln and exits to OS with status 1
+func (e *Entry) Fatal(args ...interface{}) { e.log(FatalLevel, fmt.Sprint(args...)) }
+
+// Panic logs the message as log.Panicln and panics
+func (e *Entry) Panic(args ...interface{}) { e.log(PanicLevel, fmt.Sprint(args...)) }
+
+// Tracef logs the message as log.Debugln
+func (e *Entry) Tracef(format string, args ...interface{}) {
+	e.log(TraceLevel, fmt.Sprintf(format, args...))
+}
+
+// Debugf logs the message as log.Debugln
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	e.log(DebugLevel, fmt.Sprintf(format, args...))
+}
+
+// Infof logs the message as log.Println
+func (e *Entry) Infof(format string, args ...interface{}) {
+	e.log(InfoLevel, fmt.Sprintf(format, args...))
+}
+
+// Printf logs the message as log.Println
+func (e *Entry) Printf(format string, args ...interface{}) {
+	e.log(InfoLevel, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs the message as log.Warningln
+func (e *Entry) Warnf(format string, args ...interface{}) {
+	e.log(WarnLevel, fmt.Sprintf(format, args...))
+}
+
+// Warningf logs the message as log.Warningln
+func (e *Entry) Warningf(format string, args ...interface{}) {
+	e.log(WarnLevel, fmt.Sprintf(format, args...))
+}
+
+// Errorf logs the message as log.Errorln
+func (e *Entry) Errorf(format string, args ...interface{}) {
+	e.log(ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+// Fatalf logs the message as log.Fatalln and exits to OS with status 1
+func (e *Entry) Fatalf(format string, args ...interface{}) {
+	e.log(FatalLevel, fmt.Sprintf(format, args...))
+}
+
+// Panicf logs the message as log.Panicln and panics
+func (e *Entry) Panicf(format string, args ...interface{}) {
+	e.log(PanicLevel, fmt.Sprintf(format, args...))
+}
+
+// Traceln logs the message as log.Debugln
+func (e *Entry) Traceln(args ...interface{}) { e.log(TraceLevel, sprintln(args...)) }
+
+// Debugln logs the message as log.Debugln
+func (e *Entry) Debugln(args ...interface{}) { e.log(DebugLevel, sprintln(args...)) }
+
+// Infoln logs the message as log.Println
+func (e *Entry) Infoln(args ...interface{}) { e.log(InfoLevel, sprintln(args...)) }
+
+// Println logs the message as log.Println
+func (e *Entry) Println(args ...interface{}) { e.log(InfoLevel, sprintln(args...)) }
+
+// Warnln logs the message as log.Warningln
+func (e *Entry) Warnln(args ...interface{}) { e.log(WarnLevel, sprintln(args...)) }
+
+// Warningln logs the message as log.Warningln
+func (e *Entry) Warningln(args ...interface{}) { e.log(WarnLevel, sprintln(args...)) }
+
+// Errorln logs the message as log.Errorln
+func (e *Entry) Errorln(args ...interface{}) { e.log(ErrorLevel, sprintln(args...)) }
+
+// Fatalln logs the message as log.Fatalln and exits to OS with status 1
+func (e *Entry) Fatalln(args ...interface{}) { e.log(FatalLevel, sprintln(args...)) }
+
+// Panicln logs the message as log.Panicln and panics
+func (e *Entry) Panicln(args ...interface{}) { e.log(PanicLevel, sprintln(args...)) }
+
+func sprintln(args ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+var std = &Entry{}
+
+// Trace logs the message as log.Debugln
+func Trace(args ...interface{}) { std.log(TraceLevel, fmt.Sprint(args...)) }
+
+// Debug logs the message as log.Debugln
+func Debug(args ...interface{}) { std.log(DebugLevel, fmt.Sprint(args...)) }
+
+// Info logs the message as log.Println
+func Info(args ...interface{}) { std.log(InfoLevel, fmt.Sprint(args...)) }
+
+// Print logs the message as log.Println
+func Print(args ...interface{}) { std.log(InfoLevel, fmt.Sprint(args...)) }
+
+// Warn logs the message as log.Warningln
+func Warn(args ...interface{}) { std.log(WarnLevel, fmt.Sprint(args...)) }
+
+// Warning logs the message as log.Warningln
+func Warning(args ...interface{}) { std.log(WarnLevel, fmt.Sprint(args...)) }
+
+// Error logs the message as log.Errorln
+func Error(args ...interface{}) { std.log(ErrorLevel, fmt.Sprint(args...)) }
+
+// Fatal logs the message as log.Fatalln and exits to OS with status 1
+func Fatal(args ...interface{}) { std.log(FatalLevel, fmt.Sprint(args...)) }
+
+// Panic logs the message as log.Panicln and panics
+func Panic(args ...interface{}) { std.log(PanicLevel, fmt.Sprint(args...)) }
+
+// Tracef logs the message as log.Debugln
+func Tracef(format string, args ...interface{}) { std.Tracef(format, args...) }
+
+// Debugf logs the message as log.Debugln
+func Debugf(format string, args ...interface{}) { std.Debugf(format, args...) }
+
+// Infof logs the message as log.Println
+func Infof(format string, args ...interface{}) { std.Infof(format, args...) }
+
+// Printf logs the message as log.Println
+func Printf(format string, args ...interface{}) { std.Printf(format, args...) }
+
+// Warnf logs the message as log.Warningln
+func Warnf(format string, args ...interface{}) { std.Warnf(format, args...) }
+
+// Warningf logs the message as log.Warningln
+func Warningf(format string, args ...interface{}) { std.Warningf(format, args...) }
+
+// Errorf logs the message as log.Errorln
+func Errorf(format string, args ...interface{}) { std.Errorf(format, args...) }
+
+// Fatalf logs the message as log.Fatalln and exits to OS with status 1
+func Fatalf(format string, args ...interface{}) { std.Fatalf(format, args...) }
+
+// Panicf logs the message as log.Panicln and panics
+func Panicf(format string, args ...interface{}) { std.Panicf(format, args...) }
+
+// Traceln logs the message as log.Debugln
+func Traceln(args ...interface{}) { std.Traceln(args...) }
+
+// Debugln logs the message as log.Debugln
+func Debugln(args ...interface{}) { std.Debugln(args...) }
+
+// Infoln logs the message as log.Println
+func Infoln(args ...interface{}) { std.Infoln(args...) }
+
+// Println logs the message as log.Println
+func Println(args ...interface{}) { std.Println(args...) }
+
+// Warnln logs the message as log.Warningln
+func Warnln(args ...interface{}) { std.Warnln(args...) }
+
+// Warningln logs the message as log.Warningln
+func Warningln(args ...interface{}) { std.Warningln(args...) }
+
+// Errorln logs the message as log.Errorln
+func Errorln(args ...interface{}) { std.Errorln(args...) }
+
+// Fatalln logs the message as log.Fatalln and exits to OS with status 1
+func Fatalln(args ...interface{}) { std.Fatalln(args...) }
+
+// Panicln logs the message as log.Panicln and panics
+func Panicln(args ...interface{}) { std.Panicln(args...) }
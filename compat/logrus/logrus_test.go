@@ -0,0 +1,56 @@
+package logrus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestLogrus(t *testing.T) {
+	var entries []*log.Entry
+	log.RemoveAdapter("stdout")
+	log.AddAdapter("capture", log.AdapterPod{
+		Adapter: func(e *log.Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+	defer log.RemoveAdapter("capture")
+
+	SetLevel(InfoLevel)
+	Debug("hidden")
+	Info("info")
+	WithFields(Fields{"user": "ana", "id": 7}).Warnf("%d retries", 3)
+	WithError(errors.New("boom")).WithField("op", "save").Error("failed")
+	func() {
+		defer func() {
+			if r := recover(); r != "broken" {
+				t.Errorf("expected panic value \"broken\", but got %q", r)
+			}
+		}()
+		WithField("op", "load").Panic("broken")
+	}()
+
+	expected := []struct {
+		m      log.MsgType
+		msg    string
+		fields string
+	}{
+		{log.MessageLog, "info", "map[]"},
+		{log.WarningLog, "3 retries", "map[id:7 user:ana]"},
+		{log.ErrorLog, "failed", "map[error:boom op:save]"},
+		{log.PanicLog, "broken", "map[op:load]"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, but got %d", len(expected), len(entries))
+	}
+	for i, e := range entries {
+		if e.Type != expected[i].m || e.Message() != expected[i].msg {
+			t.Errorf("expected %v %q, but got %v %q", expected[i].m, expected[i].msg, e.Type, e.Message())
+		}
+		if fields := fmt.Sprint(e.Fields); fields != expected[i].fields {
+			t.Errorf("expected fields %s, but got %s", expected[i].fields, fields)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Package zap implements the basic API of go.uber.org/zap on top of
+// github.com/nuveo/log, so programs can migrate by changing only the
+// import path. Fields are added to the entries with log.WithFields, a
+// field overrides the ones added before it with the same key.
+package zap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// Field is a key/value pair added to a message
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any creates a field with any value
+func Any(key string, value interface{}) Field { return Field{Key: key, Value: value} }
+
+// String creates a string field
+func String(key, value string) Field { return Field{Key: key, Value: value} }
+
+// Int creates an int field
+func Int(key string, value int) Field { return Field{Key: key, Value: value} }
+
+// Int64 creates an int64 field
+func Int64(key string, value int64) Field { return Field{Key: key, Value: value} }
+
+// Float64 creates a float64 field
+func Float64(key string, value float64) Field { return Field{Key: key, Value: value} }
+
+// Bool creates a bool field
+func Bool(key string, value bool) Field { return Field{Key: key, Value: value} }
+
+// Duration creates a time.Duration field
+func Duration(key string, value time.Duration) Field { return Field{Key: key, Value: value} }
+
+// Time creates a time.Time field
+func Time(key string, value time.Time) Field { return Field{Key: key, Value: value} }
+
+// Error creates the field "error" with err
+func Error(err error) Field { return Field{Key: "error", Value: err} }
+
+// NamedError creates an error field with the name key
+func NamedError(key string, err error) Field { return Field{Key: key, Value: err} }
+
+// Logger forwards the messages to github.com/nuveo/log
+type Logger struct {
+	fields []Field
+	nop    bool
+}
+
+var global = NewNop()
+
+// NewProduction creates a Logger, debug messages are hidden unless
+// log.DebugMode is enabled.
+func NewProduction(opts ...interface{}) (*Logger, error) {
+	return &Logger{}, nil
+}
+
+// NewDevelopment creates a Logger and enables log.DebugMode
+func NewDevelopment(opts ...interface{}) (*Logger, error) {
+	log.SetDebugMode(true)
+	return &Logger{}, nil
+}
+
+// NewExample creates a Logger, as NewProduction
+func NewExample(opts ...interface{}) *Logger {
+	return &Logger{}
+}
+
+// NewNop creates a Logger that discards the messages, Panic still panics
+// and Fatal still exits to OS.
+func NewNop() *Logger {
+	return &Logger{nop: true}
+}
+
+// L returns the global Logger
+func L() *Logger {
+	return global
+}
+
+// S returns the global SugaredLogger
+func S() *SugaredLogger {
+	return global.Sugar()
+}
+
+// ReplaceGlobals replaces the global Logger and returns a function to
+// restore the previous one.
+func ReplaceGlobals(l *Logger) func() {
+	prev := global
+	global = l
+	return func() { ReplaceGlobals(prev) }
+}
+
+// With returns a child Logger with the fields added
+func (l *Logger) With(fields ...Field) *Logger {
+	f := make([]Field, 0, len(l.fields)+len(fields))
+	f = append(f, l.fields...)
+	f = append(f, fields...)
+	return &Logger{fields: f, nop: l.nop}
+}
+
+// Named is accepted for compatibility, the name is added as the field
+// "logger".
+func (l *Logger) Named(name string) *Logger {
+	return l.With(String("logger", name))
+}
+
+// Sugar wraps the Logger in a SugaredLogger
+func (l *Logger) Sugar() *SugaredLogger {
+	return &SugaredLogger{base: l}
+}
+
+// Sync flushes the adapters
+func (l *Logger) Sync() error {
+	if l.nop {
+		return nil
+	}
+	return log.Flush()
+}
+
+// logger returns a log.FieldLogger with the fields of l and fields
+func (l *Logger) logger(fields []Field) *log.FieldLogger {
+	f := make(log.Fields, len(l.fields)+len(fields))
+	for _, v := range l.fields {
+		f[v.Key] = v.Value
+	}
+	for _, v := range fields {
+		f[v.Key] = v.Value
+	}
+	return log.WithFields(f)
+}
+
+// Debug logs the message as log.Debugln
+func (l *Logger) Debug(msg string, fields ...Field) {
+	if !l.nop {
+		l.logger(fields).Debugln(msg)
+	}
+}
+
+// Info logs the message as log.Println
+func (l *Logger) Info(msg string, fields ...Field) {
+	if !l.nop {
+		l.logger(fields).Println(msg)
+	}
+}
+
+// Warn logs the message as log.Warningln
+func (l *Logger) Warn(msg string, fields ...Field) {
+	if !l.nop {
+		l.logger(fields).Warningln(msg)
+	}
+}
+
+// Error logs the message as log.Errorln
+func (l *Logger) Error(msg string, fields ...Field) {
+	if !l.nop {
+		l.logger(fields).Errorln(msg)
+	}
+}
+
+// DPanic logs the message as log.Panicln if log.DebugMode is enabled, as
+// log.Errorln otherwise
+func (l *Logger) DPanic(msg string, fields ...Field) {
+	if !log.GetDebugMode() {
+		l.Error(msg, fields...)
+		return
+	}
+	l.Panic(msg, fields...)
+}
+
+// Panic logs the message as log.Panicln and panics
+func (l *Logger) Panic(msg string, fields ...Field) {
+	if l.nop {
+		panic(msg)
+	}
+	l.logger(fields).Panicln(msg)
+}
+
+// Fatal logs the message as log.Fatalln and exits to OS with status 1
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	if l.nop {
+		log.Exit(1)
+		return
+	}
+	l.logger(fields).Fatalln(msg)
+}
+
+// SugaredLogger implements the loosely typed API of zap
+type SugaredLogger struct {
+	base *Logger
+}
+
+// Desugar returns the Logger wrapped by s
+func (s *SugaredLogger) Desugar() *Logger {
+	return s.base
+}
+
+// With returns a child SugaredLogger with the key/value pairs added
+func (s *SugaredLogger) With(keysAndValues ...interface{}) *SugaredLogger {
+	return &SugaredLogger{base: s.base.With(sweeten(keysAndValues)...)}
+}
+
+// Sync flushes the adapters
+func (s *SugaredLogger) Sync() error {
+	return s.base.Sync()
+}
+
+// sweeten converts loosely typed key/value pairs in fields, a Field is
+// used as is and a key without value is kept as "!BADKEY".
+func sweeten(keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		if f, ok := keysAndValues[i].(Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			fields = append(fields, Any("!BADKEY", keysAndValues[i]))
+			break
+		}
+		fields = append(fields, Any(fmt.Sprint(keysAndValues[i]), keysAndValues[i+1]))
+		i++
+	}
+	return fields
+}
+
+// Debug logs the message as log.Debugln
+func (s *SugaredLogger) Debug(args ...interface{}) { s.base.Debug(fmt.Sprint(args...)) }
+
+// Info logs the message as log.Println
+func (s *SugaredLogger) Info(args ...interface{}) { s.base.Info(fmt.Sprint(args...)) }
+
+// Warn logs the message as log.Warningln
+func (s *SugaredLogger) Warn(args ...interface{}) { s.base.Warn(fmt.Sprint(args...)) }
+
+// Error logs the message as log.Errorln
+func (s *SugaredLogger) Error(args ...interface{}) { s.base.Error(fmt.Sprint(args...)) }
+
+// Panic logs the message as log.Panicln and panics
+func (s *SugaredLogger) Panic(args ...interface{}) { s.base.Panic(fmt.Sprint(args...)) }
+
+// Fatal logs the message as log.Fatalln and exits to OS
+func (s *SugaredLogger) Fatal(args ...interface{}) { s.base.Fatal(fmt.Sprint(args...)) }
+
+// Debugf logs the message as log.Debugln
+func (s *SugaredLogger) Debugf(template string, args ...interface{}) {
+	s.base.Debug(fmt.Sprintf(template, args...))
+}
+
+// Infof logs the message as log.Println
+func (s *SugaredLogger) Infof(template string, args ...interface{}) {
+	s.base.Info(fmt.Sprintf(template, args...))
+}
+
+// Warnf logs the message as log.Warningln
+func (s *SugaredLogger) Warnf(template string, args ...interface{}) {
+	s.base.Warn(fmt.Sprintf(template, args...))
+}
+
+// Errorf logs the message as log.Errorln
+func (s *SugaredLogger) Errorf(template string, args ...interface{}) {
+	s.base.Error(fmt.Sprintf(template, args...))
+}
+
+// Panicf logs the message as log.Panicln and panics
+func (s *SugaredLogger) Panicf(template string, args ...interface{}) {
+	s.base.Panic(fmt.Sprintf(template, args...))
+}
+
+// Fatalf logs the message as log.Fatalln and exits to OS
+func (s *SugaredLogger) Fatalf(template string, args ...interface{}) {
+	s.base.Fatal(fmt.Sprintf(template, args...))
+}
+
+// Debugw logs the message and key/value pairs as log.Debugln
+func (s *SugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.base.Debug(msg, sweeten(keysAndValues)...)
+}
+
+// Infow logs the message and key/value pairs as log.Println
+func (s *SugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.base.Info(msg, sweeten(keysAndValues)...)
+}
+
+// Warnw logs the message and key/value pairs as log.Warningln
+func (s *SugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.base.Warn(msg, sweeten(keysAndValues)...)
+}
+
+// Errorw logs the message and key/value pairs as log.Errorln
+func (s *SugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.base.Error(msg, sweeten(keysAndValues)...)
+}
+
+// Panicw logs the message and key/value pairs as log.Panicln and panics
+func (s *SugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.base.Panic(msg, sweeten(keysAndValues)...)
+}
+
+// Fatalw logs the message and key/value pairs as log.Fatalln and exits to OS
+func (s *SugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.base.Fatal(msg, sweeten(keysAndValues)...)
+}
@@ -0,0 +1,68 @@
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestZap(t *testing.T) {
+	var entries []*log.Entry
+	log.RemoveAdapter("stdout")
+	log.AddAdapter("capture", log.AdapterPod{
+		Adapter: func(e *log.Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+	defer log.RemoveAdapter("capture")
+
+	logger, err := NewProduction()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	l := logger.With(String("service", "api"))
+	l.Info("started", Int("port", 8080))
+	l.Error("request failed", Error(errors.New("timeout")))
+
+	sugar := l.Sugar()
+	sugar.Warnw("slow query", "ms", 120, "table")
+	sugar.Infof("%d users", 3)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "broken" {
+				t.Errorf("expected panic value \"broken\", but got %q", r)
+			}
+		}()
+		l.Panic("broken", String("ssn", "123"))
+	}()
+
+	nop := NewNop().With(String("service", "api"))
+	nop.Info("hidden")
+	nop.Sugar().Errorw("hidden", "ms", 1)
+
+	expected := []struct {
+		m      log.MsgType
+		msg    string
+		fields string
+	}{
+		{log.MessageLog, "started", "map[port:8080 service:api]"},
+		{log.ErrorLog, "request failed", "map[error:timeout service:api]"},
+		{log.WarningLog, "slow query", "map[!BADKEY:table ms:120 service:api]"},
+		{log.MessageLog, "3 users", "map[service:api]"},
+		{log.PanicLog, "broken", "map[service:api ssn:123]"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, but got %d", len(expected), len(entries))
+	}
+	for i, e := range entries {
+		if e.Type != expected[i].m || e.Message() != expected[i].msg {
+			t.Errorf("expected %v %q, but got %v %q", expected[i].m, expected[i].msg, e.Type, e.Message())
+		}
+		if fields := fmt.Sprint(e.Fields); fields != expected[i].fields {
+			t.Errorf("expected fields %s, but got %s", expected[i].fields, fields)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ContextInfo describes why the context of the entry was done, e.g.
+// "context deadline exceeded 350ms ago", it returns an empty string if
+// the entry was not logged by a *Ctx function or the context was active.
+func (e *Entry) ContextInfo() string {
+	if e.CtxErr == nil {
+		return ""
+	}
+	if e.CtxErr == context.DeadlineExceeded && !e.Deadline.IsZero() {
+		return fmt.Sprintf("%v %v ago", e.CtxErr, e.Time.Sub(e.Deadline).Round(time.Millisecond))
+	}
+	return e.CtxErr.Error()
+}
+
+type loggerKey struct{}
+
+// WithContext returns a copy of ctx carrying the logger of ctx with the
+// fields added, e.g. the request ID, so the functions called with the
+// context log them, see FromContext.
+func WithContext(ctx context.Context, fields Fields) context.Context {
+	return FromContext(ctx).WithFields(fields).WithContext(ctx)
+}
+
+// FromContext returns the logger stored in ctx by WithContext, a
+// FieldLogger of the default logger without fields if there is none. The
+// *Ctx functions log with it.
+func FromContext(ctx context.Context) *FieldLogger {
+	if l, ok := ctx.Value(loggerKey{}).(*FieldLogger); ok {
+		return l
+	}
+	return &FieldLogger{}
+}
+
+// WithContext returns a copy of ctx carrying l, retrieved by FromContext
+func (l *FieldLogger) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// WithContext returns a copy of ctx carrying l, retrieved by FromContext
+func (l *Logger) WithContext(ctx context.Context) context.Context {
+	return (&FieldLogger{logger: l}).WithContext(ctx)
+}
+
+func runAdaptersCtx(ctx context.Context, m MsgType, o OutType, msg ...interface{}) {
+	l := FromContext(ctx)
+	e := l.logger.logger().newEntry(l.fields, m, o, msg...)
+	e.CtxErr = ctx.Err()
+	e.Deadline, _ = ctx.Deadline()
+	e.Verbose = IsDebug(ctx)
+	dispatch(e)
+}
+
+// ErrorlnCtx works like Errorln, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func ErrorlnCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, ErrorLog, LineOut, msg...)
+}
+
+// ErrorfCtx works like Errorf, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func ErrorfCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, ErrorLog, FormattedOut, msg...)
+}
+
+// WarninglnCtx works like Warningln, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func WarninglnCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, WarningLog, LineOut, msg...)
+}
+
+// WarningfCtx works like Warningf, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func WarningfCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, WarningLog, FormattedOut, msg...)
+}
+
+// PrintlnCtx works like Println, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func PrintlnCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, MessageLog, LineOut, msg...)
+}
+
+// PrintfCtx works like Printf, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func PrintfCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, MessageLog, FormattedOut, msg...)
+}
+
+// DebuglnCtx works like Debugln, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func DebuglnCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, DebugLog, LineOut, msg...)
+}
+
+// DebugfCtx works like Debugf, annotating the message if ctx was
+// canceled or its deadline exceeded.
+func DebugfCtx(ctx context.Context, msg ...interface{}) {
+	runAdaptersCtx(ctx, DebugLog, FormattedOut, msg...)
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestErrorlnCtx(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), now().Add(-350*time.Millisecond))
+	defer cancel()
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"active", context.Background(), "\x1b[91m" + timeFormated + " [error] failed\x1b[0;00m\n"},
+		{"canceled", canceled, "\x1b[91m" + timeFormated + " [error] failed (context canceled)\x1b[0;00m\n"},
+		{"deadline", expired, "\x1b[91m" + timeFormated + " [error] failed (context deadline exceeded 350ms ago)\x1b[0;00m\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errorln := func(msg ...interface{}) { ErrorlnCtx(tc.ctx, msg...) }
+			out, err := getOutput(errorln, "failed")
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if string(out) != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestLoggerContext(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	OutputFormat = JSONFormatter
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	if l := FromContext(context.Background()); len(l.Fields()) != 0 {
+		t.Fatalf("Error, fields %v without a logger in the context", l.Fields())
+	}
+	ctx := WithContext(context.Background(), Fields{"request_id": "r1"})
+	ctx = WithContext(ctx, Fields{"user_id": 7})
+
+	check := func(logFunc func()) {
+		t.Helper()
+		buf.Reset()
+		logFunc()
+		var line struct {
+			Fields Fields `json:"fields"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("Error, %v decoding %q", err, buf.String())
+		}
+		if line.Fields["request_id"] != "r1" || line.Fields["user_id"] != float64(7) {
+			t.Fatalf("Error, fields %v, expected the fields of the context", line.Fields)
+		}
+	}
+	check(func() { FromContext(ctx).Println("context test") })
+	check(func() { PrintlnCtx(ctx, "context test") })
+	check(func() {
+		ctx := New(WithOutput(&buf), WithFormatter(JSONFormatter)).WithContext(context.Background())
+		ErrorlnCtx(WithContext(ctx, Fields{"request_id": "r1", "user_id": 7}), "context test")
+	})
+}
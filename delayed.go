@@ -0,0 +1,95 @@
+package log
+
+import "time"
+
+// DelayedLogger logs its messages only after a delay, see After
+type DelayedLogger struct {
+	logger *Logger
+	fields []field
+	delay  time.Duration
+}
+
+// DelayedEntry is a message of a DelayedLogger waiting for its delay
+type DelayedEntry struct {
+	timer *time.Timer
+}
+
+// After returns a DelayedLogger of the default logger, that logs its
+// messages after d unless they are canceled before, so they appear only
+// when an operation is slow, e.g.
+//
+//	wait := log.After(2 * time.Second).Warningf("still waiting for %s", addr)
+//	conn, err := dial(addr)
+//	wait.Cancel()
+func After(d time.Duration) *DelayedLogger {
+	return std.After(d)
+}
+
+// After returns a DelayedLogger of l
+func (l *Logger) After(d time.Duration) *DelayedLogger {
+	return &DelayedLogger{logger: l, delay: d}
+}
+
+// After returns a DelayedLogger of l adding the fields of l to its
+// entries
+func (l *FieldLogger) After(d time.Duration) *DelayedLogger {
+	return &DelayedLogger{logger: l.logger, fields: l.fields, delay: d}
+}
+
+// Cancel stops the message from being logged, it reports false if it was
+// already logged or canceled.
+func (d *DelayedEntry) Cancel() bool {
+	return d.timer.Stop()
+}
+
+// runAdapters logs the message after the delay, with the time it is
+// logged and the caller of the log function. The level is checked when
+// it is logged.
+func (l *DelayedLogger) runAdapters(m MsgType, o OutType, msg ...interface{}) *DelayedEntry {
+	c, file := caller(2)
+	return &DelayedEntry{timer: time.AfterFunc(l.delay, func() {
+		e := l.logger.newEntry(l.fields, m, o, msg...)
+		e.Caller, e.callerFile = c, file
+		dispatch(e)
+	})}
+}
+
+// Errorln works like log.Errorln after the delay
+func (l *DelayedLogger) Errorln(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(ErrorLog, LineOut, msg...)
+}
+
+// Errorf works like log.Errorf after the delay
+func (l *DelayedLogger) Errorf(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(ErrorLog, FormattedOut, msg...)
+}
+
+// Warningln works like log.Warningln after the delay
+func (l *DelayedLogger) Warningln(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(WarningLog, LineOut, msg...)
+}
+
+// Warningf works like log.Warningf after the delay
+func (l *DelayedLogger) Warningf(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(WarningLog, FormattedOut, msg...)
+}
+
+// Println works like log.Println after the delay
+func (l *DelayedLogger) Println(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(MessageLog, LineOut, msg...)
+}
+
+// Printf works like log.Printf after the delay
+func (l *DelayedLogger) Printf(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(MessageLog, FormattedOut, msg...)
+}
+
+// Debugln works like log.Debugln after the delay
+func (l *DelayedLogger) Debugln(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(DebugLog, LineOut, msg...)
+}
+
+// Debugf works like log.Debugf after the delay
+func (l *DelayedLogger) Debugf(msg ...interface{}) *DelayedEntry {
+	return l.runAdapters(DebugLog, FormattedOut, msg...)
+}
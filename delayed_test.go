@@ -0,0 +1,54 @@
+package log
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAfter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	DebugMode = true
+
+	var (
+		mu      sync.Mutex
+		entries []*Entry
+	)
+	logged := make(chan struct{}, 1)
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		mu.Lock()
+		entries = append(entries, e)
+		mu.Unlock()
+		logged <- struct{}{}
+	}})
+	RemoveAdapter("stdout")
+
+	canceled := After(time.Hour).Warningln("never")
+	if !canceled.Cancel() {
+		t.Fatal("Error, Cancel reported the message as logged")
+	}
+	slow := WithField("dep", "db").After(time.Millisecond).Warningf("still waiting for %s", "db")
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error, delayed message not logged")
+	}
+	if slow.Cancel() {
+		t.Fatal("Error, Cancel reported the logged message as canceled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(entries) != 1 {
+		t.Fatalf("Error, expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Type != WarningLog || e.Message() != "still waiting for db" || e.Fields["dep"] != "db" {
+		t.Fatalf("Error, logged %v %q %v", e.Type, e.Message(), e.Fields)
+	}
+	if !regexp.MustCompile(`^delayed_test.go:\d+$`).MatchString(e.Caller) {
+		t.Fatalf("Error, caller %q", e.Caller)
+	}
+}
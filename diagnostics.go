@@ -0,0 +1,165 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fields of the position of the diagnostics, set by At
+const (
+	FileKey   = "file"
+	LineKey   = "line"
+	ColumnKey = "column"
+	// RuleKey is the optional field of the rule that found the problem,
+	// the ruleId of the SARIF results
+	RuleKey = "rule"
+)
+
+// Diagnostic is a warning or error collected by CollectDiagnostics
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Rule    string
+	Type    MsgType
+	Message string
+}
+
+var (
+	collecting      bool
+	diagnostics     []Diagnostic
+	diagnosticsLock = sync.Mutex{}
+)
+
+// At returns a FieldLogger of the default logger that adds the position
+// of a problem in a source file, as reported by lint-style tools, e.g.
+// log.At("main.go", 12, 3).Warningln("unused variable x"). Zero line and
+// column are not added.
+func At(file string, line, column int) *FieldLogger {
+	return (&FieldLogger{}).At(file, line, column)
+}
+
+// At returns a FieldLogger of l that adds the position of a problem in a
+// source file
+func (l *Logger) At(file string, line, column int) *FieldLogger {
+	return (&FieldLogger{logger: l}).At(file, line, column)
+}
+
+// At returns a child of l that adds the position of a problem in a
+// source file
+func (l *FieldLogger) At(file string, line, column int) *FieldLogger {
+	fields := Fields{FileKey: file}
+	if line > 0 {
+		fields[LineKey] = line
+	}
+	if column > 0 {
+		fields[ColumnKey] = column
+	}
+	return l.WithFields(fields)
+}
+
+// CollectDiagnostics starts or stops collecting the warnings and errors of
+// the default logger instead of writing them to the adapters, so they can
+// be written at the end grouped by file with WriteDiagnostics or as SARIF
+// with WriteSARIF. The fatal and panic messages are still written, and
+// the collected messages are counted by Summary. Stopping doesn't discard
+// the diagnostics collected.
+func CollectDiagnostics(enabled bool) {
+	diagnosticsLock.Lock()
+	collecting = enabled
+	diagnosticsLock.Unlock()
+}
+
+// collectDiagnostic adds e to the diagnostics if they are being collected,
+// it reports if e was collected
+func collectDiagnostic(e *Entry) bool {
+	if e.Type != WarningLog && e.Type != ErrorLog {
+		return false
+	}
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+	if !collecting {
+		return false
+	}
+	d := Diagnostic{
+		Type:    e.Type,
+		Message: strings.TrimSuffix(e.Message(), "\n"),
+	}
+	d.File, _ = e.Fields[FileKey].(string)
+	d.Line, _ = e.Fields[LineKey].(int)
+	d.Column, _ = e.Fields[ColumnKey].(int)
+	d.Rule, _ = e.Fields[RuleKey].(string)
+	diagnostics = append(diagnostics, d)
+	return true
+}
+
+// Diagnostics returns the collected diagnostics sorted by file and
+// position, the ones without file first, in the order they were logged
+// for the same position.
+func Diagnostics() []Diagnostic {
+	diagnosticsLock.Lock()
+	d := append([]Diagnostic(nil), diagnostics...)
+	diagnosticsLock.Unlock()
+	sort.SliceStable(d, func(i, j int) bool {
+		if d[i].File != d[j].File {
+			return d[i].File < d[j].File
+		}
+		if d[i].Line != d[j].Line {
+			return d[i].Line < d[j].Line
+		}
+		return d[i].Column < d[j].Column
+	})
+	return d
+}
+
+// ResetDiagnostics discards the collected diagnostics
+func ResetDiagnostics() {
+	diagnosticsLock.Lock()
+	diagnostics = nil
+	diagnosticsLock.Unlock()
+}
+
+// position returns the line:column of d
+func (d Diagnostic) position() string {
+	switch {
+	case d.Line == 0:
+		return ""
+	case d.Column == 0:
+		return fmt.Sprintf("%d", d.Line)
+	}
+	return fmt.Sprintf("%d:%d", d.Line, d.Column)
+}
+
+// WriteDiagnostics writes the collected diagnostics to w grouped by file,
+// each file followed by its diagnostics, e.g.
+//
+//	main.go
+//	  12:3 [warning] unused variable x (unused)
+func WriteDiagnostics(w io.Writer) error {
+	var b strings.Builder
+	file := ""
+	for i, d := range Diagnostics() {
+		if i == 0 || d.File != file {
+			file = d.File
+			if file == "" {
+				b.WriteString("(no file)\n")
+			} else {
+				b.WriteString(file + "\n")
+			}
+		}
+		b.WriteString("  ")
+		if pos := d.position(); pos != "" {
+			b.WriteString(pos + " ")
+		}
+		b.WriteString(levelTag(d.Type) + " " + d.Message)
+		if d.Rule != "" {
+			b.WriteString(" (" + d.Rule + ")")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
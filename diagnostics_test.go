@@ -0,0 +1,45 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	CollectDiagnostics(true)
+	defer CollectDiagnostics(false)
+	defer ResetDiagnostics()
+	before, _ := Counts()
+
+	At("b.go", 3, 0).Errorln("undefined: y")
+	At("a.go", 12, 3).WithField(RuleKey, "unused").Warningln("unused variable x")
+	At("a.go", 2, 1).Warningf("shadowed %s", "err")
+	Warningln("no go files in testdata")
+	Println("checked 2 files")
+
+	if buf.String() == "" || bytes.Contains(buf.Bytes(), []byte("unused")) {
+		t.Fatalf("Error, wrote %q, expected only the info message", buf.String())
+	}
+	if w, _ := Counts(); w-before != 3 {
+		t.Fatalf("Error, %d warnings counted, expected 3", w-before)
+	}
+
+	var out bytes.Buffer
+	if err := WriteDiagnostics(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "(no file)\n" +
+		"  [warning] no go files in testdata\n" +
+		"a.go\n" +
+		"  2:1 [warning] shadowed err\n" +
+		"  12:3 [warning] unused variable x (unused)\n" +
+		"b.go\n" +
+		"  3 [error] undefined: y\n"
+	if out.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", out.String(), expected)
+	}
+}
@@ -0,0 +1,262 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// diffContext is the count of unchanged lines around the changes of the
+// unified diffs
+const diffContext = 3
+
+// Diff logs at debug level the differences between before and after with
+// the label, on the lines after it. Two strings are compared by lines as
+// a unified diff, other values are compared as by JSON, each changed
+// path on a line:
+//
+//	  spec.replicas: 2 -> 3
+//	+ spec.labels.tier: "web"
+//	- spec.ports[1]: 443
+//
+// The values are compared only if the debug mode is enabled.
+func Diff(label string, before, after interface{}) {
+	std.diff(nil, label, before, after)
+}
+
+// Diff works like log.Diff logging to the adapters of l
+func (l *Logger) Diff(label string, before, after interface{}) {
+	l.diff(nil, label, before, after)
+}
+
+// Diff works like log.Diff adding the fields of l
+func (l *FieldLogger) Diff(label string, before, after interface{}) {
+	l.logger.diff(l.fields, label, before, after)
+}
+
+func (l *Logger) diff(fields []field, label string, before, after interface{}) {
+	if !l.isDebug() {
+		return
+	}
+	var lines []string
+	b, okb := before.(string)
+	a, oka := after.(string)
+	if okb && oka {
+		if redacting() {
+			b, a = redactText(b), redactText(a)
+		}
+		lines = unifiedDiff(strings.Split(b, "\n"), strings.Split(a, "\n"))
+	} else {
+		var err error
+		if lines, err = structDiff(before, after); err != nil {
+			dispatch(l.newEntry(fields, DebugLog, LineOut, label, ": ", err))
+			return
+		}
+	}
+	if len(lines) == 0 {
+		dispatch(l.newEntry(fields, DebugLog, LineOut, label, ": no changes"))
+		return
+	}
+	dispatch(l.newEntry(fields, DebugLog, LineOut, label, "\n", strings.Join(lines, "\n")))
+}
+
+// unifiedDiff returns the hunks of the unified diff of the lines
+func unifiedDiff(before, after []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// ops are the lines prefixed by ' ', '-' or '+'
+	var ops []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			ops = append(ops, " "+before[i])
+			i++
+			j++
+		case j == len(after) || (i < len(before) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, "-"+before[i])
+			i++
+		default:
+			ops = append(ops, "+"+after[j])
+			j++
+		}
+	}
+
+	var out []string
+	for k := 0; k < len(ops); {
+		if ops[k][0] == ' ' {
+			k++
+			continue
+		}
+		// the hunk starts diffContext lines before the change and ends
+		// when more than twice diffContext lines are unchanged
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end, same := k, 0
+		for ; end < len(ops) && same <= 2*diffContext; end++ {
+			if ops[end][0] == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+		}
+		end -= same - diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+		bStart, aStart := 1, 1
+		for _, op := range ops[:start] {
+			if op[0] != '+' {
+				bStart++
+			}
+			if op[0] != '-' {
+				aStart++
+			}
+		}
+		bLen, aLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op[0] != '+' {
+				bLen++
+			}
+			if op[0] != '-' {
+				aLen++
+			}
+		}
+		if len(out) == 0 {
+			out = append(out, "--- before", "+++ after")
+		}
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@", hunkRange(bStart, bLen), hunkRange(aStart, aLen)))
+		out = append(out, ops[start:end]...)
+		k = end
+	}
+	return out
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		start--
+	}
+	if n == 1 {
+		return strconv.Itoa(start)
+	}
+	return strconv.Itoa(start) + "," + strconv.Itoa(n)
+}
+
+// structDiff returns the paths changed between the values encoded as by
+// JSON
+func structDiff(before, after interface{}) ([]string, error) {
+	b, err := normalize(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := normalize(after)
+	if err != nil {
+		return nil, err
+	}
+	if redacting() {
+		b, a = redactValue(b), redactValue(a)
+	}
+	var lines []string
+	walkDiff(&lines, "", b, a)
+	return lines, nil
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var n interface{}
+	err = d.Decode(&n)
+	return n, err
+}
+
+func compact(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// walkDiff appends the changes between b and a under path
+func walkDiff(lines *[]string, path string, b, a interface{}) {
+	name := path
+	if name == "" {
+		name = "."
+	}
+	switch bv := b.(type) {
+	case map[string]interface{}:
+		av, ok := a.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(bv)+len(av))
+		for k := range bv {
+			keys = append(keys, k)
+		}
+		for k := range av {
+			if _, ok := bv[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			bk, inB := bv[k]
+			ak, inA := av[k]
+			switch {
+			case !inA:
+				*lines = append(*lines, "- "+p+": "+compact(bk))
+			case !inB:
+				*lines = append(*lines, "+ "+p+": "+compact(ak))
+			default:
+				walkDiff(lines, p, bk, ak)
+			}
+		}
+		return
+	case []interface{}:
+		av, ok := a.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(bv) || i < len(av); i++ {
+			p := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(av):
+				*lines = append(*lines, "- "+p+": "+compact(bv[i]))
+			case i >= len(bv):
+				*lines = append(*lines, "+ "+p+": "+compact(av[i]))
+			default:
+				walkDiff(lines, p, bv[i], av[i])
+			}
+		}
+		return
+	}
+	if bs, as := compact(b), compact(a); bs != as {
+		*lines = append(*lines, "  "+name+": "+bs+" -> "+as)
+	}
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	type spec struct {
+		Replicas int               `json:"replicas"`
+		Labels   map[string]string `json:"labels"`
+		Ports    []int             `json:"ports"`
+	}
+	before := spec{Replicas: 2, Labels: map[string]string{"app": "api"}, Ports: []int{80, 443}}
+	after := spec{Replicas: 3, Labels: map[string]string{"app": "api", "tier": "web"}, Ports: []int{80}}
+
+	Diff("spec", before, after)
+	if buf.Len() != 0 {
+		t.Fatalf("Error, wrote %q out of the debug mode", buf.String())
+	}
+
+	DebugMode = true
+	Diff("spec", before, after)
+	ts := now().Format(TimeFormat)
+	expected := ts + " [debug] diff_test.go:30 spec\n" +
+		ts + ` [debug] + labels.tier: "web"` + "\n" +
+		ts + " [debug] - ports[1]: 443\n" +
+		ts + " [debug]   replicas: 2 -> 3\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	Diff("spec", before, before)
+	if !strings.HasSuffix(buf.String(), " spec: no changes\n") {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := strings.Split("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl", "\n")
+	after := strings.Split("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm", "\n")
+	expected := []string{
+		"--- before",
+		"+++ after",
+		"@@ -1,5 +1,5 @@",
+		" a", "-b", "+B", " c", " d", " e",
+		"@@ -10,3 +10,4 @@",
+		" j", " k", " l", "+m",
+	}
+	got := unifiedDiff(before, after)
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Error, got %q, expected %q", got, expected)
+	}
+	if got := unifiedDiff([]string{"x"}, []string{"x"}); len(got) != 0 {
+		t.Fatalf("Error, expected no hunks, got %q", got)
+	}
+	expected = []string{"--- before", "+++ after", "@@ -0,0 +1 @@", "+x"}
+	if got := unifiedDiff(nil, []string{"x"}); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Error, got %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Fields are key/value pairs attached to an entry
+type Fields map[string]interface{}
+
+// Enricher provides fields attached to every entry, like host or
+// infrastructure metadata. Enrich is called once, the fields are cached.
+type Enricher interface {
+	Enrich() (Fields, error)
+}
+
+// EnricherFunc allows to use a function as an Enricher
+type EnricherFunc func() (Fields, error)
+
+// Enrich calls f
+func (f EnricherFunc) Enrich() (Fields, error) {
+	return f()
+}
+
+type enricherPod struct {
+	enricher Enricher
+	priority int
+	dynamic  bool
+
+	// lock guards once and fields, replaced by RefreshEnrichers
+	lock   sync.Mutex
+	once   *sync.Once
+	fields Fields
+}
+
+var (
+	// enrichers is replaced, not changed, so enrichFields can range over
+	// it without the lock
+	enrichers  []*enricherPod
+	enrichLock = sync.Mutex{}
+)
+
+// AddEnricher registers e, the enrichers run in ascending priority order,
+// in registration order for the same priority, and the fields of the
+// later ones override the fields of the previous ones with the same key.
+func AddEnricher(e Enricher, priority int) {
+	addEnricher(&enricherPod{enricher: e, priority: priority, once: new(sync.Once)})
+}
+
+// AddDynamicEnricher registers e to run for every entry instead of once,
+// for values that change while the program runs, like the variant of a
+// feature flag or the current migration step. It must be fast and must
+// not log; entries are logged without its fields when it fails.
+func AddDynamicEnricher(e Enricher, priority int) {
+	addEnricher(&enricherPod{enricher: e, priority: priority, dynamic: true})
+}
+
+func addEnricher(p *enricherPod) {
+	enrichLock.Lock()
+	list := make([]*enricherPod, len(enrichers), len(enrichers)+1)
+	copy(list, enrichers)
+	list = append(list, p)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].priority < list[j].priority
+	})
+	enrichers = list
+	enrichLock.Unlock()
+}
+
+// RemoveEnrichers removes all the enrichers
+func RemoveEnrichers() {
+	enrichLock.Lock()
+	enrichers = nil
+	enrichLock.Unlock()
+}
+
+// RefreshEnrichers discards the cached fields, the enrichers will run
+// again for the next entry.
+func RefreshEnrichers() {
+	enrichLock.Lock()
+	list := enrichers
+	enrichLock.Unlock()
+	for _, p := range list {
+		if !p.dynamic {
+			p.lock.Lock()
+			p.once = new(sync.Once)
+			p.lock.Unlock()
+		}
+	}
+}
+
+// static returns the cached fields of p, running its enricher the first
+// time. Only the caller that ran it gets the error. The entries logged
+// while it runs wait for it, without holding enrichLock.
+func (p *enricherPod) static() (Fields, error) {
+	p.lock.Lock()
+	once := p.once
+	p.lock.Unlock()
+	var err error
+	once.Do(func() {
+		var f Fields
+		f, err = p.enricher.Enrich()
+		p.lock.Lock()
+		p.fields = f
+		p.lock.Unlock()
+	})
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.fields, err
+}
+
+// enrichFields returns the fields of the enrichers, running them if
+// needed. Errors of the enrichers that run once are logged as warnings.
+// The enrichers run without enrichLock, so a slow one doesn't stop the
+// goroutines logging without it.
+func enrichFields() Fields {
+	enrichLock.Lock()
+	list := enrichers
+	enrichLock.Unlock()
+	var (
+		errs   []error
+		fields Fields
+	)
+	for _, p := range list {
+		var f Fields
+		if p.dynamic {
+			f, _ = p.enricher.Enrich()
+		} else {
+			var err error
+			if f, err = p.static(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(f) == 0 {
+			continue
+		}
+		if fields == nil {
+			fields = make(Fields, len(f))
+		}
+		for k, v := range f {
+			fields[k] = v
+		}
+	}
+	for _, err := range errs {
+		Warningln("log: enricher failed:", err)
+	}
+	return fields
+}
+
+// HostEnricher attaches the host name, the process id and the program name
+var HostEnricher = EnricherFunc(func() (Fields, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return Fields{
+		"host":    host,
+		"pid":     os.Getpid(),
+		"program": filepath.Base(os.Args[0]),
+	}, nil
+})
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnrichers(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var entries []*Entry
+	RemoveAdapter("stdout")
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+
+	calls := 0
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		calls++
+		return Fields{"zone": "b", "region": "us"}, nil
+	}), 10)
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		return Fields{"zone": "a", "app": "api"}, nil
+	}), 0)
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		return nil, errors.New("metadata unavailable")
+	}), 5)
+
+	Println("first")
+	Println("second")
+
+	if calls != 1 {
+		t.Fatalf("Error, expected enricher to run once, got %d", calls)
+	}
+	// the warning of the failed enricher, first and second
+	if len(entries) != 3 {
+		t.Fatalf("Error, expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Type != WarningLog {
+		t.Fatalf("Error, expected enricher warning, got %q", entries[0].Message())
+	}
+	expected := Fields{"zone": "b", "region": "us", "app": "api"}
+	for _, e := range entries {
+		if !reflect.DeepEqual(e.Fields, expected) {
+			t.Fatalf("Error, expected fields %v, got %v", expected, e.Fields)
+		}
+	}
+	entries[1].Fields["zone"] = "changed"
+	if entries[2].Fields["zone"] != "b" {
+		t.Fatal("Error, entries share the fields")
+	}
+
+	RefreshEnrichers()
+	Println("third")
+	if calls != 2 {
+		t.Fatalf("Error, expected enricher to run again, got %d", calls)
+	}
+}
+
+func TestHostEnricher(t *testing.T) {
+	f, err := HostEnricher.Enrich()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	host, _ := os.Hostname()
+	if f["host"] != host || f["pid"] != os.Getpid() {
+		t.Fatalf("Error, unexpected fields %v", f)
+	}
+}
+
+func TestDynamicEnricher(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var entries []*Entry
+	RemoveAdapter("stdout")
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+
+	variant := "a"
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		return Fields{"flag": "static"}, nil
+	}), 0)
+	AddDynamicEnricher(EnricherFunc(func() (Fields, error) {
+		if variant == "" {
+			return nil, errors.New("no variant")
+		}
+		return Fields{"flag": variant}, nil
+	}), 1)
+
+	Println("first")
+	variant = "b"
+	Println("second")
+	variant = ""
+	Println("third")
+
+	expected := []interface{}{"a", "b", "static"}
+	if len(entries) != len(expected) {
+		t.Fatalf("Error, expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, e := range entries {
+		if e.Fields["flag"] != expected[i] {
+			t.Errorf("Error, expected flag %v, got %v", expected[i], e.Fields["flag"])
+		}
+	}
+}
+
+func TestSlowEnricher(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		once.Do(func() { close(started) })
+		<-release
+		return Fields{"zone": "a"}, nil
+	}), 0)
+	done := make(chan struct{})
+	go func() {
+		Println("first")
+		close(done)
+	}()
+	<-started
+
+	// the registration doesn't wait for the enricher running
+	registered := make(chan struct{})
+	go func() {
+		AddDynamicEnricher(EnricherFunc(func() (Fields, error) {
+			return Fields{"step": 1}, nil
+		}), 1)
+		RefreshEnrichers()
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error, AddDynamicEnricher waited for the enricher")
+	}
+	close(release)
+	<-done
+	if f := enrichFields(); f["zone"] != "a" || f["step"] != 1 {
+		t.Fatalf("Error, fields %v", f)
+	}
+}
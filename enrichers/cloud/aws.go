@@ -0,0 +1,52 @@
+package cloud
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nuveo/log"
+)
+
+// AWS returns an enricher for the EC2 instance metadata service, using
+// IMDSv2 session tokens.
+func AWS() *Enricher {
+	return newEnricher("http://169.254.169.254", fetchAWS)
+}
+
+func fetchAWS(e *Enricher) (log.Fields, error) {
+	req, err := http.NewRequest(http.MethodPut, e.BaseURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := e.get(req)
+	if err != nil {
+		return nil, err
+	}
+
+	get := func(path string) (string, error) {
+		req, err := http.NewRequest(http.MethodGet, e.BaseURL+"/latest/meta-data/"+path, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		b, err := e.get(req)
+		return strings.TrimSpace(string(b)), err
+	}
+
+	fields := log.Fields{ProviderField: "aws"}
+	paths := map[string]string{
+		InstanceField: "instance-id",
+		ZoneField:     "placement/availability-zone",
+		RegionField:   "placement/region",
+		TypeField:     "instance-type",
+	}
+	for field, path := range paths {
+		v, err := get(path)
+		if err != nil {
+			return nil, err
+		}
+		fields[field] = v
+	}
+	return fields, nil
+}
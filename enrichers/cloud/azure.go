@@ -0,0 +1,44 @@
+package cloud
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nuveo/log"
+)
+
+// Azure returns an enricher for the Azure Instance Metadata Service
+func Azure() *Enricher {
+	return newEnricher("http://169.254.169.254", fetchAzure)
+}
+
+func fetchAzure(e *Enricher) (log.Fields, error) {
+	req, err := http.NewRequest(http.MethodGet, e.BaseURL+"/metadata/instance/compute?api-version=2021-02-01", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	b, err := e.get(req)
+	if err != nil {
+		return nil, err
+	}
+	compute := struct {
+		VMID     string `json:"vmId"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMSize   string `json:"vmSize"`
+	}{}
+	if err = json.Unmarshal(b, &compute); err != nil {
+		return nil, err
+	}
+	fields := log.Fields{
+		ProviderField: "azure",
+		InstanceField: compute.VMID,
+		RegionField:   compute.Location,
+		TypeField:     compute.VMSize,
+	}
+	if compute.Zone != "" {
+		fields[ZoneField] = compute.Zone
+	}
+	return fields, nil
+}
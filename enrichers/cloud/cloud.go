@@ -0,0 +1,90 @@
+// Package cloud implements enrichers that attach the instance metadata of
+// AWS, GCP and Azure to every entry:
+//
+//	log.AddEnricher(cloud.Detect(), 0)
+//
+// The metadata endpoints are queried once, with a short timeout, the
+// fields are cached by the log package.
+package cloud
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/nuveo/log"
+)
+
+// Field names, following the OpenTelemetry semantic conventions
+const (
+	ProviderField = "cloud.provider"
+	RegionField   = "cloud.region"
+	ZoneField     = "cloud.availability_zone"
+	InstanceField = "host.id"
+	TypeField     = "host.type"
+)
+
+// DefaultTimeout of the metadata requests
+const DefaultTimeout = time.Second
+
+// Enricher queries the metadata endpoint of a cloud provider
+type Enricher struct {
+	// BaseURL of the metadata endpoint
+	BaseURL string
+	Client  *http.Client
+	fetch   func(e *Enricher) (log.Fields, error)
+}
+
+func newEnricher(baseURL string, fetch func(e *Enricher) (log.Fields, error)) *Enricher {
+	return &Enricher{
+		BaseURL: baseURL,
+		Client:  &http.Client{Timeout: DefaultTimeout},
+		fetch:   fetch,
+	}
+}
+
+// Enrich queries the metadata endpoint
+func (e *Enricher) Enrich() (log.Fields, error) {
+	return e.fetch(e)
+}
+
+func (e *Enricher) get(req *http.Request) ([]byte, error) {
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("metadata " + req.URL.Path + ": " + resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Detect returns an enricher that queries all providers at the same time
+// and uses the first one that answers, no fields are attached if none
+// answers.
+func Detect() log.Enricher {
+	return detector{AWS(), GCP(), Azure()}
+}
+
+type detector []*Enricher
+
+func (d detector) Enrich() (log.Fields, error) {
+	results := make(chan log.Fields, len(d))
+	for _, e := range d {
+		go func(e *Enricher) {
+			f, err := e.Enrich()
+			if err != nil {
+				f = nil
+			}
+			results <- f
+		}(e)
+	}
+	for range d {
+		if f := <-results; f != nil {
+			return f, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,127 @@
+package cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestAWS(t *testing.T) {
+	values := map[string]string{
+		"/latest/meta-data/instance-id":                 "i-0abc",
+		"/latest/meta-data/placement/availability-zone": "us-east-1a",
+		"/latest/meta-data/placement/region":            "us-east-1",
+		"/latest/meta-data/instance-type":               "t3.micro",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			if r.Method != http.MethodPut {
+				http.Error(w, "method", http.StatusMethodNotAllowed)
+				return
+			}
+			_, _ = w.Write([]byte("token"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			http.Error(w, "token", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(values[r.URL.Path]))
+	}))
+	defer srv.Close()
+
+	e := AWS()
+	e.BaseURL = srv.URL
+	f, err := e.Enrich()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := log.Fields{
+		ProviderField: "aws",
+		InstanceField: "i-0abc",
+		ZoneField:     "us-east-1a",
+		RegionField:   "us-east-1",
+		TypeField:     "t3.micro",
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Fatalf("expected %v, but got %v", expected, f)
+	}
+}
+
+func TestGCP(t *testing.T) {
+	values := map[string]string{
+		"/computeMetadata/v1/instance/id":           "123",
+		"/computeMetadata/v1/instance/zone":         "projects/42/zones/us-central1-a",
+		"/computeMetadata/v1/instance/machine-type": "projects/42/machineTypes/e2-small",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "flavor", http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(values[r.URL.Path]))
+	}))
+	defer srv.Close()
+
+	e := GCP()
+	e.BaseURL = srv.URL
+	f, err := e.Enrich()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := log.Fields{
+		ProviderField: "gcp",
+		InstanceField: "123",
+		ZoneField:     "us-central1-a",
+		RegionField:   "us-central1",
+		TypeField:     "e2-small",
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Fatalf("expected %v, but got %v", expected, f)
+	}
+}
+
+func TestAzure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			http.Error(w, "metadata", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"vmId":"vm-1","location":"westeurope","zone":"2","vmSize":"Standard_B1s"}`))
+	}))
+	defer srv.Close()
+
+	e := Azure()
+	e.BaseURL = srv.URL
+	f, err := e.Enrich()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := log.Fields{
+		ProviderField: "azure",
+		InstanceField: "vm-1",
+		ZoneField:     "2",
+		RegionField:   "westeurope",
+		TypeField:     "Standard_B1s",
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Fatalf("expected %v, but got %v", expected, f)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	d := detector{AWS(), GCP()}
+	for _, e := range d {
+		e.BaseURL = srv.URL
+	}
+	f, err := d.Enrich()
+	if err != nil || f != nil {
+		t.Fatalf("expected no fields, but got %v %v", f, err)
+	}
+}
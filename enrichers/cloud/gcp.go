@@ -0,0 +1,53 @@
+package cloud
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/nuveo/log"
+)
+
+// GCP returns an enricher for the Compute Engine metadata server
+func GCP() *Enricher {
+	return newEnricher("http://metadata.google.internal", fetchGCP)
+}
+
+func fetchGCP(e *Enricher) (log.Fields, error) {
+	get := func(p string) (string, error) {
+		req, err := http.NewRequest(http.MethodGet, e.BaseURL+"/computeMetadata/v1/instance/"+p, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		b, err := e.get(req)
+		return strings.TrimSpace(string(b)), err
+	}
+
+	id, err := get("id")
+	if err != nil {
+		return nil, err
+	}
+	// zone and machine-type are returned as
+	// projects/<number>/zones/<zone> and
+	// projects/<number>/machineTypes/<type>
+	zone, err := get("zone")
+	if err != nil {
+		return nil, err
+	}
+	machineType, err := get("machine-type")
+	if err != nil {
+		return nil, err
+	}
+	zone = path.Base(zone)
+	fields := log.Fields{
+		ProviderField: "gcp",
+		InstanceField: id,
+		ZoneField:     zone,
+		TypeField:     path.Base(machineType),
+	}
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		fields[RegionField] = zone[:i]
+	}
+	return fields, nil
+}
@@ -0,0 +1,132 @@
+// Package kubernetes implements an enricher that attaches the pod
+// metadata to every entry, so logs shipped directly from pods carry the
+// Kubernetes context:
+//
+//	log.AddEnricher(kubernetes.New(), 0)
+//
+// The metadata is read from environment variables set with the downward
+// API, e.g.
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom:
+//	    fieldRef:
+//	      fieldPath: metadata.name
+//
+// and, for the missing ones, from the service account namespace file and
+// the pod host name. Labels are read from a downward API volume file if
+// LabelsFile exists. Outside Kubernetes no field is attached.
+package kubernetes
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nuveo/log"
+)
+
+// Field names, following the OpenTelemetry semantic conventions
+const (
+	PodField       = "k8s.pod.name"
+	NamespaceField = "k8s.namespace.name"
+	NodeField      = "k8s.node.name"
+	ContainerField = "k8s.container.name"
+	LabelPrefix    = "k8s.pod.label."
+)
+
+// Enricher reads the pod metadata
+type Enricher struct {
+	PodNameEnv       string
+	NamespaceEnv     string
+	NodeNameEnv      string
+	ContainerNameEnv string
+	// ServiceAccountDir is the directory with the namespace file of the
+	// service account token mount.
+	ServiceAccountDir string
+	// LabelsFile is the downward API volume file with metadata.labels
+	LabelsFile string
+}
+
+// New creates an Enricher with the usual variable names and paths
+func New() *Enricher {
+	return &Enricher{
+		PodNameEnv:        "POD_NAME",
+		NamespaceEnv:      "POD_NAMESPACE",
+		NodeNameEnv:       "NODE_NAME",
+		ContainerNameEnv:  "CONTAINER_NAME",
+		ServiceAccountDir: "/var/run/secrets/kubernetes.io/serviceaccount",
+		LabelsFile:        "/etc/podinfo/labels",
+	}
+}
+
+// Enrich returns the pod metadata, no fields outside Kubernetes
+func (e *Enricher) Enrich() (log.Fields, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil, nil
+	}
+	fields := log.Fields{}
+	set := func(key, value string) {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+
+	pod := os.Getenv(e.PodNameEnv)
+	if pod == "" {
+		pod, _ = os.Hostname()
+	}
+	set(PodField, pod)
+
+	ns := os.Getenv(e.NamespaceEnv)
+	if ns == "" && e.ServiceAccountDir != "" {
+		b, err := ioutil.ReadFile(filepath.Join(e.ServiceAccountDir, "namespace"))
+		if err == nil {
+			ns = strings.TrimSpace(string(b))
+		}
+	}
+	set(NamespaceField, ns)
+	set(NodeField, os.Getenv(e.NodeNameEnv))
+	set(ContainerField, os.Getenv(e.ContainerNameEnv))
+
+	if e.LabelsFile == "" {
+		return fields, nil
+	}
+	labels, err := readLabels(e.LabelsFile)
+	if os.IsNotExist(err) {
+		return fields, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range labels {
+		fields[LabelPrefix+k] = v
+	}
+	return fields, nil
+}
+
+// readLabels reads a downward API file, with one key="value" per line
+func readLabels(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	labels := make(map[string]string)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		kv := strings.SplitN(s.Text(), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.Unquote(kv[1])
+		if err != nil {
+			v = kv[1]
+		}
+		labels[kv[0]] = v
+	}
+	return labels, s.Err()
+}
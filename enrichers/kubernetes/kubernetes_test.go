@@ -0,0 +1,54 @@
+package kubernetes
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestEnrich(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "namespace"), []byte("payments\n"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	labels := filepath.Join(dir, "labels")
+	err = ioutil.WriteFile(labels, []byte("app=\"api\"\ntier=\"backend\"\n"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	e := New()
+	e.ServiceAccountDir = dir
+	e.LabelsFile = labels
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	f, err := e.Enrich()
+	if err != nil || f != nil {
+		t.Fatalf("Error, expected no fields outside Kubernetes, got %v %v", f, err)
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "")
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("CONTAINER_NAME", "api")
+	f, err = e.Enrich()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := log.Fields{
+		PodField:             "api-7d9f",
+		NamespaceField:       "payments",
+		NodeField:            "node-1",
+		ContainerField:       "api",
+		LabelPrefix + "app":  "api",
+		LabelPrefix + "tier": "backend",
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Fatalf("Error, expected %v, got %v", expected, f)
+	}
+}
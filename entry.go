@@ -0,0 +1,220 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Entry is a log message as received by the adapters
+type Entry struct {
+	// Seq is a monotonic sequence number, adapters that deliver entries
+	// asynchronously or in batches can use it to restore the order in
+	// which they were logged.
+	Seq  uint64
+	Time time.Time
+	Type MsgType
+	Out  OutType
+	Msg  []interface{}
+	// CtxErr is the error of the context given to the *Ctx functions, nil
+	// if the context was active when the message was logged.
+	CtxErr error
+	// Deadline of the context given to the *Ctx functions, if any.
+	Deadline time.Time
+	// Env is the environment of the process, attached to error entries
+	// when CaptureEnv is enabled.
+	Env *Environment
+	// Fields are the key/value pairs attached to the entry
+	Fields Fields
+	// Stack is the stack trace attached to the entry, if any
+	Stack []Frame
+	// Verbose is set when the entry was logged with a context created by
+	// WithDebug.
+	Verbose bool
+	// ID of the entry, created by EntryIDGenerator
+	ID string
+	// Ref is the reference of an error entry, created by
+	// ErrorRefGenerator
+	Ref string
+	// Keys are the sorted keys of the Fields given with WithFields,
+	// rendered with the message by KeyValues.
+	Keys []string
+
+	// Caller is the file:line of the call that logged the entry
+	Caller string
+	// callerFile is the path of the file of Caller, empty for entries
+	// received from other processes
+	callerFile string
+	// Span is the operation of the entries logged by Begin and End
+	Span *Span
+
+	// failures counts the adapters that failed to write the entry
+	failures int32
+	// console is set when an adapter with ConsoleOnFailure failed to
+	// write the entry
+	console int32
+	// pending counts the adapters still writing the entry
+	pending int32
+	// block is set for the entries of Block
+	block bool
+	// payload is the value of the entries of JSON, YAML and XML
+	payload *payload
+	// tx buffers the entry until it is committed
+	tx *Tx
+	// logger that created the entry, nil is the default logger
+	logger *Logger
+}
+
+var seq uint64
+
+func newEntry(m MsgType, o OutType, msg ...interface{}) *Entry {
+	return std.newEntry(nil, m, o, msg...)
+}
+
+// newEntry creates an entry of l with the fields given at the call site
+func (l *Logger) newEntry(fields []field, m MsgType, o OutType, msg ...interface{}) *Entry {
+	e := &Entry{
+		logger: l,
+		Seq:    atomic.AddUint64(&seq, 1),
+		Time:   now(),
+		Type:   m,
+		Out:    o,
+		Msg:    msg,
+	}
+	e.Fields = enrichFields()
+	e.addFields(fields)
+	if redacting() {
+		e.redact()
+	}
+	if len(AnonymizeKey) > 0 {
+		e.anonymize()
+	}
+	if EntryIDGenerator != nil {
+		e.ID = EntryIDGenerator()
+	}
+	e.errorInfo()
+	return e
+}
+
+// errorInfo sets the reference and the environment of error, fatal and
+// panic entries
+func (e *Entry) errorInfo() {
+	if !e.Type.IsError() {
+		return
+	}
+	if ErrorRefGenerator != nil {
+		e.Ref = ErrorRefGenerator()
+	}
+	if CaptureEnv {
+		e.Env = captureEnv()
+	}
+}
+
+// Message returns the text of the entry, formatted if it was logged by
+// one of the *f functions. The line break of *ln functions is not
+// included.
+func (e *Entry) Message() string {
+	if e.Out == FormattedOut {
+		return fmt.Sprintf(e.Msg[0].(string), e.Msg[1:]...)
+	}
+	return fmt.Sprint(e.Msg...)
+}
+
+// SchemaVersion is the version of the envelope written by
+// Entry.MarshalJSON in the "schema_version" field. Fields are only added
+// to the envelope, never renamed or removed, and readers ignore the fields
+// they don't know, so entries written by newer versions of the package
+// are still decoded with the fields known by the reader. Entries without
+// "schema_version" were written before the envelope was versioned and are
+// read as version 1.
+const SchemaVersion = 1
+
+type entryJSON struct {
+	SchemaVersion int          `json:"schema_version"`
+	Seq           uint64       `json:"seq"`
+	Time          time.Time    `json:"time"`
+	Type          MsgType      `json:"type"`
+	Level         string       `json:"level"`
+	Message       string       `json:"message"`
+	Context       string       `json:"context,omitempty"`
+	Env           *Environment `json:"env,omitempty"`
+	Fields        Fields       `json:"fields,omitempty"`
+	Stack         []Frame      `json:"stack,omitempty"`
+	Verbose       bool         `json:"verbose,omitempty"`
+	ID            string       `json:"id,omitempty"`
+	Ref           string       `json:"ref,omitempty"`
+	Keys          []string     `json:"keys,omitempty"`
+	Caller        string       `json:"caller,omitempty"`
+	Span          *Span        `json:"span,omitempty"`
+}
+
+// MarshalJSON encodes the entry as a JSON object, the format used to send
+// entries to other processes.
+func (e *Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(entryJSON{
+		SchemaVersion: SchemaVersion,
+		Seq:           e.Seq,
+		Time:          e.Time,
+		Type:          e.Type,
+		Level:         Prefixes[e.Type],
+		Message:       e.Message(),
+		Context:       e.ContextInfo(),
+		Env:           e.Env,
+		Fields:        e.Fields,
+		Stack:         e.Stack,
+		Verbose:       e.Verbose,
+		ID:            e.ID,
+		Ref:           e.Ref,
+		Keys:          e.Keys,
+		Caller:        e.Caller,
+		Span:          e.Span,
+	})
+}
+
+// UnmarshalJSON decodes an entry encoded by MarshalJSON, the message is
+// restored as a LineOut entry and the context info, if any, is appended
+// to it. A message type unknown to this version is
+// resolved by the level name and, failing that, read as MessageLog.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	v := entryJSON{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.SchemaVersion < 0 {
+		return fmt.Errorf("log: invalid schema version %d", v.SchemaVersion)
+	}
+	msg := v.Message
+	if v.Context != "" {
+		msg += " (" + v.Context + ")"
+	}
+	*e = Entry{
+		Seq:     v.Seq,
+		Time:    v.Time,
+		Type:    resolveType(v.Type, v.Level),
+		Out:     LineOut,
+		Msg:     []interface{}{msg},
+		Env:     v.Env,
+		Fields:  v.Fields,
+		Stack:   v.Stack,
+		Verbose: v.Verbose,
+		ID:      v.ID,
+		Ref:     v.Ref,
+		Keys:    v.Keys,
+		Caller:  v.Caller,
+		Span:    v.Span,
+	}
+	return nil
+}
+
+func resolveType(m MsgType, level string) MsgType {
+	if int(m) < len(Prefixes) {
+		return m
+	}
+	for t, p := range Prefixes {
+		if p == level {
+			return MsgType(t)
+		}
+	}
+	return MessageLog
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEntrySeq(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var entries []*Entry
+	RemoveAdapter("stdout")
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+
+	Println("first")
+	Errorf("%s", "second")
+	Warningln("third")
+
+	if len(entries) != 3 {
+		t.Fatalf("Error, expected 3 entries, got %d", len(entries))
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Seq != entries[i-1].Seq+1 {
+			t.Fatalf("Error, expected sequence %d, got %d", entries[i-1].Seq+1, entries[i].Seq)
+		}
+	}
+	if msg := entries[1].Message(); msg != "second" {
+		t.Fatalf("Error, expected \"second\", got %q", msg)
+	}
+}
+
+func TestEntryJSONCompatibility(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected MsgType
+	}{
+		{"current", `{"schema_version":1,"seq":1,"type":2,"level":"warning","message":"x"}`, WarningLog},
+		{"unversioned", `{"seq":1,"type":4,"level":"error","message":"x"}`, ErrorLog},
+		{"unknown fields", `{"schema_version":9,"type":3,"level":"debug","message":"x","new":{"a":1}}`, DebugLog},
+		{"unknown type", `{"schema_version":9,"type":200,"level":"error","message":"x"}`, ErrorLog},
+		{"unknown level", `{"schema_version":9,"type":200,"level":"notice","message":"x"}`, MessageLog},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Entry{}
+			if err := json.Unmarshal([]byte(tc.data), e); err != nil {
+				t.Fatal(err.Error())
+			}
+			if e.Type != tc.expected || e.Message() != "x" {
+				t.Errorf("expected type %v and message \"x\", but got %v and %q", tc.expected, e.Type, e.Message())
+			}
+		})
+	}
+}
+
+func TestEntryJSONSchemaVersion(t *testing.T) {
+	b, err := json.Marshal(&Entry{Time: time.Unix(1498405744, 0).UTC(), Type: ErrorLog, Msg: []interface{}{"x"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := `{"schema_version":1,"seq":0,"time":"2017-06-25T15:49:04Z","type":4,"level":"error","message":"x"}`
+	if string(b) != expected {
+		t.Fatalf("Error, expected %s, got %s", expected, string(b))
+	}
+}
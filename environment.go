@@ -0,0 +1,87 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Environment of the process attached to error entries when CaptureEnv
+// is enabled
+type Environment struct {
+	// Vars contains the variables listed in CaptureEnvVars, values of
+	// secret variables are replaced by "***".
+	Vars map[string]string `json:"vars,omitempty"`
+	// Dir is the working directory
+	Dir string `json:"dir,omitempty"`
+	// Umask of the process, -1 if not available. Only Linux reads the
+	// current one, the other Unix systems report the umask at the start.
+	Umask int `json:"umask"`
+}
+
+var (
+	// CaptureEnv attaches the Environment of the process to error
+	// entries, to help reproducing failures reported from the field.
+	CaptureEnv bool
+
+	// CaptureEnvVars are the names of the environment variables
+	// attached, "*" attaches all of them.
+	CaptureEnvVars = []string{"PATH", "HOME", "USER", "SHELL", "LANG", "TZ"}
+
+	// SecretEnvPatterns are the case insensitive parts of variable names
+	// whose values are redacted.
+	SecretEnvPatterns = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "AUTH", "PRIVATE", "DSN"}
+)
+
+func captureEnv() *Environment {
+	env := &Environment{
+		Vars:  make(map[string]string),
+		Umask: umask(),
+	}
+	env.Dir, _ = os.Getwd()
+	for _, name := range CaptureEnvVars {
+		if name == "*" {
+			for _, kv := range os.Environ() {
+				i := strings.IndexByte(kv, '=')
+				if i > 0 {
+					env.Vars[kv[:i]] = redactEnv(kv[:i], kv[i+1:])
+				}
+			}
+			continue
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			env.Vars[name] = redactEnv(name, v)
+		}
+	}
+	return env
+}
+
+func redactEnv(name, value string) string {
+	upper := strings.ToUpper(name)
+	for _, p := range SecretEnvPatterns {
+		if strings.Contains(upper, strings.ToUpper(p)) {
+			return "***"
+		}
+	}
+	return value
+}
+
+// lines returns the environment as indented lines for the console
+func (env *Environment) lines() []string {
+	names := make([]string, 0, len(env.Vars))
+	for name := range env.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names)+1)
+	dir := "    dir: " + env.Dir
+	if env.Umask >= 0 {
+		dir += fmt.Sprintf(" umask: %04o", env.Umask)
+	}
+	lines = append(lines, dir)
+	for _, name := range names {
+		lines = append(lines, "    env: "+name+"="+env.Vars[name])
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCaptureEnv(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	rescueVars := CaptureEnvVars
+	defer func() { CaptureEnvVars = rescueVars }()
+
+	t.Setenv("LOGSYS_TEST_VALUE", "visible")
+	t.Setenv("LOGSYS_TEST_TOKEN", "hidden")
+	CaptureEnvVars = []string{"LOGSYS_TEST_VALUE", "LOGSYS_TEST_TOKEN", "LOGSYS_TEST_MISSING"}
+	CaptureEnv = true
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+
+	out, err := getOutput(Warningln, "warning")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Contains(string(out), "env:") {
+		t.Fatalf("Error, environment attached to a warning: %q", string(out))
+	}
+
+	out, err = getOutput(Errorln, "failed")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, expected := range []string{"\n    env: LOGSYS_TEST_TOKEN=***", "\n    env: LOGSYS_TEST_VALUE=visible"} {
+		if !strings.Contains(string(out), expected) {
+			t.Fatalf("Error, printed %q, expected to contain %q", string(out), expected)
+		}
+	}
+
+	env := entries[1].Env
+	wd, _ := os.Getwd()
+	if env == nil || env.Dir != wd || len(env.Vars) != 2 {
+		t.Fatalf("Error, unexpected environment %+v", env)
+	}
+}
+
+func TestRedactEnv(t *testing.T) {
+	testCases := []struct {
+		name, value, expected string
+	}{
+		{"HOME", "/root", "/root"},
+		{"DB_PASSWORD", "x", "***"},
+		{"aws_secret_access_key", "x", "***"},
+		{"GITHUB_TOKEN", "x", "***"},
+	}
+	for _, tc := range testCases {
+		if got := redactEnv(tc.name, tc.value); got != tc.expected {
+			t.Errorf("expected %s=%q, but got %q", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package log
+
+import "strings"
+
+// CauseIndent is the prefix used by DefaultAdapter for each line of an
+// error chain rendered below the log message.
+var CauseIndent = "    caused by: "
+
+// ErrorChain returns the messages of the errors wrapped by err, from the
+// outermost cause to the root cause. err itself is not included. Errors
+// are unwrapped through the Unwrap() error, Unwrap() []error and
+// Cause() error methods.
+func ErrorChain(err error) []string {
+	var chain []string
+	for _, cause := range causes(err) {
+		chain = append(chain, cause.Error())
+		chain = append(chain, ErrorChain(cause)...)
+	}
+	return chain
+}
+
+func causes(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case interface{ Unwrap() error }:
+		if c := e.Unwrap(); c != nil {
+			return []error{c}
+		}
+	case interface{ Cause() error }:
+		if c := e.Cause(); c != nil && c != err {
+			return []error{c}
+		}
+	}
+	return nil
+}
+
+// errorLines returns the indented cause lines of every error found in msg.
+func errorLines(o OutType, msg ...interface{}) []string {
+	if o == FormattedOut && len(msg) > 0 {
+		msg = msg[1:]
+	}
+	var lines []string
+	for _, m := range msg {
+		err, ok := m.(error)
+		if !ok {
+			continue
+		}
+		for _, c := range ErrorChain(err) {
+			lines = append(lines, CauseIndent+c)
+		}
+	}
+	return lines
+}
+
+// truncateLines cuts every line of s bigger than size and adds "..." at
+// the end of it.
+func truncateLines(s string, size int) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if len(l) > size {
+			lines[i] = l[:size] + "..."
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type causer struct {
+	msg   string
+	cause error
+}
+
+func (c causer) Error() string { return c.msg }
+func (c causer) Cause() error  { return c.cause }
+
+func TestErrorChain(t *testing.T) {
+	root := errors.New("root")
+	testCases := []struct {
+		name     string
+		err      error
+		expected []string
+	}{
+		{"no chain", root, nil},
+		{"wrapped", fmt.Errorf("outer: %w", fmt.Errorf("middle: %w", root)), []string{"middle: root", "root"}},
+		{"causer", causer{"outer", root}, []string{"root"}},
+		{"joined", errors.Join(root, errors.New("other")), []string{"root", "other"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := ErrorChain(tc.err)
+			if !reflect.DeepEqual(chain, tc.expected) {
+				t.Errorf("expected %q, but got %q", tc.expected, chain)
+			}
+		})
+	}
+}
+
+func TestErrorCauseRendering(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+
+	err := fmt.Errorf("read config: %w", errors.New("file not found"))
+	out, e := getOutput(Errorln, err)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	expectedValue := "\x1b[91m" + timeFormated + " [error] read config: file not found\n" +
+		CauseIndent + "file not found\x1b[0;00m\n"
+	if string(out) != expectedValue {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expectedValue)
+	}
+
+	MaxLineSize = 20
+	out, e = getOutput(Errorf, "%v", err)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	expectedValue = ("\x1b[91m" + timeFormated)[:20] + "...\n" + (CauseIndent + "file not found")[:20] + "..."
+	if string(out) != expectedValue {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expectedValue)
+	}
+}
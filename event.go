@@ -0,0 +1,138 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FieldType is the type of a field of an EventSchema
+type FieldType uint8
+
+// Types of the fields of an event
+const (
+	AnyField FieldType = iota
+	StringField
+	IntField
+	FloatField
+	BoolField
+)
+
+var fieldTypeNames = []string{
+	AnyField:    "any",
+	StringField: "string",
+	IntField:    "int",
+	FloatField:  "float",
+	BoolField:   "bool",
+}
+
+// EventSchema maps the fields of an event to their types. Every field of
+// the schema is required and fields not in the schema are rejected.
+type EventSchema map[string]FieldType
+
+var (
+	eventsLock = sync.RWMutex{}
+	events     = make(map[string]EventSchema)
+)
+
+// RegisterEvent registers the schema of the event name, replacing the
+// schema registered before, if any. Only registered events are logged
+// by Event.
+func RegisterEvent(name string, schema EventSchema) {
+	eventsLock.Lock()
+	events[name] = schema
+	eventsLock.Unlock()
+}
+
+// Event logs the machine event name, e.g. user_created or payment_failed,
+// with fields given as key/value pairs:
+//
+//	log.Event("payment_failed", "order", id, "amount", 9.90)
+//
+// The fields are validated against the schema given to RegisterEvent and
+// the event is not logged if they don't match. Events are logged with the
+// EventLog type, the name is the message of the entry, so adapters can
+// tell them apart from the diagnostic messages, and update the metrics
+// registered with RegisterEventMetric.
+func Event(name string, fields ...interface{}) error {
+	return std.event(name, fields...)
+}
+
+// Event works like log.Event logging to the adapters of l
+func (l *Logger) Event(name string, fields ...interface{}) error {
+	return l.event(name, fields...)
+}
+
+func (l *Logger) event(name string, fields ...interface{}) error {
+	f, err := eventFields(name, fields)
+	if err != nil {
+		return err
+	}
+	dispatch(l.newEntry(fieldList(f), EventLog, LineOut, name))
+	updateMetrics(name, f)
+	return nil
+}
+
+// eventFields returns the key/value pairs as Fields validated against the
+// schema of the event name
+func eventFields(name string, kv []interface{}) (Fields, error) {
+	eventsLock.RLock()
+	schema, ok := events[name]
+	eventsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("log: event %s not registered", name)
+	}
+	if len(kv)%2 != 0 {
+		return nil, fmt.Errorf("log: event %s: odd number of key/value arguments", name)
+	}
+	f := make(Fields, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		k, ok := kv[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("log: event %s: key %v is not a string", name, kv[i])
+		}
+		t, ok := schema[k]
+		if !ok {
+			return nil, fmt.Errorf("log: event %s: unknown field %s", name, k)
+		}
+		if !t.accepts(kv[i+1]) {
+			return nil, fmt.Errorf("log: event %s: field %s is %T, want %s", name, k, kv[i+1], fieldTypeNames[t])
+		}
+		f[k] = kv[i+1]
+	}
+	var missing []string
+	for k := range schema {
+		if _, ok := f[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("log: event %s: missing fields %v", name, missing)
+	}
+	return f, nil
+}
+
+func (t FieldType) accepts(v interface{}) bool {
+	switch t {
+	case StringField:
+		_, ok := v.(string)
+		return ok
+	case IntField:
+		switch v.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return true
+		}
+		return false
+	case FloatField:
+		switch v.(type) {
+		case float32, float64:
+			return true
+		}
+		return false
+	case BoolField:
+		_, ok := v.(bool)
+		return ok
+	}
+	return true
+}
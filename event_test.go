@@ -0,0 +1,65 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvent(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	RegisterEvent("payment_failed", EventSchema{
+		"order":  IntField,
+		"amount": FloatField,
+		"reason": StringField,
+	})
+
+	var eventErr error
+	out, err := getOutput(func(msg ...interface{}) {
+		eventErr = Event("payment_failed", "order", 42, "amount", 9.9, "reason", "card declined")
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if eventErr != nil {
+		t.Fatal(eventErr.Error())
+	}
+	expected := now().Format(TimeFormat) + " [event] payment_failed amount=9.9 order=42 reason=\"card declined\"\n"
+	if string(out) != expected {
+		t.Fatalf("Error, printed %q, expected %q", out, expected)
+	}
+	if len(entries) != 1 || entries[0].Type != EventLog || entries[0].Message() != "payment_failed" {
+		t.Fatalf("Error, unexpected entries %v", entries)
+	}
+	if entries[0].Caller == "" || !strings.HasPrefix(entries[0].Caller, "event_test.go:") {
+		t.Fatalf("Error, caller %q", entries[0].Caller)
+	}
+
+	invalid := []struct {
+		name   string
+		fields []interface{}
+		err    string
+	}{
+		{"user_created", nil, "not registered"},
+		{"payment_failed", []interface{}{"order", 42, "amount"}, "odd number"},
+		{"payment_failed", []interface{}{"order", "42", "amount", 9.9, "reason", ""}, "field order is string, want int"},
+		{"payment_failed", []interface{}{"order", 42, "amount", 9.9, "reason", "", "card", "visa"}, "unknown field card"},
+		{"payment_failed", []interface{}{"order", 42}, "missing fields [amount reason]"},
+	}
+	for _, c := range invalid {
+		err := Event(c.name, c.fields...)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("Error, %s %v returned %v, expected %q", c.name, c.fields, err, c.err)
+		}
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Error, invalid events were logged: %v", entries[1:])
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nuveo/log"
+	"github.com/nuveo/log/adapters/ring"
+)
+
+// Logs to a ring file, or prints the entries of the ring file given as
+// argument, e.g. after a crash.
+func main() {
+	if len(os.Args) > 1 {
+		entries, err := ring.Read(os.Args[1])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, e := range entries {
+			fmt.Printf("%s [%s] %s\n", e.Time.Format(log.TimeFormat), log.Prefixes[e.Type], e.Message())
+		}
+		return
+	}
+
+	config := make(map[string]interface{})
+	config["fileName"] = "log.ring"
+	config["size"] = 1 << 20
+	log.SetAdapterConfig("ring", config)
+	defer log.Close()
+
+	log.Println("Info message")
+	log.Warningln("Warning message")
+	log.Errorln("Error message")
+}
@@ -0,0 +1,39 @@
+package log
+
+import "sync"
+
+var (
+	exitHandlers []func()
+	exitLock     = sync.Mutex{}
+)
+
+// AddExitHandler registers f to run when the program exits with Exit or
+// with one of the Fatal functions, before the adapters are flushed, so
+// the entries logged by f are written. The handlers run once, in the
+// reverse order of registration like deferred calls.
+func AddExitHandler(f func()) {
+	exitLock.Lock()
+	exitHandlers = append(exitHandlers, f)
+	exitLock.Unlock()
+}
+
+// Exit runs the exit handlers, closes the adapters and exits with the
+// status code. Call it at the end of main instead of returning, Go
+// doesn't run anything when main returns.
+func Exit(code int) {
+	runExitHandlers()
+	_ = Close()
+	exit(code)
+}
+
+// runExitHandlers runs and removes the exit handlers, a handler that
+// exits doesn't run them again
+func runExitHandlers() {
+	exitLock.Lock()
+	list := exitHandlers
+	exitHandlers = nil
+	exitLock.Unlock()
+	for i := len(list) - 1; i >= 0; i-- {
+		list[i]()
+	}
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExitHandlers(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	code := 0
+	exit = func(c int) { code = c }
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	var order []string
+	AddExitHandler(func() { order = append(order, "first") })
+	AddExitHandler(func() {
+		order = append(order, "second")
+		// the handlers don't run again
+		Exit(3)
+	})
+	Fatalln("stop")
+	if strings.Join(order, " ") != "second first" || code != 1 {
+		t.Fatalf("Error, ran %q and exited with %d", order, code)
+	}
+	if out := buf.String(); !strings.Contains(out, "[fatal] stop") {
+		t.Fatalf("Error, printed %q", out)
+	}
+	Exit(0)
+	if len(order) != 2 || code != 0 {
+		t.Fatalf("Error, ran %q and exited with %d", order, code)
+	}
+}
@@ -0,0 +1,142 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FailurePolicy is what is done with an entry that an adapter failed to
+// write after its retries
+type FailurePolicy int
+
+const (
+	// DropOnFailure drops the entry for the adapter, the default. It is
+	// still written to stderr if every adapter failed.
+	DropOnFailure FailurePolicy = iota
+	// ConsoleOnFailure writes the entry to stderr, as when every adapter
+	// fails.
+	ConsoleOnFailure
+)
+
+var (
+	// FallbackBurst is the number of messages written to stderr in each
+	// FallbackInterval when every adapter fails, the others are counted
+	// and reported as dropped.
+	FallbackBurst = 10
+
+	// FallbackInterval is the period of FallbackBurst
+	FallbackInterval = time.Second
+
+	// OnAdapterError, if not nil, is called with the name of the adapter,
+	// the entry and the error when an adapter fails to write an entry
+	// after its retries, instead of reporting the error on stderr. It may
+	// be called by several goroutines at once with StartWorkers.
+	OnAdapterError func(name string, e *Entry, err error)
+
+	fallbackOut  io.Writer = os.Stderr
+	fallbackLock           = sync.Mutex{}
+	fallback     struct {
+		active  bool
+		start   time.Time
+		written int
+		dropped int
+	}
+)
+
+// runAdapter calls the adapter, retrying as set by a.Retries. The error,
+// or the panic, of the last try is reported to OnAdapterError, or on
+// stderr, and returned.
+func runAdapter(name string, a AdapterPod, e *Entry) error {
+	err := callAdapter(name, a, e)
+	for i := 0; err != nil && i < a.Retries; i++ {
+		time.Sleep(a.RetryDelay)
+		err = callAdapter(name, a, e)
+	}
+	if err == nil {
+		return nil
+	}
+	if a.OnFailure == ConsoleOnFailure {
+		atomic.StoreInt32(&e.console, 1)
+	}
+	if OnAdapterError != nil {
+		OnAdapterError(name, e, err)
+	} else {
+		fallbackWrite(fmt.Sprintf("log: %v\n", err))
+	}
+	return err
+}
+
+// callAdapter writes e with the adapter, a panic of the adapter is
+// returned as an error
+func callAdapter(name string, a AdapterPod, e *Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("adapter %s: %v", name, r)
+		}
+	}()
+	if a.Write != nil {
+		if err = a.Write(e, a.Config); err != nil {
+			return fmt.Errorf("adapter %s: %w", name, err)
+		}
+		return nil
+	}
+	if a.Adapter != nil {
+		a.Adapter(e, a.Config)
+	}
+	return nil
+}
+
+// fallbackEntry writes e to stderr, when it couldn't be written by any
+// adapter, with a banner when the adapters start failing.
+func fallbackEntry(e *Entry) {
+	fallbackLock.Lock()
+	banner := !fallback.active
+	fallback.active = true
+	fallbackLock.Unlock()
+	if banner {
+		fallbackWrite("log: all adapters are failing, writing to stderr\n")
+	}
+	fallbackLine(e)
+}
+
+// fallbackLine writes e to stderr as a plain line
+func fallbackLine(e *Entry) {
+	fallbackWrite(fmt.Sprintf("%s [%s] %s\n", timestamp(e.Time, e.logger.logger().timeLayout()), Prefixes[e.Type], e.Message()))
+}
+
+// fallbackRecovered reports on stderr that the adapters work again
+func fallbackRecovered() {
+	fallbackLock.Lock()
+	active := fallback.active
+	fallback.active = false
+	fallbackLock.Unlock()
+	if active {
+		fallbackWrite("log: adapters recovered\n")
+	}
+}
+
+// fallbackWrite writes s to stderr, at most FallbackBurst times in every
+// FallbackInterval.
+func fallbackWrite(s string) {
+	fallbackLock.Lock()
+	defer fallbackLock.Unlock()
+	t := time.Now()
+	if t.Sub(fallback.start) >= FallbackInterval {
+		if fallback.dropped > 0 {
+			fmt.Fprintf(fallbackOut, "log: %d messages dropped\n", fallback.dropped)
+		}
+		fallback.start = t
+		fallback.written = 0
+		fallback.dropped = 0
+	}
+	if fallback.written >= FallbackBurst {
+		fallback.dropped++
+		return
+	}
+	fallback.written++
+	_, _ = io.WriteString(fallbackOut, s)
+}
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+
+	var buf bytes.Buffer
+	fallbackOut = &buf
+	FallbackBurst = 4
+	FallbackInterval = time.Hour
+	defer func() { FallbackBurst, FallbackInterval = 10, time.Second }()
+
+	failing := true
+	AddAdapter("failing", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		if failing {
+			panic("connection refused")
+		}
+	}})
+
+	rescueStdout := os.Stdout
+	defer func() { os.Stdout = rescueStdout }()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_ = r.Close()
+	_ = w.Close()
+	os.Stdout = w
+
+	Errorln("first")
+	Errorln("second")
+	os.Stdout = rescueStdout
+
+	expected := "log: adapter failing: connection refused\n" +
+		"log: all adapters are failing, writing to stderr\n" +
+		timeFormated + " [error] first\n" +
+		"log: adapter failing: connection refused\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, stderr %q, expected %q", buf.String(), expected)
+	}
+	if fallback.dropped != 1 {
+		t.Fatalf("Error, expected 1 dropped message, got %d", fallback.dropped)
+	}
+
+	FallbackInterval = 0
+	failing = false
+	buf.Reset()
+	_, _ = getOutput(Println, "ok")
+	if !strings.HasPrefix(buf.String(), "log: 1 messages dropped\nlog: adapters recovered\n") {
+		t.Fatalf("Error, stderr %q, expected the recovery", buf.String())
+	}
+}
+
+func TestAdapterFailurePolicy(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+
+	var buf bytes.Buffer
+	fallbackOut = &buf
+	FallbackInterval = time.Hour
+	defer func() { FallbackInterval = time.Second }()
+
+	tries := 0
+	AddAdapter("network", AdapterPod{
+		Write: func(e *Entry, config map[string]interface{}) error {
+			if tries++; tries < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+		Retries: 2,
+	})
+	_, _ = getOutput(Errorln, "first")
+	if tries != 3 || buf.Len() != 0 {
+		t.Fatalf("Error, %d tries, stderr %q", tries, buf.String())
+	}
+
+	var failed []string
+	OnAdapterError = func(name string, e *Entry, err error) {
+		failed = append(failed, e.Message()+": "+err.Error())
+	}
+	tries = -10
+	AddAdapter("network", AdapterPod{
+		Write: func(e *Entry, config map[string]interface{}) error {
+			tries++
+			return errors.New("connection refused")
+		},
+		Retries:   1,
+		OnFailure: ConsoleOnFailure,
+	})
+	_, _ = getOutput(Errorln, "second")
+	if tries != -8 {
+		t.Fatalf("Error, expected 2 tries, got %d", tries+10)
+	}
+	if len(failed) != 1 || failed[0] != "second: adapter network: connection refused" {
+		t.Fatalf("Error, OnAdapterError got %q", failed)
+	}
+	expected := timeFormated + " [error] second\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, stderr %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	AddAdapter("network", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		panic("closed")
+	}})
+	_, _ = getOutput(Errorln, "third")
+	if len(failed) != 2 || failed[1] != "third: adapter network: closed" || buf.Len() != 0 {
+		t.Fatalf("Error, OnAdapterError got %q, stderr %q", failed, buf.String())
+	}
+}
@@ -0,0 +1,223 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldLogger logs messages with structured fields, the fields are added
+// to the Fields of every entry and rendered after the message as
+// key=value pairs.
+//
+// A FieldLogger is immutable: WithFields, WithField and Without return a
+// child with a copy of the fields, so loggers can be shared by goroutines
+// and changing a child never changes its parent. A child inherits the
+// fields of its parent, the fields given to the child override the ones
+// with the same key and Without removes them. The fields of a FieldLogger
+// override the fields of the enrichers with the same key.
+//
+// The fields are stored in a flat append-only list, a child copies the
+// list of its parent with a single allocation and the overrides are
+// resolved when an entry is created, so deep chains of loggers, e.g. of
+// middleware stacks, are cheap to create.
+type FieldLogger struct {
+	logger *Logger
+	fields []field
+}
+
+// field of a FieldLogger, deleted by Without if deleted is set
+type field struct {
+	key     string
+	value   interface{}
+	deleted bool
+}
+
+// WithFields returns a FieldLogger that adds fields to every entry
+func WithFields(fields Fields) *FieldLogger {
+	return std.WithFields(fields)
+}
+
+// WithField returns a FieldLogger that adds the field key to every entry
+func WithField(key string, value interface{}) *FieldLogger {
+	return std.WithField(key, value)
+}
+
+// with returns a child of l with n more fields set by add
+func (l *FieldLogger) with(n int, add func(f []field) []field) *FieldLogger {
+	f := make([]field, len(l.fields), len(l.fields)+n)
+	copy(f, l.fields)
+	return &FieldLogger{logger: l.logger, fields: add(f)}
+}
+
+// WithFields returns a copy of l with fields added, replacing the fields
+// of l with the same key.
+func (l *FieldLogger) WithFields(fields Fields) *FieldLogger {
+	return l.with(len(fields), func(f []field) []field {
+		for k, v := range fields {
+			f = append(f, field{key: k, value: v})
+		}
+		return f
+	})
+}
+
+// WithField returns a copy of l with the field key added
+func (l *FieldLogger) WithField(key string, value interface{}) *FieldLogger {
+	return l.with(1, func(f []field) []field {
+		return append(f, field{key: key, value: value})
+	})
+}
+
+// Without returns a copy of l without the fields keys, inherited or not.
+// The fields of the enrichers are not removed.
+func (l *FieldLogger) Without(keys ...string) *FieldLogger {
+	return l.with(len(keys), func(f []field) []field {
+		for _, k := range keys {
+			f = append(f, field{key: k, deleted: true})
+		}
+		return f
+	})
+}
+
+// Fields returns a copy of the fields of l
+func (l *FieldLogger) Fields() Fields {
+	f := make(Fields, len(l.fields))
+	for _, v := range l.fields {
+		if v.deleted {
+			delete(f, v.key)
+		} else {
+			f[v.key] = v.value
+		}
+	}
+	return f
+}
+
+func (l *FieldLogger) runAdapters(m MsgType, o OutType, msg ...interface{}) *Entry {
+	e := l.logger.newEntry(l.fields, m, o, msg...)
+	dispatch(e)
+	return e
+}
+
+// Fatalln works like log.Fatalln adding the fields of l
+func (l *FieldLogger) Fatalln(msg ...interface{}) {
+	l.runAdapters(FatalLog, LineOut, msg...)
+	runExitHandlers()
+	_ = l.logger.Flush()
+	exit(1)
+}
+
+// Fatalf works like log.Fatalf adding the fields of l
+func (l *FieldLogger) Fatalf(msg ...interface{}) {
+	l.runAdapters(FatalLog, FormattedOut, msg...)
+	runExitHandlers()
+	_ = l.logger.Flush()
+	exit(1)
+}
+
+// Panicln works like log.Panicln adding the fields of l
+func (l *FieldLogger) Panicln(msg ...interface{}) {
+	e := l.runAdapters(PanicLog, LineOut, msg...)
+	_ = l.logger.Flush()
+	panic(e.Message())
+}
+
+// Panicf works like log.Panicf adding the fields of l
+func (l *FieldLogger) Panicf(msg ...interface{}) {
+	e := l.runAdapters(PanicLog, FormattedOut, msg...)
+	_ = l.logger.Flush()
+	panic(e.Message())
+}
+
+// Errorln works like log.Errorln adding the fields of l
+func (l *FieldLogger) Errorln(msg ...interface{}) {
+	l.runAdapters(ErrorLog, LineOut, msg...)
+}
+
+// Errorf works like log.Errorf adding the fields of l
+func (l *FieldLogger) Errorf(msg ...interface{}) {
+	l.runAdapters(ErrorLog, FormattedOut, msg...)
+}
+
+// Warningln works like log.Warningln adding the fields of l
+func (l *FieldLogger) Warningln(msg ...interface{}) {
+	l.runAdapters(WarningLog, LineOut, msg...)
+}
+
+// Warningf works like log.Warningf adding the fields of l
+func (l *FieldLogger) Warningf(msg ...interface{}) {
+	l.runAdapters(WarningLog, FormattedOut, msg...)
+}
+
+// Println works like log.Println adding the fields of l
+func (l *FieldLogger) Println(msg ...interface{}) {
+	l.runAdapters(MessageLog, LineOut, msg...)
+}
+
+// Printf works like log.Printf adding the fields of l
+func (l *FieldLogger) Printf(msg ...interface{}) {
+	l.runAdapters(MessageLog, FormattedOut, msg...)
+}
+
+// Debugln works like log.Debugln adding the fields of l
+func (l *FieldLogger) Debugln(msg ...interface{}) {
+	l.runAdapters(DebugLog, LineOut, msg...)
+}
+
+// Debugf works like log.Debugf adding the fields of l
+func (l *FieldLogger) Debugf(msg ...interface{}) {
+	l.runAdapters(DebugLog, FormattedOut, msg...)
+}
+
+// KeyValues returns the fields given with WithFields as key=value pairs
+// sorted by key, values with spaces, quotes or "=" are quoted. The fields
+// of the enrichers are not included, they are the same for every entry.
+func (e *Entry) KeyValues() string {
+	pairs := make([]string, 0, len(e.Keys))
+	for _, k := range e.Keys {
+		pairs = append(pairs, k+"="+logfmtValue(fmt.Sprint(e.Fields[k])))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// addFields adds the fields given at the call site to the entry, a field
+// overrides the ones with the same key before it in the list
+func (e *Entry) addFields(fields []field) {
+	if len(fields) == 0 {
+		return
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.deleted || shadowed(f.key, fields[i+1:]) {
+			continue
+		}
+		if e.Fields == nil {
+			e.Fields = make(Fields, len(fields))
+		}
+		e.Fields[f.key] = f.value
+		e.Keys = append(e.Keys, f.key)
+	}
+	sort.Strings(e.Keys)
+}
+
+// shadowed reports if key is in fields, the lists are short enough for a
+// linear search to be faster than a map
+func shadowed(key string, fields []field) bool {
+	for _, f := range fields {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldList returns fields as a list of fields
+func fieldList(fields Fields) []field {
+	if len(fields) == 0 {
+		return nil
+	}
+	f := make([]field, 0, len(fields))
+	for k, v := range fields {
+		f = append(f, field{key: k, value: v})
+	}
+	return f
+}
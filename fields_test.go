@@ -0,0 +1,108 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWithFields(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		return Fields{"host": "web1"}, nil
+	}), 0)
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	l := WithField("user", "alice").WithFields(Fields{"items": 3, "note": "two words"})
+	err := validate("Println", l.Println, "\x1b\\[37m"+timeFormated+" \\[msg\\] order placed items=3 note=\"two words\" user=alice\x1b\\[0;00m\n", "order placed")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the parent logger is not changed
+	err = validate("Printf", WithField("user", "alice").Printf, "\x1b\\[37m"+timeFormated+" \\[msg\\] 2 done user=alice\x1b\\[0;00m", "%d done", 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	e := entries[0]
+	if e.Fields["items"] != 3 || e.Fields["host"] != "web1" || len(e.Keys) != 3 {
+		t.Fatalf("Error, unexpected fields %v keys %v", e.Fields, e.Keys)
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var d Entry
+	if err = json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.KeyValues() != e.KeyValues() {
+		t.Fatalf("Error, decoded %q, expected %q", d.KeyValues(), e.KeyValues())
+	}
+}
+
+func TestFieldInheritance(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	AddEnricher(EnricherFunc(func() (Fields, error) {
+		return Fields{"host": "web1"}, nil
+	}), 0)
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	parent := WithFields(Fields{"user": "alice", "request": 1})
+	child := parent.WithFields(Fields{"user": "bob", "host": "web2"})
+	grandchild := child.Without("request", "missing")
+
+	_, _ = getOutput(func(msg ...interface{}) {
+		parent.Println("parent")
+		child.Println("child")
+		grandchild.Println("grandchild")
+	})
+
+	expected := []string{
+		"request=1 user=alice",
+		"host=web2 request=1 user=bob",
+		"host=web2 user=bob",
+	}
+	for i, e := range entries {
+		if kv := e.KeyValues(); kv != expected[i] {
+			t.Fatalf("Error, entry %d has %q, expected %q", i, kv, expected[i])
+		}
+	}
+	if entries[0].Fields["host"] != "web1" || entries[1].Fields["host"] != "web2" {
+		t.Fatalf("Error, unexpected enricher fields %v %v", entries[0].Fields, entries[1].Fields)
+	}
+
+	f := child.Fields()
+	f["user"] = "carol"
+	if child.Fields()["user"] != "bob" || len(parent.Fields()) != 2 {
+		t.Fatal("Error, the fields of the loggers were changed")
+	}
+}
+
+func TestFieldLoggerAllocs(t *testing.T) {
+	l := WithField("a", 1)
+	for _, k := range []string{"b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		l = l.WithField(k, k)
+	}
+	// the list of fields and the logger
+	if n := testing.AllocsPerRun(100, func() { l.WithField("k", 2) }); n > 2 {
+		t.Fatalf("Error, WithField allocated %v times", n)
+	}
+	e := &Entry{}
+	e.addFields(l.WithField("a", 2).Without("b").fields)
+	if len(e.Keys) != 9 || e.Fields["a"] != 2 || e.Fields["b"] != nil {
+		t.Fatalf("Error, unexpected fields %v", e.Fields)
+	}
+}
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Formatters selectable with the -log-format flag of BindFlags
+var flagFormats = map[string]Formatter{
+	"text":      TextFormatter,
+	"json":      JSONFormatter,
+	"logfmt":    LogfmtFormatter,
+	"tap":       TAPFormatter,
+	"github":    GitHubActionsFormatter,
+	"teamcity":  TeamCityFormatter,
+	"buildkite": BuildkiteFormatter,
+}
+
+// flagValue is a flag.Value that configures the package when set
+type flagValue struct {
+	set    func(s string) error
+	isBool bool
+}
+
+func (f *flagValue) String() string     { return "" }
+func (f *flagValue) Set(s string) error { return f.set(s) }
+func (f *flagValue) IsBoolFlag() bool   { return f.isBool }
+
+// boolFlag returns a flag.Value of a boolean flag that calls set when it
+// is true
+func boolFlag(set func()) *flagValue {
+	return &flagValue{isBool: true, set: func(s string) error {
+		switch strings.ToLower(s) {
+		case "true", "1", "t":
+			set()
+		case "false", "0", "f":
+		default:
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		return nil
+	}}
+}
+
+// BindFlags registers on fs, flag.CommandLine if nil, the flags of the
+// usual logging options of CLI tools, applied to the default logger as
+// they are parsed:
+//
+//	-v           show the debug messages and the callers (DebugLevel)
+//	-vv          as -v, with microsecond timestamps
+//	-q           show only the warnings and errors (WarningLevel)
+//	-log-format  text, json, logfmt, tap, github,
+//	             teamcity or buildkite
+//	-log-file    append the messages to the file, without colors
+//
+// The flag package also accepts them with two dashes, e.g. --log-file.
+// The file stays open until the program exits.
+func BindFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.Var(boolFlag(func() { SetLevel(DebugLevel) }), "v", "show debug messages")
+	fs.Var(boolFlag(func() {
+		SetLevel(DebugLevel)
+		TimeFormat = MicrosecondTimeFormat
+	}), "vv", "show debug messages with microsecond timestamps")
+	fs.Var(boolFlag(func() { SetLevel(WarningLevel) }), "q", "show only warnings and errors")
+	fs.Var(&flagValue{set: func(s string) error {
+		f, ok := flagFormats[strings.ToLower(s)]
+		if !ok {
+			return fmt.Errorf("unknown format %q, use text, json, logfmt, tap, github, teamcity or buildkite", s)
+		}
+		OutputFormat = f
+		return nil
+	}}, "log-format", "log format: text, json, logfmt, tap, github, teamcity or buildkite")
+	fs.Var(&flagValue{set: func(s string) error {
+		f, err := os.OpenFile(s, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		EnableANSIColors = false
+		SetOutput(f)
+		return nil
+	}}, "log-file", "append log messages to the file")
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBindFlags(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app.log")
+
+	fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+	BindFlags(fs)
+	if err = fs.Parse([]string{"-v", "--log-format", "logfmt", "--log-file", name, "arg"}); err != nil {
+		t.Fatal(err)
+	}
+	if GetLevel() != DebugLevel || fs.Arg(0) != "arg" {
+		t.Fatalf("Error, level %v args %q", GetLevel(), fs.Args())
+	}
+	Warningln("flags test")
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "level=warning") || !strings.Contains(string(b), `msg="flags test"`) {
+		t.Fatalf("Error, wrote %q", b)
+	}
+
+	fs = flag.NewFlagSet("tool", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	BindFlags(fs)
+	if err = fs.Parse([]string{"-vv", "-q"}); err != nil {
+		t.Fatal(err)
+	}
+	if GetLevel() != WarningLevel || TimeFormat != MicrosecondTimeFormat {
+		t.Fatalf("Error, level %v time format %q", GetLevel(), TimeFormat)
+	}
+	if err = fs.Parse([]string{"-log-format", "xml"}); err == nil {
+		t.Fatal("Error, expected error for unknown format")
+	}
+}
@@ -0,0 +1,206 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter renders an entry as the text written to stdout by
+// DefaultAdapter, including the line break.
+type Formatter func(e *Entry) string
+
+// OutputFormat is the Formatter of the default logger, TextFormatter by
+// default. Use JSONFormatter or LogfmtFormatter to emit lines parsed by
+// log shippers.
+var OutputFormat Formatter = TextFormatter
+
+// WithFormatter sets the Formatter of the logger, default TextFormatter
+func WithFormatter(f Formatter) Option {
+	return func(l *Logger) { l.format = f }
+}
+
+func (l *Logger) formatter() Formatter {
+	if l = l.logger(); l == std || l.format == nil {
+		return OutputFormat
+	}
+	return l.format
+}
+
+// TextFormatter formats the entry as a line of text, colored if ANSI
+// colors are enabled, with the error causes, the environment and the
+// stack on indented lines. The lines of multiline messages are prefixed
+// by the timestamp and the level, the fields follow the first line, the
+// lines of Block are marked by BlockPrefix and the payloads of JSON, YAML
+// and XML are indented, both are not truncated. It is the default OutputFormat.
+func TextFormatter(e *Entry) string {
+	var debugInfo, lineBreak string
+
+	if e.ShowCaller() && e.Caller != "" {
+		debugInfo = callerLink(e) + " "
+	}
+
+	l := e.logger.logger()
+	colored := l.colors(e.Type.IsError())
+	var lines []string
+	if e.payload != nil {
+		lines = append([]string{e.payload.label}, e.payloadLines(colored)...)
+	} else {
+		lines = strings.Split(e.Message(), "\n")
+	}
+	if e.block {
+		for i, l := range lines {
+			lines[i] = BlockPrefix + l
+		}
+	}
+	output := lines[0]
+	if e.Out == LineOut {
+		lineBreak = "\n"
+	}
+	if kv := e.KeyValues(); kv != "" {
+		output += " " + kv
+	}
+	if info := e.ContextInfo(); info != "" {
+		output += " (" + info + ")"
+	}
+	if e.Ref != "" {
+		output += " (ref " + e.Ref + ")"
+	}
+
+	prefix := timestamp(e.Time, l.timeLayout()) + " " + levelTag(e.Type) + " "
+	for _, line := range lines[1:] {
+		output += "\n" + prefix + line
+	}
+
+	if causes := errorLines(e.Out, e.Msg...); len(causes) > 0 {
+		output = output + "\n" + strings.Join(causes, "\n")
+	}
+
+	if e.Env != nil {
+		output = output + "\n" + strings.Join(e.Env.lines(), "\n")
+	}
+
+	if len(e.Stack) > 0 {
+		output = output + "\n" + strings.Join(stackLines(e.Stack), "\n")
+	}
+
+	output = prefix + debugInfo + output
+	if colored {
+		output = e.color() + output + "\033[0;00m"
+	}
+	if e.block || e.payload != nil {
+		return output + lineBreak
+	}
+	return truncateLines(output, l.lineSize()) + lineBreak
+}
+
+type jsonLine struct {
+	SchemaVersion int      `json:"schema_version"`
+	Seq           uint64   `json:"seq"`
+	Time          string   `json:"time"`
+	Level         string   `json:"level"`
+	Caller        string   `json:"caller,omitempty"`
+	Message       string   `json:"message"`
+	Fields        Fields   `json:"fields,omitempty"`
+	Context       string   `json:"context,omitempty"`
+	Ref           string   `json:"ref,omitempty"`
+	ID            string   `json:"id,omitempty"`
+	Causes        []string `json:"causes,omitempty"`
+	Stack         []Frame  `json:"stack,omitempty"`
+	// Payload is the value logged by JSON, YAML or XML
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// JSONFormatter formats the entry as a single line JSON object with the
+// SchemaVersion, the sequence number, that orders the entries of the
+// process logged at the same time, the time in RFC 3339 format, the
+// level, the caller, the message, the fields and the error causes as an
+// array. The messages are not truncated.
+func JSONFormatter(e *Entry) string {
+	line := jsonLine{
+		SchemaVersion: SchemaVersion,
+		Seq:           e.Seq,
+		Time:          e.Time.Format(time.RFC3339Nano),
+		Level:         Prefixes[e.Type],
+		Caller:        e.Caller,
+		Message:       strings.TrimSuffix(e.Message(), "\n"),
+		Fields:        e.Fields,
+		Context:       e.ContextInfo(),
+		Ref:           e.Ref,
+		ID:            e.ID,
+		Stack:         e.Stack,
+	}
+	line.Causes = entryCauses(e)
+	if e.payload != nil {
+		line.Message, line.Payload = e.payload.label, e.payload.embedded()
+	}
+	b, err := json.Marshal(line)
+	if err != nil {
+		b, _ = json.Marshal(jsonLine{
+			SchemaVersion: SchemaVersion,
+			Seq:           line.Seq,
+			Time:          line.Time,
+			Level:         line.Level,
+			Caller:        line.Caller,
+			Message:       line.Message,
+			Context:       fmt.Sprintf("log: unable to encode fields: %v", err),
+		})
+	}
+	return string(b) + "\n"
+}
+
+// LogfmtFormatter formats the entry as a line of logfmt key=value pairs,
+// ts, level, caller, msg, ctx, ref and id followed by the fields sorted by
+// key and the error causes joined in err. The messages are not truncated.
+func LogfmtFormatter(e *Entry) string {
+	pairs := []string{
+		"ts=" + e.Time.Format(time.RFC3339Nano),
+		"level=" + Prefixes[e.Type],
+	}
+	add := func(k, v string) {
+		if v != "" {
+			pairs = append(pairs, k+"="+logfmtValue(v))
+		}
+	}
+	add("caller", e.Caller)
+	pairs = append(pairs, "msg="+logfmtValue(strings.TrimSuffix(e.Message(), "\n")))
+	add("ctx", e.ContextInfo())
+	add("ref", e.Ref)
+	add("id", e.ID)
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+logfmtValue(fmt.Sprint(e.Fields[k])))
+	}
+	add("err", strings.Join(entryCauses(e), "; "))
+	return strings.Join(pairs, " ") + "\n"
+}
+
+// logfmtValue returns v quoted if it is empty or has spaces, quotes or "="
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// entryCauses returns the causes of the errors in the message of e
+func entryCauses(e *Entry) []string {
+	var causes []string
+	msg := e.Msg
+	if e.Out == FormattedOut && len(msg) > 0 {
+		msg = msg[1:]
+	}
+	for _, m := range msg {
+		if err, ok := m.(error); ok {
+			causes = append(causes, ErrorChain(err)...)
+		}
+	}
+	return causes
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	OutputFormat = JSONFormatter
+
+	err := fmt.Errorf("save failed: %w", errors.New("disk full"))
+	out, e := getOutput(WithField("user", "alice").Errorf, "%v\n", err)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	if strings.Count(string(out), "\n") != 1 || !strings.HasSuffix(string(out), "\n") {
+		t.Fatalf("Error, expected a single line, got %q", string(out))
+	}
+
+	var line map[string]interface{}
+	if e = json.Unmarshal(out, &line); e != nil {
+		t.Fatal(e.Error())
+	}
+	if line["schema_version"] != float64(SchemaVersion) || line["seq"].(float64) == 0 {
+		t.Fatalf("Error, expected the schema version and the sequence, got %v", line)
+	}
+	if line["time"] != now().Format("2006-01-02T15:04:05Z07:00") || line["level"] != "error" ||
+		line["message"] != "save failed: disk full" {
+		t.Fatalf("Error, unexpected line %v", line)
+	}
+	if !strings.HasPrefix(line["caller"].(string), "log_test.go:") {
+		t.Fatalf("Error, unexpected caller %v", line["caller"])
+	}
+	if fields := line["fields"].(map[string]interface{}); fields["user"] != "alice" {
+		t.Fatalf("Error, unexpected fields %v", fields)
+	}
+	if causes := line["causes"].([]interface{}); len(causes) != 1 || causes[0] != "disk full" {
+		t.Fatalf("Error, unexpected causes %v", causes)
+	}
+
+	l := New(WithFormatter(JSONFormatter), WithTimeFormat("15:04"))
+	out, _ = getOutput(l.Println, "text")
+	if !strings.HasPrefix(string(out), `{"schema_version":1,"seq":`) {
+		t.Fatalf("Error, expected JSON from the logger, got %q", string(out))
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	OutputFormat = LogfmtFormatter
+
+	err := fmt.Errorf("save failed: %w", errors.New("disk full"))
+	out, e := getOutput(WithFields(Fields{"user": "alice", "note": "two words"}).Errorln, err)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	expected := "ts=" + now().Format("2006-01-02T15:04:05Z07:00") + " level=error caller=log_test.go:[0-9]+ " +
+		`msg="save failed: disk full" note="two words" user=alice err="disk full"` + "\n"
+	if !regexp.MustCompile("^" + expected + "$").Match(out) {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expected)
+	}
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ghMessageEscaper  = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	ghPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// GitHubActionsFormatter formats the errors, fatal and panic messages and
+// the warnings as workflow commands of GitHub Actions, shown as
+// annotations of the run and of the pull requests, e.g.
+//
+//	::error file=main.go,line=12,col=3::unused variable x
+//
+// The position is the one given with At, or the caller relative to the
+// workspace when it is in it. The debug messages are ::debug:: commands,
+// shown when the debug logging of the run is enabled, and the other
+// messages are formatted by TextFormatter.
+func GitHubActionsFormatter(e *Entry) string {
+	var command string
+	switch {
+	case e.Type.IsError():
+		command = "error"
+	case e.Type == WarningLog:
+		command = "warning"
+	case e.Type == DebugLog:
+		command = "debug"
+	default:
+		return TextFormatter(e)
+	}
+
+	msg := strings.TrimSuffix(e.Message(), "\n")
+	if kv := e.KeyValues(); kv != "" {
+		msg += " " + kv
+	}
+	if e.Ref != "" {
+		msg += " (ref " + e.Ref + ")"
+	}
+	for _, c := range entryCauses(e) {
+		msg += "\n" + CauseIndent + c
+	}
+	if command == "debug" {
+		return "::debug::" + ghMessageEscaper.Replace(msg) + "\n"
+	}
+
+	var props []string
+	file, _ := e.Fields[FileKey].(string)
+	line, _ := e.Fields[LineKey].(int)
+	col, _ := e.Fields[ColumnKey].(int)
+	if file == "" {
+		file, line, col = workspaceFile(e.callerFile), callerLine(e.Caller), 0
+	}
+	if file != "" {
+		props = append(props, "file="+ghPropertyEscaper.Replace(filepath.ToSlash(file)))
+		if line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", line))
+		}
+		if col > 0 {
+			props = append(props, fmt.Sprintf("col=%d", col))
+		}
+	}
+	if rule, _ := e.Fields[RuleKey].(string); rule != "" {
+		props = append(props, "title="+ghPropertyEscaper.Replace(rule))
+	}
+	params := ""
+	if len(props) > 0 {
+		params = " " + strings.Join(props, ",")
+	}
+	return "::" + command + params + "::" + ghMessageEscaper.Replace(msg) + "\n"
+}
+
+// workspaceFile returns path relative to the workspace of GitHub Actions,
+// empty if it is not in the workspace
+func workspaceFile(path string) string {
+	ws := os.Getenv("GITHUB_WORKSPACE")
+	if path == "" || ws == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(ws, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return rel
+}
+
+// callerLine returns the line of the caller "file:line"
+func callerLine(caller string) int {
+	line, _ := strconv.Atoi(caller[strings.LastIndexByte(caller, ':')+1:])
+	return line
+}
+
+// AutoGitHubActions sets OutputFormat to GitHubActionsFormatter when the
+// program runs in a workflow of GitHub Actions, detected by the
+// GITHUB_ACTIONS environment variable, and returns false, leaving
+// OutputFormat untouched, otherwise.
+func AutoGitHubActions() bool {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return false
+	}
+	OutputFormat = GitHubActionsFormatter
+	return true
+}
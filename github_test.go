@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGitHubActionsFormatter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	OutputFormat = GitHubActionsFormatter
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("GITHUB_WORKSPACE", os.Getenv("GITHUB_WORKSPACE"))
+	os.Setenv("GITHUB_WORKSPACE", filepath.Dir(wd))
+
+	At("cmd/a,b.go", 12, 3).WithField(RuleKey, "unused").Warningln("unused variable x")
+	Errorln("copy failed: ", fmt.Errorf("50%% written: %w", errors.New("disk full")))
+	DebugMode = true
+	Debugf("line one\nline two")
+	Println("done")
+
+	expected := regexp.MustCompile(`^::warning file=cmd/a%2Cb.go,line=12,col=3,title=unused::unused variable x column=3 file=cmd/a,b.go line=12 rule=unused
+::error file=` + regexp.QuoteMeta(filepath.ToSlash(filepath.Base(wd))) + `/github_test.go,line=\d+::copy failed: 50%25 written: disk full%0A    caused by: disk full
+::debug::line one%0Aline two
+\S+ \S+ \[msg\] github_test.go:\d+ done
+$`)
+	if !expected.MatchString(buf.String()) {
+		t.Fatalf("Error, wrote\n%s", buf.String())
+	}
+}
+
+func TestAutoGitHubActions(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	defer os.Setenv("GITHUB_ACTIONS", os.Getenv("GITHUB_ACTIONS"))
+
+	os.Setenv("GITHUB_ACTIONS", "")
+	if AutoGitHubActions() {
+		t.Fatal("Error, detected GitHub Actions without GITHUB_ACTIONS")
+	}
+	os.Setenv("GITHUB_ACTIONS", "true")
+	if !AutoGitHubActions() || fmt.Sprintf("%p", OutputFormat) != fmt.Sprintf("%p", GitHubActionsFormatter) {
+		t.Fatal("Error, GitHubActionsFormatter not set under GitHub Actions")
+	}
+}
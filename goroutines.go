@@ -0,0 +1,177 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goroutineGroup are the goroutines created at the same site
+type goroutineGroup struct {
+	creator Frame
+	count   int
+	states  map[string]int
+	// tops counts the first frames out of the runtime
+	tops map[Frame]int
+	// minutes is the longest time blocked
+	minutes int
+}
+
+// Goroutines logs a summary of the goroutines running as a message of
+// type m, instead of their stacks: a line for each site where they were
+// created, with the count, their states and the longest time one of them
+// is blocked, followed by the function most of them are in, e.g.
+//
+//	12 goroutines created by net/http.(*Server).Serve (server.go:3285), 12 IO wait, up to 5 minutes
+//	    in net/http.(*conn).serve (server.go:1990)
+//
+// The sites are sorted by the count, a growing count in the log of a
+// long running program shows where goroutines leak.
+func Goroutines(m MsgType) {
+	std.goroutines(nil, m)
+}
+
+// Goroutines works like log.Goroutines logging to the adapters of l
+func (l *Logger) Goroutines(m MsgType) {
+	l.goroutines(nil, m)
+}
+
+// Goroutines works like log.Goroutines adding the fields of l
+func (l *FieldLogger) Goroutines(m MsgType) {
+	l.logger.goroutines(l.fields, m)
+}
+
+func (l *Logger) goroutines(fields []field, m MsgType) {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	groups, total := groupGoroutines(string(buf))
+	lines := []string{fmt.Sprintf("%d goroutines", total)}
+	for _, g := range groups {
+		lines = append(lines, g.lines()...)
+	}
+	dispatch(l.newEntry(fields, m, LineOut, strings.Join(lines, "\n")))
+}
+
+// groupGoroutines groups the goroutines of the output of runtime.Stack by
+// their creation site, sorted by the count
+func groupGoroutines(dump string) ([]*goroutineGroup, int) {
+	bySite := make(map[Frame]*goroutineGroup)
+	total := 0
+	for _, block := range strings.Split(dump, "\n\n") {
+		if !strings.HasPrefix(block, "goroutine ") {
+			continue
+		}
+		total++
+		header := strings.SplitN(block, "\n", 2)[0]
+		state, minutes := goroutineState(header)
+		frames := parseStack([]byte(block + "\n"))
+
+		var creator Frame
+		if n := len(frames); n > 0 && strings.HasPrefix(frames[n-1].Function, "created by ") {
+			creator = frames[n-1]
+			creator.Function = strings.TrimPrefix(creator.Function, "created by ")
+			if i := strings.Index(creator.Function, " in goroutine "); i >= 0 {
+				creator.Function = creator.Function[:i]
+			}
+			frames = frames[:n-1]
+		}
+		g, ok := bySite[creator]
+		if !ok {
+			g = &goroutineGroup{creator: creator, states: make(map[string]int), tops: make(map[Frame]int)}
+			bySite[creator] = g
+		}
+		g.count++
+		g.states[state]++
+		if minutes > g.minutes {
+			g.minutes = minutes
+		}
+		for _, f := range frames {
+			if !strings.HasPrefix(f.Function, "runtime.") {
+				g.tops[f]++
+				break
+			}
+		}
+	}
+	groups := make([]*goroutineGroup, 0, len(bySite))
+	for _, g := range bySite {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		return groups[i].creator.Function < groups[j].creator.Function
+	})
+	return groups, total
+}
+
+// goroutineState returns the state and the minutes blocked in the header
+// of a goroutine, e.g. "goroutine 7 [chan receive, 2 minutes]:"
+func goroutineState(header string) (string, int) {
+	start, end := strings.IndexByte(header, '['), strings.LastIndexByte(header, ']')
+	if start < 0 || end < start {
+		return "unknown", 0
+	}
+	parts := strings.Split(header[start+1:end], ", ")
+	minutes := 0
+	for _, p := range parts[1:] {
+		if strings.HasSuffix(p, " minutes") {
+			minutes, _ = strconv.Atoi(strings.TrimSuffix(p, " minutes"))
+		}
+	}
+	return parts[0], minutes
+}
+
+func frameSite(f Frame) string {
+	return fmt.Sprintf("%s (%s:%d)", f.Function, filepath.Base(f.File), f.Line)
+}
+
+// lines returns the summary of g
+func (g *goroutineGroup) lines() []string {
+	line := fmt.Sprintf("%d goroutines", g.count)
+	if g.count == 1 {
+		line = "1 goroutine"
+	}
+	if g.creator.Function == "" {
+		line += " not created by a goroutine"
+	} else {
+		line += " created by " + frameSite(g.creator)
+	}
+	states := make([]string, 0, len(g.states))
+	for s := range g.states {
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if g.states[states[i]] != g.states[states[j]] {
+			return g.states[states[i]] > g.states[states[j]]
+		}
+		return states[i] < states[j]
+	})
+	for _, s := range states {
+		line += fmt.Sprintf(", %d %s", g.states[s], s)
+	}
+	if g.minutes > 0 {
+		line += fmt.Sprintf(", up to %d minutes", g.minutes)
+	}
+
+	var top Frame
+	for f, n := range g.tops {
+		if n > g.tops[top] || (n == g.tops[top] && frameSite(f) < frameSite(top)) {
+			top = f
+		}
+	}
+	if top.Function == "" {
+		return []string{line}
+	}
+	return []string{line, "    in " + frameSite(top)}
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const goroutineDump = `goroutine 1 [running]:
+main.main()
+	/src/main.go:20 +0x25
+
+goroutine 7 [chan receive, 5 minutes]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/go/src/runtime/proc.go:398 +0xce
+main.worker(0xc000010000)
+	/src/worker.go:12 +0x45
+created by main.start in goroutine 1
+	/src/main.go:15 +0x66
+
+goroutine 8 [chan receive, 2 minutes]:
+main.worker(0xc000010000)
+	/src/worker.go:12 +0x45
+created by main.start in goroutine 1
+	/src/main.go:15 +0x66
+
+goroutine 9 [select]:
+main.loop()
+	/src/loop.go:30 +0x45
+created by main.start in goroutine 1
+	/src/main.go:15 +0x66
+
+goroutine 10 [IO wait]:
+net.accept()
+	/go/src/net/fd.go:1 +0x45
+created by main.serve
+	/src/server.go:8 +0x66
+`
+
+func TestGroupGoroutines(t *testing.T) {
+	groups, total := groupGoroutines(goroutineDump)
+	if total != 5 || len(groups) != 3 {
+		t.Fatalf("Error, got %d goroutines in %d groups", total, len(groups))
+	}
+	var lines []string
+	for _, g := range groups {
+		lines = append(lines, g.lines()...)
+	}
+	expected := []string{
+		"3 goroutines created by main.start (main.go:15), 2 chan receive, 1 select, up to 5 minutes",
+		"    in main.worker (worker.go:12)",
+		"1 goroutine not created by a goroutine, 1 running",
+		"    in main.main (main.go:20)",
+		"1 goroutine created by main.serve (server.go:8), 1 IO wait",
+		"    in net.accept (fd.go:1)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Error, got\n%s\nexpected\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestGoroutines(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 3; i++ {
+		go func() { <-stop }()
+	}
+	Goroutines(WarningLog)
+	if len(entries) != 1 || entries[0].Type != WarningLog {
+		t.Fatalf("Error, got %d entries", len(entries))
+	}
+	if msg := entries[0].Message(); !strings.Contains(msg, "3 goroutines created by github.com/nuveo/log.TestGoroutines (goroutines_test.go:75), ") {
+		t.Fatalf("Error, logged %q", msg)
+	}
+	if out := buf.String(); !strings.Contains(out, "[warning] 3 goroutines created by github.com/nuveo/log.TestGoroutines") {
+		t.Fatalf("Error, printed %q", out)
+	}
+}
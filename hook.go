@@ -0,0 +1,89 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ErrDropEntry returned by a hook suppresses the entry
+var ErrDropEntry = errors.New("log: entry dropped")
+
+// Hook intercepts the entries before they are written to the adapters,
+// it can change the entry, e.g. to redact a message or to add a field, or
+// suppress it returning ErrDropEntry
+type Hook func(e *Entry) error
+
+var (
+	hooks    []Hook
+	hookLock = sync.RWMutex{}
+)
+
+// AddHook registers h to run for every entry of every logger that is
+// enabled, after its caller is set, in registration order. A hook that
+// fails with an error other than ErrDropEntry is reported on stderr and
+// the entry is written anyway. Hooks run on the goroutine that logs and
+// must not log.
+//
+//	log.AddHook(func(e *log.Entry) error {
+//		e.SetField("host", host)
+//		return nil
+//	})
+func AddHook(h Hook) {
+	hookLock.Lock()
+	hooks = append(hooks, h)
+	hookLock.Unlock()
+}
+
+// RemoveHooks removes all the hooks
+func RemoveHooks() {
+	hookLock.Lock()
+	hooks = nil
+	hookLock.Unlock()
+}
+
+// runHooks runs the hooks for e, it returns false if one of them drops it
+func runHooks(e *Entry) bool {
+	hookLock.RLock()
+	list := hooks
+	hookLock.RUnlock()
+	for _, h := range list {
+		err := h(e)
+		if err == ErrDropEntry {
+			return false
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "log: hook failed:", err)
+		}
+	}
+	return true
+}
+
+// SetField sets the field key of e, shown with the message like the
+// fields given with WithFields
+func (e *Entry) SetField(key string, value interface{}) {
+	if e.Fields == nil {
+		e.Fields = make(Fields)
+	}
+	if _, ok := e.Fields[key]; ok {
+		e.DeleteField(key)
+	}
+	e.Fields[key] = value
+	i := sort.SearchStrings(e.Keys, key)
+	e.Keys = append(e.Keys, "")
+	copy(e.Keys[i+1:], e.Keys[i:])
+	e.Keys[i] = key
+}
+
+// DeleteField removes the field key of e
+func (e *Entry) DeleteField(key string) {
+	delete(e.Fields, key)
+	for i, k := range e.Keys {
+		if k == key {
+			e.Keys = append(e.Keys[:i:i], e.Keys[i+1:]...)
+			return
+		}
+	}
+}
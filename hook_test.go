@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	var callers []string
+	AddHook(func(e *Entry) error {
+		callers = append(callers, e.Caller)
+		e.SetField("host", "web1")
+		return nil
+	})
+	AddHook(func(e *Entry) error {
+		if strings.Contains(e.Message(), "health") {
+			return ErrDropEntry
+		}
+		if _, ok := e.Fields["password"]; ok {
+			e.SetField("password", "***")
+		}
+		return nil
+	})
+	AddHook(func(e *Entry) error {
+		return errors.New("ignored")
+	})
+
+	Debugln("disabled")
+	Println("health check")
+	WithFields(Fields{"user": "ann", "password": "secret"}).Println("login")
+
+	ts := now().Format(TimeFormat)
+	expected := ts + " [msg] login host=web1 password=*** user=ann\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+	if len(callers) != 2 || callers[0] != "hook_test.go:37" {
+		t.Fatalf("Error, the hooks got the callers %q", callers)
+	}
+}
+
+func TestSetField(t *testing.T) {
+	e := &Entry{}
+	e.SetField("b", 1)
+	e.SetField("a", 2)
+	e.SetField("c", 3)
+	e.SetField("b", 4)
+	e.DeleteField("c")
+	e.DeleteField("missing")
+	if strings.Join(e.Keys, ",") != "a,b" || e.Fields["b"] != 4 || len(e.Fields) != 2 {
+		t.Fatalf("Error, got the keys %q and the fields %v", e.Keys, e.Fields)
+	}
+}
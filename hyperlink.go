@@ -0,0 +1,76 @@
+package log
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// URL templates of CallerLinks
+const (
+	// FileLinks opens the file of the caller
+	FileLinks = "file://{path}"
+	// VSCodeLinks opens the file of the caller at the line in VS Code
+	VSCodeLinks = "vscode://file{path}:{line}"
+)
+
+// CallerLinks, if not empty, renders the callers shown by TextFormatter
+// in the debug mode as OSC 8 hyperlinks, so they can be clicked in the
+// terminal to jump to the source. It is the URL template of the links,
+// "{path}" is replaced by the absolute path of the file and "{line}" by
+// the line, e.g. FileLinks or VSCodeLinks. The terminals that don't
+// support OSC 8 show the escape sequences, use AutoHyperlinks to enable
+// the links only on the ones that do.
+var CallerLinks string
+
+// AutoHyperlinks sets CallerLinks to template if stdout is a terminal
+// known to support OSC 8 hyperlinks and returns false, leaving
+// CallerLinks untouched, otherwise.
+func AutoHyperlinks(template string) bool {
+	if _, ok := terminalWidth(os.Stdout.Fd()); !ok || !hyperlinksSupported() {
+		return false
+	}
+	lock.Lock()
+	CallerLinks = template
+	lock.Unlock()
+	return true
+}
+
+// hyperlinksSupported detects the terminals that support OSC 8 by their
+// environment variables
+func hyperlinksSupported() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "Hyper", "ghostty":
+		return true
+	}
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	for _, env := range []string{"WT_SESSION", "KONSOLE_VERSION", "DOMTERM"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	term := os.Getenv("TERM")
+	return strings.Contains(term, "kitty") || strings.HasPrefix(term, "foot") || term == "alacritty"
+}
+
+// callerLink returns the caller of e, as a hyperlink if CallerLinks is set
+func callerLink(e *Entry) string {
+	if CallerLinks == "" || e.callerFile == "" {
+		return e.Caller
+	}
+	path := filepath.ToSlash(e.callerFile)
+	if !strings.HasPrefix(path, "/") {
+		// windows drive letter
+		path = "/" + path
+	}
+	line := e.Caller[strings.LastIndexByte(e.Caller, ':')+1:]
+	link := strings.NewReplacer(
+		"{path}", (&url.URL{Path: path}).EscapedPath(),
+		"{line}", line,
+	).Replace(CallerLinks)
+	return "\x1b]8;;" + link + "\x1b\\" + e.Caller + "\x1b]8;;\x1b\\"
+}
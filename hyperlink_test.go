@@ -0,0 +1,52 @@
+package log
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestCallerLinks(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	DebugMode = true
+	CallerLinks = VSCodeLinks
+
+	out, err := getOutput(Println, "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dir, _ := os.Getwd()
+	expected := "^" + regexp.QuoteMeta(now().Format(TimeFormat)+" [msg] \x1b]8;;vscode://file"+dir+"/log_test.go:") +
+		"([0-9]+)" + regexp.QuoteMeta("\x1b\\log_test.go:") + "([0-9]+)" + regexp.QuoteMeta("\x1b]8;;\x1b\\ log test\n") + "$"
+	m := regexp.MustCompile(expected).FindStringSubmatch(string(out))
+	if m == nil || m[1] != m[2] {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expected)
+	}
+
+	// entries received from other processes have no path
+	e := &Entry{Caller: "main.go:10"}
+	if l := callerLink(e); l != "main.go:10" {
+		t.Fatalf("Error, unexpected link %q", l)
+	}
+}
+
+func TestHyperlinksSupported(t *testing.T) {
+	for _, env := range []string{"TERM_PROGRAM", "VTE_VERSION", "WT_SESSION", "KONSOLE_VERSION", "DOMTERM", "TERM"} {
+		if v, ok := os.LookupEnv(env); ok {
+			defer os.Setenv(env, v)
+		} else {
+			defer os.Unsetenv(env)
+		}
+		os.Unsetenv(env)
+	}
+	os.Setenv("TERM", "xterm-256color")
+	if hyperlinksSupported() {
+		t.Fatal("Error, expected no support")
+	}
+	os.Setenv("VTE_VERSION", "6003")
+	if !hyperlinksSupported() {
+		t.Fatal("Error, expected support for VTE")
+	}
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IDGenerator creates unique identifiers
+type IDGenerator func() string
+
+var (
+	// RequestIDGenerator creates the IDs returned by NewRequestID,
+	// default UUIDv4.
+	RequestIDGenerator IDGenerator = UUIDv4
+
+	// EntryIDGenerator, if not nil, creates the ID of every entry.
+	EntryIDGenerator IDGenerator
+
+	// ErrorRefGenerator, if not nil, creates a reference for every error
+	// entry, shown with the message and returned by HTTPError, so users
+	// can report it.
+	ErrorRefGenerator IDGenerator
+)
+
+// NewRequestID creates a request ID with RequestIDGenerator
+func NewRequestID() string {
+	return RequestIDGenerator()
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+// UUIDv4 creates a random UUID (RFC 4122 version 4)
+func UUIDv4() string {
+	var u [16]byte
+	randomBytes(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID creates a lexicographically sortable identifier with the current
+// time in milliseconds and 80 random bits.
+func ULID() string {
+	var u [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	randomBytes(u[6:])
+
+	// 128 bits in 26 characters of 5 bits, the first one has 3 bits
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	var b [26]byte
+	for i := 25; i >= 0; i-- {
+		b[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b[:])
+}
+
+// Sonyflake returns a generator of Sonyflake compatible IDs: 39 bits of
+// time in 10ms units since 2014-09-01, 8 bits of sequence and 16 bits of
+// machineID, formatted in decimal.
+func Sonyflake(machineID uint16) IDGenerator {
+	epoch := time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	var (
+		mu      sync.Mutex
+		elapsed int64
+		seq     uint16
+	)
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		current := int64(time.Since(epoch) / (10 * time.Millisecond))
+		if current > elapsed {
+			elapsed = current
+			seq = 0
+		} else {
+			seq = (seq + 1) & 0xff
+			if seq == 0 {
+				// sequence overflow, use the next time unit
+				elapsed++
+				time.Sleep(time.Duration(elapsed-current) * 10 * time.Millisecond)
+			}
+		}
+		id := uint64(elapsed)<<24 | uint64(seq)<<16 | uint64(machineID)
+		return strconv.FormatUint(id, 10)
+	}
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestIDGenerators(t *testing.T) {
+	tests := []struct {
+		name string
+		gen  IDGenerator
+		re   string
+	}{
+		{"UUIDv4", UUIDv4, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"},
+		{"ULID", ULID, "^[0-7][0-9A-HJKMNP-TV-Z]{25}$"},
+		{"Sonyflake", Sonyflake(7), "^[0-9]+$"},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(tt.re)
+		seen := map[string]bool{}
+		for i := 0; i < 1000; i++ {
+			id := tt.gen()
+			if !re.MatchString(id) {
+				t.Fatalf("Error, %s generated %q", tt.name, id)
+			}
+			if seen[id] {
+				t.Fatalf("Error, %s generated %q twice", tt.name, id)
+			}
+			seen[id] = true
+		}
+	}
+
+	id, _ := strconv.ParseUint(Sonyflake(7)(), 10, 64)
+	if id&0xffff != 7 {
+		t.Fatalf("Error, Sonyflake machine ID is %d, expected 7", id&0xffff)
+	}
+	if a, b := ULID(), ULID(); a[:10] > b[:10] {
+		t.Fatalf("Error, ULID %q sorts after %q", a, b)
+	}
+}
+
+func TestEntryIDs(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	n := 0
+	counter := func() string {
+		n++
+		return "id" + strconv.Itoa(n)
+	}
+	EntryIDGenerator = counter
+	ErrorRefGenerator = func() string { return "REF1" }
+
+	e := newEntry(MessageLog, LineOut, "msg")
+	if e.ID != "id1" || e.Ref != "" {
+		t.Fatalf("Error, entry ID %q ref %q", e.ID, e.Ref)
+	}
+	e = newEntry(ErrorLog, LineOut, "msg")
+	if e.ID != "id2" || e.Ref != "REF1" {
+		t.Fatalf("Error, entry ID %q ref %q", e.ID, e.Ref)
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var d Entry
+	if err = json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.ID != "id2" || d.Ref != "REF1" {
+		t.Fatalf("Error, decoded ID %q ref %q", d.ID, d.Ref)
+	}
+
+	out, err := getOutput(Errorln, "failed")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !regexp.MustCompile(`failed \(ref REF1\)`).Match(out) {
+		t.Fatalf("Error, printed %q, expected the reference", string(out))
+	}
+
+	var body map[string]string
+	_, err = getOutput(func(msg ...interface{}) {
+		w := httptest.NewRecorder()
+		HTTPError(w, http.StatusInternalServerError)
+		err = json.Unmarshal(w.Body.Bytes(), &body)
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if body["reference"] != "REF1" {
+		t.Fatalf("Error, response %v, expected reference REF1", body)
+	}
+}
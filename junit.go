@@ -0,0 +1,102 @@
+package log
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the collected diagnostics to w as JUnit XML, so CI
+// systems show them as test results. Each file is a test suite, the
+// diagnostics without file are in the suite named name, and each
+// diagnostic is a test case named by its position and rule. The errors
+// are failures and the warnings are skipped test cases, shown without
+// failing the build.
+func WriteJUnit(w io.Writer, name string) error {
+	suites := junitSuites{Name: name}
+	index := make(map[string]int)
+	for _, d := range Diagnostics() {
+		file := d.File
+		if file == "" {
+			file = name
+		}
+		i, ok := index[file]
+		if !ok {
+			i = len(suites.Suites)
+			index[file] = i
+			suites.Suites = append(suites.Suites, junitSuite{Name: file})
+		}
+		s := &suites.Suites[i]
+
+		c := junitCase{Name: d.Message, ClassName: file}
+		if pos := d.position(); pos != "" {
+			c.Name = file + ":" + pos
+		}
+		if d.Rule != "" {
+			c.Name += " " + d.Rule
+		}
+		m := &junitMessage{Message: d.Message, Type: Prefixes[d.Type], Text: d.Message}
+		if d.Type == ErrorLog {
+			c.Failure = m
+			s.Failures++
+			suites.Failures++
+		} else {
+			c.Skipped = m
+			s.Skipped++
+			suites.Skipped++
+		}
+		s.Tests++
+		suites.Tests++
+		s.Cases = append(s.Cases, c)
+	}
+	b, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(b)+"\n")
+	return err
+}
+
+// WriteJUnitFile writes the collected diagnostics to the file name as
+// JUnit XML
+func WriteJUnitFile(name, suite string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err = WriteJUnit(f, suite); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
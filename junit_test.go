@@ -0,0 +1,45 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	SetOutput(ioutil.Discard)
+	CollectDiagnostics(true)
+	defer CollectDiagnostics(false)
+	defer ResetDiagnostics()
+
+	At("a.go", 12, 3).WithField(RuleKey, "unused").Warningln("unused variable x")
+	At("a.go", 20, 0).Errorln(`undefined: "y"`)
+	Errorln("build failed")
+
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, "vet"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="vet" tests="3" failures="2" skipped="1">
+  <testsuite name="vet" tests="1" failures="1" skipped="0">
+    <testcase name="build failed" classname="vet">
+      <failure message="build failed" type="error">build failed</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="a.go" tests="2" failures="1" skipped="1">
+    <testcase name="a.go:12:3 unused" classname="a.go">
+      <skipped message="unused variable x" type="warning">unused variable x</skipped>
+    </testcase>
+    <testcase name="a.go:20" classname="a.go">
+      <failure message="undefined: &#34;y&#34;" type="error">undefined: &#34;y&#34;</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if out.String() != expected {
+		t.Fatalf("Error, wrote\n%s\nexpected\n%s", out.String(), expected)
+	}
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of the messages, the messages below the level of
+// the logger are not logged.
+type Level uint32
+
+// Levels in increasing severity
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarningLevel
+	ErrorLevel
+	FatalLevel
+)
+
+var levelNames = []string{
+	DebugLevel:   "debug",
+	InfoLevel:    "info",
+	WarningLevel: "warning",
+	ErrorLevel:   "error",
+	FatalLevel:   "fatal",
+}
+
+func (l Level) String() string {
+	if int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("Level(%d)", uint32(l))
+}
+
+// ParseLevel returns the level of its name, case insensitive, with "warn"
+// accepted for "warning".
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "warn" {
+		return WarningLevel, nil
+	}
+	for l, name := range levelNames {
+		if name == s {
+			return Level(l), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("log: unknown level %q", s)
+}
+
+// MarshalText returns the name of the level
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses the level with ParseLevel, so levels can be read
+// from config files.
+func (l *Level) UnmarshalText(b []byte) error {
+	v, err := ParseLevel(string(b))
+	if err != nil {
+		return err
+	}
+	*l = v
+	return nil
+}
+
+// Level returns the level of the message type. Message2Log and EventLog
+// are InfoLevel and PanicLog is FatalLevel.
+func (m MsgType) Level() Level {
+	switch m {
+	case DebugLog:
+		return DebugLevel
+	case WarningLog:
+		return WarningLevel
+	case ErrorLog:
+		return ErrorLevel
+	case FatalLog, PanicLog:
+		return FatalLevel
+	}
+	return InfoLevel
+}
+
+// SetLevel sets the minimum level of the messages logged by the package
+// functions, InfoLevel by default. DebugLevel enables the debug messages
+// as DebugMode does.
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+// GetLevel returns the minimum level of the messages logged by the
+// package functions
+func GetLevel() Level {
+	return std.GetLevel()
+}
+
+// WithLevel sets the minimum level of the messages of the logger, default
+// InfoLevel
+func WithLevel(level Level) Option {
+	return func(l *Logger) { l.level = uint32(level) }
+}
+
+// SetLevel sets the minimum level of the messages logged by l
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreUint32(&l.logger().level, uint32(level))
+}
+
+// GetLevel returns the minimum level of the messages logged by l
+func (l *Logger) GetLevel() Level {
+	return Level(atomic.LoadUint32(&l.logger().level))
+}
+
+// enabled reports if e is logged at the level of its logger. The events
+// are not diagnostics and are always logged, the debug messages are also
+// logged by the debug mode and by the contexts created by WithDebug.
+func (e *Entry) enabled() bool {
+	switch {
+	case e.Type == EventLog:
+		return true
+	case e.Type == DebugLog:
+		return e.DebugEnabled()
+	}
+	return e.Type.Level() >= e.logger.GetLevel()
+}
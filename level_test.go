@@ -0,0 +1,90 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		s        string
+		expected Level
+	}{
+		{"debug", DebugLevel},
+		{"INFO", InfoLevel},
+		{"warn", WarningLevel},
+		{" warning ", WarningLevel},
+		{"error", ErrorLevel},
+		{"Fatal", FatalLevel},
+	}
+	for _, tc := range testCases {
+		l, err := ParseLevel(tc.s)
+		if err != nil || l != tc.expected {
+			t.Fatalf("Error, ParseLevel(%q) = %v, %v, expected %v", tc.s, l, err, tc.expected)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("Error, expected error for unknown level")
+	}
+
+	var cfg struct {
+		Level Level `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(`{"level":"warn"}`), &cfg); err != nil || cfg.Level != WarningLevel {
+		t.Fatalf("Error, decoded %v, %v", cfg.Level, err)
+	}
+	if b, _ := json.Marshal(cfg); string(b) != `{"level":"warning"}` {
+		t.Fatalf("Error, encoded %s", b)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var types []MsgType
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		types = append(types, e.Type)
+	}})
+	RegisterEvent("level_changed", EventSchema{})
+
+	logAll := func() {
+		Debugln("debug")
+		Println("info")
+		Warningln("warning")
+		Errorln("error")
+		_ = Event("level_changed")
+	}
+
+	SetLevel(WarningLevel)
+	out, err := getOutput(func(msg ...interface{}) { logAll() })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(types) != 3 || types[0] != WarningLog || types[1] != ErrorLog || types[2] != EventLog {
+		t.Fatalf("Error, adapter received %v", types)
+	}
+	if w, _ := Counts(); w == 0 {
+		t.Fatal("Error, warning not counted")
+	}
+	if len(out) == 0 {
+		t.Fatal("Error, nothing printed")
+	}
+
+	types = nil
+	SetLevel(DebugLevel)
+	_, _ = getOutput(func(msg ...interface{}) { logAll() })
+	if len(types) != 5 || types[0] != DebugLog {
+		t.Fatalf("Error, adapter received %v at DebugLevel", types)
+	}
+
+	l := New(WithLevel(ErrorLevel), WithoutAdapter("stdout"), WithAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) { types = append(types, e.Type) },
+	}))
+	types = nil
+	l.Warningln("warning")
+	l.Errorln("error")
+	if len(types) != 1 || types[0] != ErrorLog || GetLevel() != DebugLevel {
+		t.Fatalf("Error, logger received %v", types)
+	}
+}
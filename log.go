@@ -3,11 +3,11 @@ package log
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"path/filepath"
-	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,45 +22,160 @@ const (
 	WarningLog         MsgType = 2
 	DebugLog           MsgType = 3
 	ErrorLog           MsgType = 4
+	EventLog           MsgType = 5
+	FatalLog           MsgType = 6
+	PanicLog           MsgType = 7
 	FormattedOut       OutType = 0
 	LineOut            OutType = 1
 	DefaultMaxLineSize int     = 2000
-	DefaultTimeFormat  string  = "2006/01/02 15:04:05"
+	DefaultTimeFormat  string  = MillisecondTimeFormat
+
+	// SecondTimeFormat is the time format with second precision, the
+	// default before MillisecondTimeFormat
+	SecondTimeFormat string = "2006/01/02 15:04:05"
+	// MillisecondTimeFormat is SecondTimeFormat with millisecond precision
+	MillisecondTimeFormat string = "2006/01/02 15:04:05.000"
+	// MicrosecondTimeFormat is SecondTimeFormat with microsecond precision
+	MicrosecondTimeFormat string = "2006/01/02 15:04:05.000000"
 )
 
 // AdapterFunc is the type for the function adapter
 // any function that has this signature can be used as an adapter
-type AdapterFunc func(m MsgType, o OutType, config map[string]interface{}, msg ...interface{})
+type AdapterFunc func(e *Entry, config map[string]interface{})
+
+// LegacyAdapterFunc is the signature of the adapters before AdapterFunc
+// got the entry, see LegacyAdapter
+type LegacyAdapterFunc func(m MsgType, o OutType, config map[string]interface{}, msg ...interface{})
+
+// LegacyAdapter returns an AdapterFunc that calls fn with the type, the
+// output and the arguments of the entry, so adapters written for the old
+// signature can still be added with AddAdapter.
+func LegacyAdapter(fn LegacyAdapterFunc) AdapterFunc {
+	return func(e *Entry, config map[string]interface{}) {
+		fn(e.Type, e.Out, config, e.Msg...)
+	}
+}
+
+// AdapterErrFunc is the type of the adapters that return the error of
+// writing the entry, e.g. of the network, set in AdapterPod.Write
+type AdapterErrFunc func(e *Entry, config map[string]interface{}) error
 
 // AdapterPod contains the metadata of an adapter
 type AdapterPod struct {
 	Adapter AdapterFunc
 	Config  map[string]interface{}
+	// Write, if not nil, is called instead of Adapter and returns the
+	// error of writing the entry, handled as set by Retries and OnFailure.
+	Write AdapterErrFunc
+	// Retries is the number of times the entry is written again when the
+	// adapter fails, waiting RetryDelay before each one. A panic of the
+	// adapter is also a failure.
+	Retries    int
+	RetryDelay time.Duration
+	// OnFailure is done with the entry the adapter failed to write after
+	// the retries, see OnAdapterError to be notified.
+	OnFailure FailurePolicy
+	// MinLevel is the level of the least severe messages the adapter
+	// gets, e.g. ErrorLevel for a chat adapter. The level of the logger,
+	// see SetLevel, still applies.
+	MinLevel Level
+	// Levels, if not empty, are the only message types the adapter gets.
+	Levels []MsgType
+	// Flush, if not nil, is called by log.Flush to write any buffered
+	// entry of the adapter.
+	Flush func(config map[string]interface{}) error
+	// Close, if not nil, is called by log.Close to release the
+	// resources of the adapter.
+	Close func(config map[string]interface{}) error
+	// Check, if not nil, is called by log.SelfTest to validate the
+	// config of the adapter, e.g. reachability of the destination.
+	Check func(config map[string]interface{}) error
 }
 
 var (
-	// DebugMode Enable debug mode
+	// DebugMode Enable debug mode, the debug messages are also shown at
+	// DebugLevel, see SetLevel. Use SetDebugMode to change it while other
+	// goroutines are logging.
 	DebugMode bool
 
-	// EnableANSIColors enables ANSI colors, default true
+	// EnableANSIColors enables ANSI colors, default true. The colors are
+	// only written to terminals, not to files, pipes or other writers,
+	// unless ForceColors is set.
 	EnableANSIColors = true
 
+	// ForceColors writes the colors enabled by EnableANSIColors, or by
+	// WithANSIColors, to any output, e.g. to pipes read by programs that
+	// show them.
+	ForceColors bool
+
 	// MaxLineSize limits the size of the line, if the size
 	// exceeds that indicated by MaxLineSize the system cuts
-	// the string and adds "..." at the end.
+	// the string and adds "..." at the end. Use SetMaxLineSize to change
+	// it while other goroutines are logging.
 	MaxLineSize = DefaultMaxLineSize
 
 	// TimeFormat defines which pattern will be applied for
-	// display time in the logs.
+	// display time in the logs, MillisecondTimeFormat by default. Use
+	// MicrosecondTimeFormat to order denser bursts of messages or
+	// SecondTimeFormat for the shorter format of the older versions.
 	TimeFormat = DefaultTimeFormat
 
-	// Colors contain color array
+	// OutputErrorHandler is called with the error when DefaultAdapter
+	// fails to write to its output, e.g. EPIPE when the reader of a pipe is
+	// gone. Nil, the default, ignores the error.
+	OutputErrorHandler func(err error)
+
+	// LevelHook, if not nil, is called with every entry before it is
+	// sent to the adapters and returns its level, e.g. to downgrade the
+	// known noisy errors of a dependency to warnings. The counters, the
+	// error reference and the adapters see the returned level.
+	LevelHook func(e *Entry) MsgType
+
+	// HTTPErrorBody, if not nil, returns the value written as JSON by
+	// HTTPError for the status code, instead of the default body with
+	// the "status", "error" and "reference" fields. The entry logged for
+	// the error has its reference, ID and fields. Returning nil writes
+	// the default body.
+	HTTPErrorBody func(status int, e *Entry) interface{}
+
+	// HTTPErrorDetail returns the message of HTTPErrorf in the "detail"
+	// field of the default body. It is disabled by default, as the
+	// message may show internals of the service to the clients.
+	HTTPErrorDetail bool
+
+	// AlignPrefixes pads the level tags to the width of the longest
+	// prefix so messages of different levels are vertically aligned.
+	AlignPrefixes bool
+
+	// ShowIcons replaces the level tags of TextFormatter with the Icons of
+	// the levels, e.g. for the friendlier output of CLI tools. The lines
+	// keep the colors of their level and AlignPrefixes doesn't apply.
+	ShowIcons bool
+
+	// Colors contain color array, see SetColor to change them while
+	// logging
 	Colors = []string{
 		MessageLog:  "\x1b[37m", // White
 		Message2Log: "\x1b[92m", // Light green
 		WarningLog:  "\x1b[93m", // Light Yellow
 		DebugLog:    "\x1b[96m", // Light Cyan
 		ErrorLog:    "\x1b[91m", // Light Red
+		EventLog:    "\x1b[95m", // Light Magenta
+		FatalLog:    "\x1b[31m", // Red
+		PanicLog:    "\x1b[35m", // Magenta
+	}
+
+	// Icons of messages shown by ShowIcons, an empty icon shows the
+	// prefix of the message instead
+	Icons = []string{
+		MessageLog:  "\u2139",     // ℹ
+		Message2Log: "\u2714",     // ✔
+		WarningLog:  "\u26a0",     // ⚠
+		DebugLog:    "\U0001f41b", // 🐛
+		ErrorLog:    "\u2716",     // ✖
+		EventLog:    "\u2605",     // ★
+		FatalLog:    "\u2620",     // ☠
+		PanicLog:    "\U0001f4a5", // 💥
 	}
 
 	// Prefixes of messages
@@ -70,13 +185,27 @@ var (
 		WarningLog:  "warning",
 		DebugLog:    "debug",
 		ErrorLog:    "error",
+		EventLog:    "event",
+		FatalLog:    "fatal",
+		PanicLog:    "panic",
 	}
 
 	now      = time.Now
+	exit     = os.Exit
 	adapters = make(map[string]AdapterPod)
 	lock     = sync.RWMutex{}
+
+	// settingsLock guards DebugMode and MaxLineSize, it is not held
+	// while the adapters run, so they can read the settings.
+	settingsLock = sync.RWMutex{}
 )
 
+// IsError reports if m is ErrorLog or one of the types of the messages
+// logged before the program exits or panics, FatalLog and PanicLog.
+func (m MsgType) IsError() bool {
+	return m == ErrorLog || m == FatalLog || m == PanicLog
+}
+
 func init() {
 	if len(adapters) == 0 {
 		AddAdapter("stdout", AdapterPod{
@@ -88,133 +217,356 @@ func init() {
 
 // AddAdapter allows to add an adapter and parameters
 func AddAdapter(name string, adapter AdapterPod) {
-	lock.Lock()
-	adapters[name] = adapter
-	lock.Unlock()
+	std.AddAdapter(name, adapter)
 }
 
 // RemoveAdapter remove the adapter from list
 func RemoveAdapter(name string) {
-	lock.Lock()
-	delete(adapters, name)
-	lock.Unlock()
+	std.RemoveAdapter(name)
+}
+
+// SetOutput sets the writer of DefaultAdapter, e.g. a file, a buffer or a
+// network connection, nil restores os.Stdout. The writes are serialized,
+// w doesn't need to be safe for concurrent use.
+func SetOutput(w io.Writer) {
+	std.SetOutput(w)
+}
+
+// SetErrorOutput sets the writer of the error, fatal and panic messages
+// of DefaultAdapter, e.g. os.Stderr to split them from the other messages
+// in container logs. Nil, the default, writes them to the output set by
+// SetOutput.
+func SetErrorOutput(w io.Writer) {
+	std.SetErrorOutput(w)
 }
 
 // SetAdapterConfig allows set new adapter parameters
 func SetAdapterConfig(name string, config map[string]interface{}) {
-	lock.Lock()
-	a := adapters[name]
-	a.Config = config
-	adapters[name] = a
-	lock.Unlock()
+	std.SetAdapterConfig(name, config)
+}
+
+// SetDebugMode sets DebugMode, safe for concurrent use with the logging
+// functions
+func SetDebugMode(enabled bool) {
+	settingsLock.Lock()
+	DebugMode = enabled
+	settingsLock.Unlock()
+}
+
+// GetDebugMode returns DebugMode, safe for concurrent use with
+// SetDebugMode
+func GetDebugMode() bool {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return DebugMode
+}
+
+// SetMaxLineSize sets MaxLineSize, safe for concurrent use with the
+// logging functions
+func SetMaxLineSize(size int) {
+	settingsLock.Lock()
+	MaxLineSize = size
+	settingsLock.Unlock()
+}
+
+// GetMaxLineSize returns MaxLineSize, safe for concurrent use with
+// SetMaxLineSize
+func GetMaxLineSize() int {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return MaxLineSize
+}
+
+func dispatch(e *Entry) {
+	if LevelHook != nil {
+		if t := LevelHook(e); t != e.Type {
+			e.Type = t
+			e.Ref, e.Env = "", nil
+			e.errorInfo()
+		}
+	}
+	if !e.enabled() {
+		return
+	}
+	if e.Caller == "" && e.logger != nil {
+		// entries created by this process, skip the function that
+		// created the entry and the log function
+		e.Caller, e.callerFile = caller(3)
+		if TraceOnError && e.Type.IsError() && e.Stack == nil {
+			e.Stack = callerStack(3)
+		}
+	}
+	if !runHooks(e) {
+		return
+	}
+	if redacting() {
+		e.redact()
+	}
+	if e.tx != nil && e.tx.add(e) {
+		return
+	}
+	countMessage(e.Type)
+	if limited(e) {
+		return
+	}
+	txLock.RLock()
+	send(e)
+	txLock.RUnlock()
+}
+
+// send writes e to the adapters of its logger, or to the diagnostics or
+// the async buffer of the default logger
+func send(e *Entry) {
+	l := e.logger.logger()
+	if l == std {
+		if collectDiagnostic(e) {
+			return
+		}
+		if b := asyncBuffered(); b != nil && b.push(e) {
+			return
+		}
+	}
+	deliver(l, e)
+}
+
+// deliver writes e to the adapters of l, or queues it for the workers
+func deliver(l *Logger, e *Entry) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l == std && pool != nil {
+		pool.enqueue(e)
+		return
+	}
+	n := 0
+	for name, a := range *l.adapters {
+		if !a.accepts(e.Type) {
+			continue
+		}
+		n++
+		if runAdapter(name, a, e) != nil {
+			atomic.AddInt32(&e.failures, 1)
+		}
+	}
+	finishEntry(e, n)
+}
+
+// accepts reports if the adapter gets the messages of type m, as set by
+// MinLevel and Levels
+func (a AdapterPod) accepts(m MsgType) bool {
+	if m.Level() < a.MinLevel {
+		return false
+	}
+	if len(a.Levels) == 0 {
+		return true
+	}
+	for _, l := range a.Levels {
+		if l == m {
+			return true
+		}
+	}
+	return false
 }
 
-func runAdapters(m MsgType, o OutType, msg ...interface{}) {
-	lock.RLock()
-	defer lock.RUnlock()
-	for _, a := range adapters {
-		a.Adapter(m, o, a.Config, msg...)
+// finishEntry writes e to stderr if the n adapters failed to write it, or
+// an adapter with ConsoleOnFailure did
+func finishEntry(e *Entry, n int) {
+	if n == 0 {
+		return
+	}
+	if int(atomic.LoadInt32(&e.failures)) >= n {
+		fallbackEntry(e)
+		return
+	}
+	fallbackRecovered()
+	if atomic.LoadInt32(&e.console) != 0 {
+		fallbackLine(e)
 	}
 }
 
 // HTTPError write lot to stdout and return json error on http.ResponseWriter with http error code.
+// If ErrorRefGenerator is set, the reference of the error is returned in
+// the "reference" field. HTTPErrorBody replaces the JSON body. Called by a
+// handler of Middleware, the error has the fields of the request and the
+// logger of the middleware.
 func HTTPError(w http.ResponseWriter, code int) {
+	httpError(w, code, "")
+}
+
+// HTTPErrorf works like HTTPError logging the status text followed by the
+// formatted message, e.g. "Not Found: user 42". The message is also
+// returned in the "detail" field of the body if HTTPErrorDetail is set.
+func HTTPErrorf(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	httpError(w, code, fmt.Sprintf(format, args...))
+}
+
+// httpError logs the error of HTTPError and HTTPErrorf, with the caller
+// of them, and writes the response
+func httpError(w http.ResponseWriter, code int, detail string) {
 	msg := http.StatusText(code)
-	Errorln(msg)
-	m := make(map[string]string)
-	m["status"] = "error"
-	m["error"] = msg
-	b, _ := json.MarshalIndent(m, "", "\t")
+	if detail != "" {
+		msg += ": " + detail
+	}
+	var e *Entry
+	rw, ok := w.(*responseWriter)
+	if ok {
+		e = rw.log.logger.newEntry(rw.log.fields, ErrorLog, LineOut, msg)
+	} else {
+		e = std.newEntry(nil, ErrorLog, LineOut, msg)
+	}
+	e.Caller, e.callerFile = caller(2)
+	dispatch(e)
+	if ok {
+		rw.ref = e.Ref
+	}
+	writeHTTPError(w, code, detail, e)
+}
+
+// writeHTTPError writes the response of the error logged by e
+func writeHTTPError(w http.ResponseWriter, code int, detail string, e *Entry) {
+	var body interface{}
+	if HTTPErrorBody != nil {
+		body = HTTPErrorBody(code, e)
+	}
+	if body == nil {
+		m := make(map[string]string)
+		m["status"] = "error"
+		m["error"] = http.StatusText(code)
+		if e.Ref != "" {
+			m["reference"] = e.Ref
+		}
+		if detail != "" && HTTPErrorDetail {
+			m["detail"] = detail
+		}
+		body = m
+	}
+	b, _ := json.MarshalIndent(body, "", "\t")
 	http.Error(w, string(b), code)
 }
 
-// Fatal show message with line break at the end and exit to OS.
+// Fatal show message with line break at the end, flushes the adapters and
+// exit to OS.
 func Fatal(msg ...interface{}) {
-	runAdapters(ErrorLog, LineOut, msg...)
-	os.Exit(-1)
+	std.runAdapters(ErrorLog, LineOut, msg...)
+	runExitHandlers()
+	_ = Flush()
+	exit(-1)
+}
+
+// Fatalln shows the message as FatalLog with line break at the end,
+// flushes the adapters and exits to OS with status 1.
+func Fatalln(msg ...interface{}) {
+	std.runAdapters(FatalLog, LineOut, msg...)
+	runExitHandlers()
+	_ = Flush()
+	exit(1)
+}
+
+// Fatalf shows the formatted message as FatalLog, flushes the adapters and
+// exits to OS with status 1.
+func Fatalf(msg ...interface{}) {
+	std.runAdapters(FatalLog, FormattedOut, msg...)
+	runExitHandlers()
+	_ = Flush()
+	exit(1)
+}
+
+// Panicln shows the message as PanicLog with line break at the end,
+// flushes the adapters and panics with the message.
+func Panicln(msg ...interface{}) {
+	e := std.runAdapters(PanicLog, LineOut, msg...)
+	_ = Flush()
+	panic(e.Message())
+}
+
+// Panicf shows the formatted message as PanicLog, flushes the adapters
+// and panics with the message.
+func Panicf(msg ...interface{}) {
+	e := std.runAdapters(PanicLog, FormattedOut, msg...)
+	_ = Flush()
+	panic(e.Message())
 }
 
 // Errorln message with line break at the end.
 func Errorln(msg ...interface{}) {
-	runAdapters(ErrorLog, LineOut, msg...)
+	std.runAdapters(ErrorLog, LineOut, msg...)
 }
 
 // Errorf shows formatted error message on stdout without line break at the end.
 func Errorf(msg ...interface{}) {
-	runAdapters(ErrorLog, FormattedOut, msg...)
+	std.runAdapters(ErrorLog, FormattedOut, msg...)
 }
 
 // Warningln shows warning message on stdout with line break at the end.
 func Warningln(msg ...interface{}) {
-	runAdapters(WarningLog, LineOut, msg...)
+	std.runAdapters(WarningLog, LineOut, msg...)
 }
 
 // Warningf shows formatted warning message on stdout without line break at the end.
 func Warningf(msg ...interface{}) {
-	runAdapters(WarningLog, FormattedOut, msg...)
+	std.runAdapters(WarningLog, FormattedOut, msg...)
 }
 
 // Println shows message on stdout with line break at the end.
 func Println(msg ...interface{}) {
-	runAdapters(MessageLog, LineOut, msg...)
+	std.runAdapters(MessageLog, LineOut, msg...)
 }
 
 // Printf shows formatted message on stdout without line break at the end.
 func Printf(msg ...interface{}) {
-	runAdapters(MessageLog, FormattedOut, msg...)
+	std.runAdapters(MessageLog, FormattedOut, msg...)
 }
 
 // Debugln shows debug message on stdout with line break at the end.
 // If debug mode is not active no message is displayed
 func Debugln(msg ...interface{}) {
-	runAdapters(DebugLog, LineOut, msg...)
+	std.runAdapters(DebugLog, LineOut, msg...)
 }
 
 // Debugf shows debug message on stdout without line break at the end.
 // If debug mode is not active no message is displayed
 func Debugf(msg ...interface{}) {
-	runAdapters(DebugLog, FormattedOut, msg...)
+	std.runAdapters(DebugLog, FormattedOut, msg...)
 }
 
-// DefaultAdapter of log package
-func DefaultAdapter(m MsgType, o OutType, config map[string]interface{}, msg ...interface{}) {
-	if m == DebugLog && !DebugMode {
+// DefaultAdapter of log package, writes the entries to the output of the
+// logger, stdout by default, formatted by the formatter of the logger,
+// OutputFormat for the default logger.
+func DefaultAdapter(e *Entry, config map[string]interface{}) {
+	if e.Type == DebugLog && !e.DebugEnabled() {
 		return
 	}
-
-	var debugInfo, lineBreak, output string
-
-	if DebugMode {
-		_, fn, line, _ := runtime.Caller(5)
-		fn = filepath.Base(fn)
-		debugInfo = fmt.Sprintf("%s:%d ", fn, line)
+	l := e.logger.logger()
+	if err := l.out.write(l.formatter()(e), e.Type.IsError()); err != nil {
+		atomic.AddInt32(&e.failures, 1)
+		if OutputErrorHandler != nil {
+			OutputErrorHandler(err)
+		}
 	}
+}
 
-	if o == FormattedOut {
-		output = fmt.Sprintf(msg[0].(string), msg[1:]...)
-	} else {
-		output = fmt.Sprint(msg...)
-		lineBreak = "\n"
-	}
+// ExitOnOutputError is an OutputErrorHandler that shows err on stderr and
+// exits to OS, as daemons that lost their output usually should.
+func ExitOnOutputError(err error) {
+	fmt.Fprintln(os.Stderr, "log: unable to write to stdout:", err)
+	os.Exit(1)
+}
 
-	if EnableANSIColors {
-		output = fmt.Sprintf("%s%s [%s] %s%s\033[0;00m",
-			Colors[m],
-			now().Format(TimeFormat),
-			Prefixes[m],
-			debugInfo,
-			output)
-	} else {
-		output = fmt.Sprintf("%s [%s] %s%s",
-			now().Format(TimeFormat),
-			Prefixes[m],
-			debugInfo,
-			output)
+// levelTag returns the prefix of m between brackets, padded with spaces
+// when AlignPrefixes is enabled, or the icon of m when ShowIcons is
+// enabled.
+func levelTag(m MsgType) string {
+	if ShowIcons && int(m) < len(Icons) && Icons[m] != "" {
+		return Icons[m]
 	}
-
-	if len(output) > MaxLineSize {
-		output = output[:MaxLineSize] + "..."
+	tag := "[" + Prefixes[m] + "]"
+	if !AlignPrefixes {
+		return tag
+	}
+	width := 0
+	for _, p := range Prefixes {
+		if len(p) > width {
+			width = len(p)
+		}
 	}
-	output = output + lineBreak
-	fmt.Print(output)
+	return fmt.Sprintf("%-*s", width+2, tag)
 }
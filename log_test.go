@@ -8,6 +8,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -36,6 +40,63 @@ func getOutput(logFunc func(msg ...interface{}), msg ...interface{}) ([]byte, er
 	return out, nil
 }
 
+// resetDefaults restores the package settings and adapters changed by
+// previous tests.
+func resetDefaults() {
+	now = func() time.Time { return time.Unix(1498405744, 0) }
+	DebugMode = false
+	EnableANSIColors = true
+	// the tests capture the output with pipes
+	ForceColors = true
+	MaxLineSize = DefaultMaxLineSize
+	TimeFormat = DefaultTimeFormat
+	AlignPrefixes = false
+	ShowIcons = false
+	ReportCaller = false
+	CallerSkip = 0
+	CallerFormat = ShortCaller
+	HTTPErrorBody = nil
+	HTTPErrorDetail = false
+	TimeDisplay = WallClockTime
+	OutputErrorHandler = nil
+	CaptureEnv = false
+	StrictAssertions = false
+	TraceOnError = false
+	RepanicOnRecover = false
+	RemoveEnrichers()
+	RemoveHooks()
+	resetEvents()
+	exitLock.Lock()
+	exitHandlers = nil
+	exitLock.Unlock()
+	EntryIDGenerator = nil
+	ErrorRefGenerator = nil
+	AnonymizeKey = nil
+	RedactedFields = nil
+	RedactPatterns = nil
+	RateLimit(0, 0)
+	ProfileOnErrors("", 0, 0)
+	capturing.Wait()
+	LevelHook = nil
+	OnAdapterError = nil
+	exit = os.Exit
+	SetLevel(InfoLevel)
+	SetOutput(nil)
+	SetErrorOutput(nil)
+	CallerLinks = ""
+	OutputFormat = TextFormatter
+	fallbackLock.Lock()
+	fallbackOut = os.Stderr
+	fallback.active = false
+	fallback.start = time.Time{}
+	fallbackLock.Unlock()
+	lock.Lock()
+	adapters = map[string]AdapterPod{
+		"stdout": {Adapter: DefaultAdapter},
+	}
+	lock.Unlock()
+}
+
 func validate(key string, logFunc func(msg ...interface{}), valueExpected string, msg ...interface{}) (err error) {
 	out, err := getOutput(logFunc, msg...)
 	if err != nil {
@@ -52,7 +113,7 @@ func validate(key string, logFunc func(msg ...interface{}), valueExpected string
 
 func TestLog(t *testing.T) {
 	now = func() time.Time { return time.Unix(1498405744, 0) }
-	timeFormated := now().Format("2006/01/02 15:04:05")
+	timeFormated := now().Format(DefaultTimeFormat)
 	DebugMode = false
 
 	data := []struct {
@@ -102,7 +163,7 @@ func TestLog(t *testing.T) {
 
 func TestHTTPError(t *testing.T) {
 	now = func() time.Time { return time.Unix(1498405744, 0) }
-	timeFormated := now().Format("2006/01/02 15:04:05")
+	timeFormated := now().Format(DefaultTimeFormat)
 
 	rescueStdout := os.Stdout
 	DebugMode = false
@@ -152,12 +213,66 @@ func TestHTTPError(t *testing.T) {
 
 }
 
+func TestHTTPErrorBody(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	SetOutput(ioutil.Discard)
+	EntryIDGenerator = func() string { return "id1" }
+	HTTPErrorBody = func(status int, e *Entry) interface{} {
+		if status == http.StatusNotFound {
+			return nil
+		}
+		return map[string]interface{}{"code": status, "message": "try again later", "request_id": e.ID}
+	}
+
+	w := httptest.NewRecorder()
+	HTTPError(w, http.StatusServiceUnavailable)
+	expected := "{\n\t\"code\": 503,\n\t\"message\": \"try again later\",\n\t\"request_id\": \"id1\"\n}\n"
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != expected {
+		t.Fatalf("Error, 'HTTPError' wrote %d %q, expected %q", w.Code, w.Body.String(), expected)
+	}
+
+	w = httptest.NewRecorder()
+	HTTPError(w, http.StatusNotFound)
+	expected = "{\n\t\"error\": \"Not Found\",\n\t\"status\": \"error\"\n}\n"
+	if w.Body.String() != expected {
+		t.Fatalf("Error, 'HTTPError' wrote %q, expected the default body %q", w.Body.String(), expected)
+	}
+}
+
+func TestHTTPErrorf(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	DebugMode = true
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	w := httptest.NewRecorder()
+	HTTPErrorf(w, http.StatusNotFound, "user %d", 42)
+	if !regexp.MustCompile(`^\S+ \S+ \[error\] log_test.go:\d+ Not Found: user 42\n$`).MatchString(buf.String()) {
+		t.Fatalf("Error, 'HTTPErrorf' printed %q", buf.String())
+	}
+	expected := "{\n\t\"error\": \"Not Found\",\n\t\"status\": \"error\"\n}\n"
+	if w.Code != http.StatusNotFound || w.Body.String() != expected {
+		t.Fatalf("Error, 'HTTPErrorf' wrote %d %q, expected %q", w.Code, w.Body.String(), expected)
+	}
+
+	HTTPErrorDetail = true
+	w = httptest.NewRecorder()
+	HTTPErrorf(w, http.StatusNotFound, "user %d", 42)
+	expected = "{\n\t\"detail\": \"user 42\",\n\t\"error\": \"Not Found\",\n\t\"status\": \"error\"\n}\n"
+	if w.Body.String() != expected {
+		t.Fatalf("Error, 'HTTPErrorf' wrote %q, expected %q", w.Body.String(), expected)
+	}
+}
+
 func TestMaxLineSize(t *testing.T) {
 	now = func() time.Time { return time.Unix(1498405744, 0) }
-	timeFormated := now().Format("2006/01/02 15:04:05")
+	timeFormated := now().Format(DefaultTimeFormat)
 	DebugMode = false
 
-	MaxLineSize = 30
+	MaxLineSize = 34
 	out, err := getOutput(Printf, "0123456789012345678901234567890123456789")
 	if err != nil {
 		t.Fatal(err.Error())
@@ -181,8 +296,9 @@ func TestMaxLineSize(t *testing.T) {
 
 func TestTimeFormat(t *testing.T) {
 	now = func() time.Time { return time.Unix(1498405744, 0) }
-	timeFormated := now().Format("2006/01/02 15:04:05")
+	timeFormated := now().Format(DefaultTimeFormat)
 	DebugMode = false
+	MaxLineSize = 34
 
 	out, err := getOutput(Printf, "testing a log message")
 	if err != nil {
@@ -195,6 +311,7 @@ func TestTimeFormat(t *testing.T) {
 	}
 
 	TimeFormat = "2006-01-02T15:04:05"
+	MaxLineSize = 30
 	out, err = getOutput(Printf, "testing a log message")
 	if err != nil {
 		t.Fatal(err.Error())
@@ -207,8 +324,8 @@ func TestTimeFormat(t *testing.T) {
 	}
 }
 
-func fackAdapter(m MsgType, o OutType, config map[string]interface{}, msg ...interface{}) {
-	fmt.Println(msg...)
+func fackAdapter(e *Entry, config map[string]interface{}) {
+	fmt.Println(e.Msg...)
 }
 
 func TestSetAdapterConfig(t *testing.T) {
@@ -226,3 +343,345 @@ func TestSetAdapterConfig(t *testing.T) {
 		t.Fatal("Error expected false")
 	}
 }
+
+func TestAdapterLevels(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+
+	var mu sync.Mutex
+	got := map[string][]string{}
+	record := func(name string) AdapterFunc {
+		return func(e *Entry, config map[string]interface{}) {
+			mu.Lock()
+			got[name] = append(got[name], Prefixes[e.Type])
+			mu.Unlock()
+		}
+	}
+	AddAdapter("console", AdapterPod{Adapter: record("console")})
+	AddAdapter("chat", AdapterPod{Adapter: record("chat"), MinLevel: ErrorLevel})
+	AddAdapter("audit", AdapterPod{Adapter: record("audit"), Levels: []MsgType{WarningLog, MessageLog}})
+
+	logAll := func() {
+		Println("info")
+		Warningln("warning")
+		Errorln("error")
+	}
+	logAll()
+	StartWorkers(2, 10)
+	logAll()
+	StopWorkers()
+
+	// the workers write the errors first
+	expected := map[string]string{
+		"console": "error error msg msg warning warning",
+		"chat":    "error error",
+		"audit":   "msg msg warning warning",
+	}
+	for name, e := range expected {
+		sort.Strings(got[name])
+		if s := strings.Join(got[name], " "); s != e {
+			t.Fatalf("Error, adapter %s got %q, expected %q", name, s, e)
+		}
+	}
+}
+
+func TestLegacyAdapter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+
+	var got []string
+	AddAdapter("legacy", AdapterPod{
+		Adapter: LegacyAdapter(func(m MsgType, o OutType, config map[string]interface{}, msg ...interface{}) {
+			if o == FormattedOut {
+				got = append(got, Prefixes[m]+" "+fmt.Sprintf(msg[0].(string), msg[1:]...))
+				return
+			}
+			got = append(got, Prefixes[m]+" "+fmt.Sprint(msg...))
+		}),
+	})
+	defer RemoveAdapter("legacy")
+	Println("line")
+	Warningf("formatted %d", 1)
+
+	expected := "msg line|warning formatted 1"
+	if s := strings.Join(got, "|"); s != expected {
+		t.Fatalf("Error, got %q, expected %q", s, expected)
+	}
+}
+
+func TestFatalAndPanic(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+	code := 0
+	exit = func(c int) { code = c }
+
+	var types []MsgType
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		types = append(types, e.Type)
+	}})
+
+	err := validate("Fatalln", Fatalln, "\x1b\\[31m"+timeFormated+" \\[fatal\\] log test\x1b\\[0;00m\n", "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if code != 1 {
+		t.Fatalf("Error, Fatalln exited with %d, expected 1", code)
+	}
+
+	var recovered interface{}
+	err = validate("Panicf", func(msg ...interface{}) {
+		defer func() { recovered = recover() }()
+		Panicf(msg...)
+	}, "\x1b\\[35m"+timeFormated+" \\[panic\\] log 42\x1b\\[0;00m", "log %d", 42)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if recovered != "log 42" {
+		t.Fatalf("Error, Panicf panicked with %v", recovered)
+	}
+	if len(types) != 2 || types[0] != FatalLog || types[1] != PanicLog {
+		t.Fatalf("Error, adapter received %v", types)
+	}
+}
+
+func TestAlignPrefixes(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+	AlignPrefixes = true
+
+	data := []struct {
+		key           string
+		logFunc       func(msg ...interface{})
+		expectedValue string
+	}{
+		{"Println", Println, "\x1b[37m" + timeFormated + " [msg]     log test\x1b[0;00m\n"},
+		{"Warningln", Warningln, "\x1b[93m" + timeFormated + " [warning] log test\x1b[0;00m\n"},
+		{"Errorln", Errorln, "\x1b[91m" + timeFormated + " [error]   log test\x1b[0;00m\n"},
+	}
+	for _, v := range data {
+		out, err := getOutput(v.logFunc, "log test")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(out) != v.expectedValue {
+			t.Fatalf("Error, '%s' printed %q, expected %q", v.key, string(out), v.expectedValue)
+		}
+	}
+}
+
+func TestShowIcons(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+	ShowIcons = true
+
+	data := []struct {
+		key           string
+		logFunc       func(msg ...interface{})
+		expectedValue string
+	}{
+		{"Println", Println, "\x1b[37m" + timeFormated + " \u2139 log test\x1b[0;00m\n"},
+		{"Warningln", Warningln, "\x1b[93m" + timeFormated + " \u26a0 log test\x1b[0;00m\n"},
+		{"Errorln", Errorln, "\x1b[91m" + timeFormated + " \u2716 log test\x1b[0;00m\n"},
+	}
+	for _, v := range data {
+		out, err := getOutput(v.logFunc, "log test")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(out) != v.expectedValue {
+			t.Fatalf("Error, '%s' printed %q, expected %q", v.key, string(out), v.expectedValue)
+		}
+	}
+
+	icon := Icons[WarningLog]
+	Icons[WarningLog] = ""
+	defer func() { Icons[WarningLog] = icon }()
+	EnableANSIColors = false
+	out, err := getOutput(Warningln, "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := timeFormated + " [warning] log test\n"; string(out) != expected {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expected)
+	}
+}
+
+func TestSubSecondTimeFormat(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	now = func() time.Time { return time.Unix(1498405744, 123456789) }
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{MillisecondTimeFormat, "04.123 [msg] log test"},
+		{MicrosecondTimeFormat, "04.123456 [msg] log test"},
+	}
+	for _, tc := range testCases {
+		TimeFormat = tc.format
+		err := validate(tc.format, Println, regexp.QuoteMeta(tc.expected), "log test")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+func TestOutputErrorHandler(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	rescueStdout := os.Stdout
+	defer func() { os.Stdout = rescueStdout }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_ = r.Close()
+	_ = w.Close()
+	os.Stdout = w
+
+	fallbackOut = ioutil.Discard
+	var got error
+	OutputErrorHandler = func(err error) { got = err }
+	Println("log test")
+	if got == nil {
+		t.Fatal("Error, expected a write error")
+	}
+}
+
+func TestLevelHook(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+	atomic.StoreUint64(&warningCount, 0)
+	atomic.StoreUint64(&errorCount, 0)
+	ErrorRefGenerator = func() string { return "REF1" }
+
+	LevelHook = func(e *Entry) MsgType {
+		if e.Type == ErrorLog && strings.HasPrefix(e.Message(), "noisy") {
+			return WarningLog
+		}
+		return e.Type
+	}
+
+	err := validate("Errorln", Errorln, "\x1b\\[93m"+timeFormated+" \\[warning\\] noisy dependency\x1b\\[0;00m\n", "noisy dependency")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = validate("Errorln", Errorln, "\x1b\\[91m"+timeFormated+" \\[error\\] failed \\(ref REF1\\)\x1b\\[0;00m\n", "failed")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if w, e := Counts(); w != 1 || e != 1 {
+		t.Fatalf("Error, expected 1 warning and 1 error, got %d and %d", w, e)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	out, err := getOutput(Println, "log test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(out) != 0 {
+		t.Fatalf("Error, printed %q to stdout", string(out))
+	}
+	expected := now().Format(TimeFormat) + " [msg] log test\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	var ebuf bytes.Buffer
+	SetErrorOutput(&ebuf)
+	buf.Reset()
+	Errorln("error test")
+	Warningln("warning test")
+	if ebuf.String() != now().Format(TimeFormat)+" [error] error test\n" {
+		t.Fatalf("Error, wrote %q to the error output", ebuf.String())
+	}
+	if buf.String() != now().Format(TimeFormat)+" [warning] warning test\n" {
+		t.Fatalf("Error, wrote %q to the output", buf.String())
+	}
+
+	var lbuf bytes.Buffer
+	l := New(WithOutput(&lbuf), WithANSIColors(false))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Println("log test")
+		}()
+	}
+	wg.Wait()
+	if n := strings.Count(lbuf.String(), " [msg] log test\n"); n != 10 {
+		t.Fatalf("Error, expected 10 lines, got %q", lbuf.String())
+	}
+}
+
+func TestConcurrentLogging(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	var count int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				Println("log test", i, j)
+				Debugln("debug test", i, j)
+				SetDebugMode(j%2 == 0)
+				SetMaxLineSize(DefaultMaxLineSize - j)
+				name := fmt.Sprintf("counter%d", i)
+				AddAdapter(name, AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+					atomic.AddInt32(&count, 1)
+				}})
+				RemoveAdapter(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	re := regexp.MustCompile(`^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d\.\d{3} \[(msg|debug)\] (\S+:\d+ )?(log|debug) test\d \d+$`)
+	n := 0
+	for _, l := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		m := re.FindStringSubmatch(l)
+		if m == nil {
+			t.Fatalf("Error, interleaved line %q", l)
+		}
+		if m[1] == "msg" {
+			n++
+		}
+	}
+	if n != 400 {
+		t.Fatalf("Error, %d messages, expected 400", n)
+	}
+}
+
+// resetEvents removes the registered events and their metrics
+func resetEvents() {
+	eventsLock.Lock()
+	events = make(map[string]EventSchema)
+	eventsLock.Unlock()
+	metricsLock.Lock()
+	metrics = make(map[string]*metric)
+	eventMetrics = make(map[string][]*metric)
+	metricsLock.Unlock()
+}
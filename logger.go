@@ -0,0 +1,340 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Logger is a logger with its own settings and adapters, so independent
+// loggers can coexist in one program. The package functions use the
+// default Logger, whose settings are the package variables DebugMode,
+// EnableANSIColors, MaxLineSize and TimeFormat and whose adapters are
+// registered with AddAdapter. Colors, Prefixes and the other package
+// variables are shared by all loggers, and the adapters of other packages
+// only follow the debug mode of the logger.
+type Logger struct {
+	debugMode   bool
+	ansiColors  bool
+	maxLineSize int
+	timeFormat  string
+	format      Formatter
+	level       uint32
+	out         *output
+	adapters    *map[string]AdapterPod
+	lock        *sync.RWMutex
+}
+
+// Option configures a Logger created by New
+type Option func(l *Logger)
+
+// WithDebugMode sets the debug mode of the logger, default false
+func WithDebugMode(enabled bool) Option {
+	return func(l *Logger) { l.debugMode = enabled }
+}
+
+// WithANSIColors enables ANSI colors, default true
+func WithANSIColors(enabled bool) Option {
+	return func(l *Logger) { l.ansiColors = enabled }
+}
+
+// WithMaxLineSize sets the maximum size of the lines, default
+// DefaultMaxLineSize
+func WithMaxLineSize(size int) Option {
+	return func(l *Logger) { l.maxLineSize = size }
+}
+
+// WithTimeFormat sets the format of the time, default DefaultTimeFormat
+func WithTimeFormat(format string) Option {
+	return func(l *Logger) { l.timeFormat = format }
+}
+
+// WithOutput sets the writer of the "stdout" adapter of the logger,
+// default os.Stdout
+func WithOutput(w io.Writer) Option {
+	return func(l *Logger) { l.out.w = w }
+}
+
+// WithErrorOutput sets the writer of the error, fatal and panic messages
+// of the "stdout" adapter of the logger, by default they are written to
+// the output of the logger
+func WithErrorOutput(w io.Writer) Option {
+	return func(l *Logger) { l.out.errW = w }
+}
+
+// WithAdapter adds the adapter name to the logger, by default the logger
+// has only the "stdout" adapter.
+func WithAdapter(name string, adapter AdapterPod) Option {
+	return func(l *Logger) { (*l.adapters)[name] = adapter }
+}
+
+// WithoutAdapter removes the adapter name from the logger
+func WithoutAdapter(name string) Option {
+	return func(l *Logger) { delete(*l.adapters, name) }
+}
+
+// std is the default logger, used by the package functions
+var std = &Logger{level: uint32(InfoLevel), out: &output{}, adapters: &adapters, lock: &lock}
+
+// output is the writer of DefaultAdapter and the writer of its errors,
+// the lock serializes the writes to writers that are not safe for
+// concurrent use
+type output struct {
+	sync.Mutex
+	w    io.Writer
+	errW io.Writer
+	// ttys caches if the files written are terminals
+	ttys map[*os.File]bool
+}
+
+// writer returns the writer of the messages, or of the errors if isError,
+// called holding the lock
+func (o *output) writer(isError bool) io.Writer {
+	w := o.w
+	if isError && o.errW != nil {
+		w = o.errW
+	}
+	if w == nil {
+		w = os.Stdout
+	}
+	return w
+}
+
+// terminal reports if the messages, or the errors if isError, are written
+// to a terminal. Other writers, e.g. files, pipes and buffers, are not.
+func (o *output) terminal(isError bool) bool {
+	o.Lock()
+	defer o.Unlock()
+	f, ok := o.writer(isError).(*os.File)
+	if !ok {
+		return false
+	}
+	tty, ok := o.ttys[f]
+	if !ok {
+		if o.ttys == nil {
+			o.ttys = make(map[*os.File]bool)
+		}
+		tty = isTerminal(f.Fd())
+		o.ttys[f] = tty
+	}
+	return tty
+}
+
+// write writes s to the writer, os.Stdout if none was set, or to the
+// writer of the errors if isError and one was set
+func (o *output) write(s string, isError bool) error {
+	o.Lock()
+	defer o.Unlock()
+	_, err := io.WriteString(o.writer(isError), s)
+	return err
+}
+
+// Default returns the default logger
+func Default() *Logger {
+	return std
+}
+
+// New returns a logger configured by opts
+func New(opts ...Option) *Logger {
+	a := map[string]AdapterPod{"stdout": {Adapter: DefaultAdapter}}
+	l := &Logger{
+		ansiColors:  true,
+		maxLineSize: DefaultMaxLineSize,
+		timeFormat:  DefaultTimeFormat,
+		level:       uint32(InfoLevel),
+		out:         &output{},
+		adapters:    &a,
+		lock:        &sync.RWMutex{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// logger returns l, the default logger if l is nil
+func (l *Logger) logger() *Logger {
+	if l == nil {
+		return std
+	}
+	return l
+}
+
+func (l *Logger) isDebug() bool {
+	if l.GetLevel() == DebugLevel {
+		return true
+	}
+	if l = l.logger(); l == std {
+		return GetDebugMode()
+	}
+	return l.debugMode
+}
+
+// colors reports if the messages of l, or its errors if isError, are
+// colored: the colors are enabled and written to a terminal or forced
+func (l *Logger) colors(isError bool) bool {
+	l = l.logger()
+	enabled := l.ansiColors
+	if l == std {
+		enabled = EnableANSIColors
+	}
+	if !enabled {
+		return false
+	}
+	return ForceColors || l.out.terminal(isError)
+}
+
+func (l *Logger) lineSize() int {
+	if l = l.logger(); l == std {
+		return GetMaxLineSize()
+	}
+	return l.maxLineSize
+}
+
+func (l *Logger) timeLayout() string {
+	if l = l.logger(); l == std {
+		return TimeFormat
+	}
+	return l.timeFormat
+}
+
+// AddAdapter allows to add an adapter and parameters
+func (l *Logger) AddAdapter(name string, adapter AdapterPod) {
+	l.lock.Lock()
+	(*l.adapters)[name] = adapter
+	l.lock.Unlock()
+}
+
+// SetOutput sets the writer of DefaultAdapter for l, nil restores
+// os.Stdout
+func (l *Logger) SetOutput(w io.Writer) {
+	l.out.Lock()
+	l.out.w = w
+	l.out.Unlock()
+}
+
+// SetErrorOutput sets the writer of the error, fatal and panic messages
+// of DefaultAdapter for l, nil writes them to the output of l
+func (l *Logger) SetErrorOutput(w io.Writer) {
+	l.out.Lock()
+	l.out.errW = w
+	l.out.Unlock()
+}
+
+// RemoveAdapter remove the adapter from list
+func (l *Logger) RemoveAdapter(name string) {
+	l.lock.Lock()
+	delete(*l.adapters, name)
+	l.lock.Unlock()
+}
+
+// SetAdapterConfig allows set new adapter parameters
+func (l *Logger) SetAdapterConfig(name string, config map[string]interface{}) {
+	l.lock.Lock()
+	a := (*l.adapters)[name]
+	a.Config = config
+	(*l.adapters)[name] = a
+	l.lock.Unlock()
+}
+
+// WithFields returns a FieldLogger of l that adds fields to every entry
+func (l *Logger) WithFields(fields Fields) *FieldLogger {
+	return (&FieldLogger{logger: l}).WithFields(fields)
+}
+
+// WithField returns a FieldLogger of l that adds the field key to every
+// entry
+func (l *Logger) WithField(key string, value interface{}) *FieldLogger {
+	return (&FieldLogger{logger: l}).WithField(key, value)
+}
+
+func (l *Logger) runAdapters(m MsgType, o OutType, msg ...interface{}) *Entry {
+	e := l.newEntry(nil, m, o, msg...)
+	dispatch(e)
+	return e
+}
+
+// Fatal show message with line break at the end, flushes the adapters and
+// exit to OS.
+func (l *Logger) Fatal(msg ...interface{}) {
+	l.runAdapters(ErrorLog, LineOut, msg...)
+	runExitHandlers()
+	_ = l.Flush()
+	exit(-1)
+}
+
+// Fatalln message as FatalLog with line break at the end, flushes the
+// adapters and exits to OS with status 1.
+func (l *Logger) Fatalln(msg ...interface{}) {
+	l.runAdapters(FatalLog, LineOut, msg...)
+	runExitHandlers()
+	_ = l.Flush()
+	exit(1)
+}
+
+// Fatalf message formatted as FatalLog, flushes the adapters and exits to
+// OS with status 1.
+func (l *Logger) Fatalf(msg ...interface{}) {
+	l.runAdapters(FatalLog, FormattedOut, msg...)
+	runExitHandlers()
+	_ = l.Flush()
+	exit(1)
+}
+
+// Panicln message as PanicLog with line break at the end, flushes the
+// adapters and panics with the message.
+func (l *Logger) Panicln(msg ...interface{}) {
+	e := l.runAdapters(PanicLog, LineOut, msg...)
+	_ = l.Flush()
+	panic(e.Message())
+}
+
+// Panicf message formatted as PanicLog, flushes the adapters and panics
+// with the message.
+func (l *Logger) Panicf(msg ...interface{}) {
+	e := l.runAdapters(PanicLog, FormattedOut, msg...)
+	_ = l.Flush()
+	panic(e.Message())
+}
+
+// Errorln message with line break at the end.
+func (l *Logger) Errorln(msg ...interface{}) {
+	l.runAdapters(ErrorLog, LineOut, msg...)
+}
+
+// Errorf message formatted without line break at the end.
+func (l *Logger) Errorf(msg ...interface{}) {
+	l.runAdapters(ErrorLog, FormattedOut, msg...)
+}
+
+// Warningln message with line break at the end.
+func (l *Logger) Warningln(msg ...interface{}) {
+	l.runAdapters(WarningLog, LineOut, msg...)
+}
+
+// Warningf message formatted without line break at the end.
+func (l *Logger) Warningf(msg ...interface{}) {
+	l.runAdapters(WarningLog, FormattedOut, msg...)
+}
+
+// Println message with line break at the end.
+func (l *Logger) Println(msg ...interface{}) {
+	l.runAdapters(MessageLog, LineOut, msg...)
+}
+
+// Printf message formatted without line break at the end.
+func (l *Logger) Printf(msg ...interface{}) {
+	l.runAdapters(MessageLog, FormattedOut, msg...)
+}
+
+// Debugln message with line break at the end, shown only in the debug
+// mode of the logger.
+func (l *Logger) Debugln(msg ...interface{}) {
+	l.runAdapters(DebugLog, LineOut, msg...)
+}
+
+// Debugf message formatted without line break at the end, shown only in
+// the debug mode of the logger.
+func (l *Logger) Debugf(msg ...interface{}) {
+	l.runAdapters(DebugLog, FormattedOut, msg...)
+}
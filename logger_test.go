@@ -0,0 +1,56 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format("15:04:05")
+
+	var got []*Entry
+	l := New(
+		WithDebugMode(true),
+		WithANSIColors(false),
+		WithTimeFormat("15:04:05"),
+		WithMaxLineSize(38),
+		WithAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+			got = append(got, e)
+		}}),
+	)
+
+	err := validate("Debugln", l.Debugln, timeFormated+" \\[debug\\] \\S+:\\d+ a.*\\.\\.\\.\n", "a very long message")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = validate("Println", l.WithField("k", "v").Println, timeFormated+" \\[msg\\] \\S+:\\d+ text k=v\n", "text")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(got) != 2 || got[1].Fields["k"] != "v" {
+		t.Fatalf("Error, unexpected entries %v", got)
+	}
+
+	// the default logger is not changed
+	err = validate("Debugln", Debugln, "", "hidden")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = validate("Println", Println, "\x1b\\[37m"+now().Format(TimeFormat)+" \\[msg\\] text\x1b\\[0;00m\n", "text")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(got) != 2 {
+		t.Fatal("Error, the default logger used the adapter of l")
+	}
+	if Default() != std {
+		t.Fatal("Error, unexpected default logger")
+	}
+
+	l = New(WithoutAdapter("stdout"))
+	err = validate("Println", l.Println, "", "text")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
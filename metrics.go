@@ -0,0 +1,194 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MetricKind is the kind of an EventMetric
+type MetricKind uint8
+
+// Kinds of metrics
+const (
+	// CounterMetric adds the value of Field, or 1 if Field is empty, to
+	// the counter on every event
+	CounterMetric MetricKind = iota
+	// GaugeMetric sets the gauge to the value of Field on every event
+	GaugeMetric
+)
+
+// EventMetric is a metric updated by the events it is registered for
+// with RegisterEventMetric.
+type EventMetric struct {
+	// Name of the metric, e.g. payments_failed_total
+	Name string
+	// Help is the description of the metric
+	Help string
+	Kind MetricKind
+	// Field of the event with the value of the metric, an IntField or a
+	// FloatField of the schema.
+	Field string
+	// Labels are the fields of the event used as labels of the metric
+	Labels []string
+}
+
+type metric struct {
+	EventMetric
+	values map[string]float64
+	labels map[string][]string
+}
+
+var (
+	metricsLock  = sync.Mutex{}
+	metrics      = make(map[string]*metric)
+	eventMetrics = make(map[string][]*metric)
+
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// RegisterEventMetric registers m to be updated by every event name
+// logged with Event, which gives metrics from the events without a
+// separate instrumentation. The metrics are exposed by MetricsHandler in
+// the Prometheus text format. The event must be registered and the Field
+// and the Labels of the metric must be fields of its schema.
+func RegisterEventMetric(name string, m EventMetric) error {
+	eventsLock.RLock()
+	schema, ok := events[name]
+	eventsLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("log: event %s not registered", name)
+	}
+	if m.Field != "" {
+		if t, ok := schema[m.Field]; !ok || (t != IntField && t != FloatField) {
+			return fmt.Errorf("log: metric %s: field %s of event %s is not a number", m.Name, m.Field, name)
+		}
+	} else if m.Kind == GaugeMetric {
+		return fmt.Errorf("log: metric %s: gauge without field", m.Name)
+	}
+	for _, l := range m.Labels {
+		if _, ok := schema[l]; !ok {
+			return fmt.Errorf("log: metric %s: unknown field %s of event %s", m.Name, l, name)
+		}
+	}
+
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+	r, ok := metrics[m.Name]
+	if !ok {
+		r = &metric{EventMetric: m, values: make(map[string]float64), labels: make(map[string][]string)}
+		metrics[m.Name] = r
+	} else if r.Kind != m.Kind || strings.Join(r.Labels, ",") != strings.Join(m.Labels, ",") {
+		return fmt.Errorf("log: metric %s registered with another kind or labels", m.Name)
+	}
+	eventMetrics[name] = append(eventMetrics[name], r)
+	return nil
+}
+
+// updateMetrics updates the metrics of the event name with its fields
+func updateMetrics(name string, f Fields) {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+	for _, m := range eventMetrics[name] {
+		labels := make([]string, len(m.Labels))
+		for i, l := range m.Labels {
+			labels[i] = fmt.Sprint(f[l])
+		}
+		key := strings.Join(labels, "\xff")
+		m.labels[key] = labels
+		v := 1.0
+		if m.Field != "" {
+			v = number(f[m.Field])
+		}
+		if m.Kind == GaugeMetric {
+			m.values[key] = v
+		} else {
+			m.values[key] += v
+		}
+	}
+}
+
+func number(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int8:
+		return float64(n)
+	case int16:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint8:
+		return float64(n)
+	case uint16:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// MetricsHandler returns a handler that writes the metrics registered
+// with RegisterEventMetric in the Prometheus text exposition format, to
+// be scraped by Prometheus, e.g. http.Handle("/metrics", log.MetricsHandler()).
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, metricsText())
+	})
+}
+
+// metricsText returns the metrics in the Prometheus text format
+func metricsText() string {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		m := metrics[name]
+		kind := "counter"
+		if m.Kind == GaugeMetric {
+			kind = "gauge"
+		}
+		if m.Help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, helpEscaper.Replace(m.Help))
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, kind)
+		keys := make([]string, 0, len(m.values))
+		for k := range m.values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(name)
+			if len(m.Labels) > 0 {
+				pairs := make([]string, len(m.Labels))
+				for i, l := range m.Labels {
+					pairs[i] = l + `="` + labelEscaper.Replace(m.labels[k][i]) + `"`
+				}
+				b.WriteString("{" + strings.Join(pairs, ",") + "}")
+			}
+			b.WriteString(" " + strconv.FormatFloat(m.values[k], 'g', -1, 64) + "\n")
+		}
+	}
+	return b.String()
+}
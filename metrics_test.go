@@ -0,0 +1,63 @@
+package log
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEventMetrics(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	adapters = map[string]AdapterPod{}
+
+	RegisterEvent("order_paid", EventSchema{
+		"method": StringField,
+		"amount": FloatField,
+		"queue":  IntField,
+	})
+	valid := []EventMetric{
+		{Name: "orders_paid_total", Help: "Paid orders.", Labels: []string{"method"}},
+		{Name: "orders_amount_total", Field: "amount"},
+		{Name: "orders_queue", Kind: GaugeMetric, Field: "queue"},
+	}
+	for _, m := range valid {
+		if err := RegisterEventMetric("order_paid", m); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	invalid := []EventMetric{
+		{Name: "orders_method", Field: "method"},
+		{Name: "orders_gauge", Kind: GaugeMetric},
+		{Name: "orders_user_total", Labels: []string{"user"}},
+		{Name: "orders_paid_total", Kind: GaugeMetric, Field: "queue"},
+	}
+	for _, m := range invalid {
+		if err := RegisterEventMetric("order_paid", m); err == nil {
+			t.Fatalf("Error, metric %v registered", m)
+		}
+	}
+	if err := RegisterEventMetric("order_lost", valid[0]); err == nil {
+		t.Fatal("Error, metric of unknown event registered")
+	}
+
+	_ = Event("order_paid", "method", "card", "amount", 10.5, "queue", 3)
+	_ = Event("order_paid", "method", `"pix"`, "amount", 2.0, "queue", 1)
+	_ = Event("order_paid", "method", "card", "amount", 1.25, "queue", 2)
+
+	w := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b, _ := ioutil.ReadAll(w.Body)
+	expected := `# TYPE orders_amount_total counter
+orders_amount_total 13.75
+# HELP orders_paid_total Paid orders.
+# TYPE orders_paid_total counter
+orders_paid_total{method="\"pix\""} 1
+orders_paid_total{method="card"} 2
+# TYPE orders_queue gauge
+orders_queue 2
+`
+	if string(b) != expected {
+		t.Fatalf("Error, metrics %q, expected %q", string(b), expected)
+	}
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// responseWriter records the status and the size of the response for the
+// access log of Middleware
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+	// log has the fields of the request, used by HTTPError
+	log *FieldLogger
+	// ref is the reference of the error logged by HTTPError
+	ref string
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush sends the buffered data to the client if the ResponseWriter
+// supports it
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, e.g. for websockets
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("log: the ResponseWriter doesn't support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the original ResponseWriter, for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Middleware logs the method, path, status code, response size and
+// latency of every request handled by next, see Logger.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return std.Middleware(next)
+}
+
+// Middleware logs the method, path, status code, response size and
+// latency of every request handled by next with l. The responses with
+// status 5xx are logged as errors and 4xx as warnings. The context of the
+// request carries a logger with the method and path, see FromContext.
+// HTTPError called by next logs the error with them and the access log
+// gets the reference of the error.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := now()
+		rw := &responseWriter{
+			ResponseWriter: w,
+			log:            l.WithFields(Fields{"method": r.Method, "path": r.URL.Path}),
+		}
+		next.ServeHTTP(rw, r.WithContext(rw.log.WithContext(r.Context())))
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		fields := Fields{
+			"status":  rw.status,
+			"size":    rw.size,
+			"latency": now().Sub(start).Round(time.Microsecond),
+		}
+		if rw.ref != "" {
+			fields["ref"] = rw.ref
+		}
+		rw.log.WithFields(fields).runAdapters(statusType(rw.status), LineOut,
+			fmt.Sprintf("%d %s", rw.status, http.StatusText(rw.status)))
+	})
+}
+
+// statusType returns the type of the access log of a response
+func statusType(status int) MsgType {
+	switch {
+	case status >= 500:
+		return ErrorLog
+	case status >= 400:
+		return WarningLog
+	}
+	return MessageLog
+}
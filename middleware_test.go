@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	ErrorRefGenerator = func() string { return "ref1" }
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	timeFormated := now().Format(TimeFormat)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/ctx", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Println("handler")
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		HTTPError(w, http.StatusInternalServerError)
+	})
+	h := Middleware(mux)
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/ok", timeFormated + " [msg] 200 OK latency=0s method=GET path=/ok size=5 status=200\n"},
+		{"/missing", timeFormated + " [warning] 404 Not Found latency=0s method=GET path=/missing size=19 status=404\n"},
+		{"/ctx", timeFormated + " [msg] handler method=GET path=/ctx\n" +
+			timeFormated + " [msg] 200 OK latency=0s method=GET path=/ctx size=0 status=200\n"},
+		{"/fail", timeFormated + " [error] Internal Server Error method=GET path=/fail (ref ref1)\n" +
+			timeFormated + " [error] 500 Internal Server Error latency=0s method=GET path=/fail ref=ref1 size=80 status=500 (ref ref1)\n"},
+	}
+	for _, tc := range testCases {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com"+tc.path, nil))
+		if buf.String() != tc.expected {
+			t.Fatalf("Error, %s logged %q, expected %q", tc.path, buf.String(), tc.expected)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/ok", nil))
+	if w.Body.String() != "hello" || w.Code != http.StatusOK {
+		t.Fatalf("Error, response %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Span identifies the operation of an entry logged by Begin or End, the
+// IDs are compatible with W3C Trace Context and OpenTelemetry.
+type Span struct {
+	Name     string    `json:"name"`
+	TraceID  string    `json:"trace_id"`
+	SpanID   string    `json:"span_id"`
+	ParentID string    `json:"parent_id,omitempty"`
+	Start    time.Time `json:"start"`
+	// End is zero in the entry logged by Begin
+	End time.Time `json:"end"`
+}
+
+// Operation is a unit of work logged when it begins and when it ends,
+// adapters can export the operations as trace spans.
+type Operation struct {
+	span   Span
+	logger *Logger
+	fields []field
+}
+
+// Begin logs the begin of the operation name and returns it, the
+// operation must be ended with End.
+func Begin(name string) *Operation {
+	return std.begin(nil, nil, name)
+}
+
+// Begin works like log.Begin logging to the adapters of l
+func (l *Logger) Begin(name string) *Operation {
+	return l.begin(nil, nil, name)
+}
+
+// Begin works like log.Begin adding the fields of l to the entries of
+// the operation
+func (l *FieldLogger) Begin(name string) *Operation {
+	return l.logger.begin(nil, l.fields, name)
+}
+
+// Begin logs the begin of the operation name, a child of o in the same
+// trace.
+func (o *Operation) Begin(name string) *Operation {
+	return o.logger.begin(o, o.fields, name)
+}
+
+func (l *Logger) begin(parent *Operation, fields []field, name string) *Operation {
+	o := &Operation{
+		logger: l,
+		fields: fields,
+		span:   Span{Name: name, SpanID: randomHex(8)},
+	}
+	if parent != nil {
+		o.span.TraceID = parent.span.TraceID
+		o.span.ParentID = parent.span.SpanID
+	} else {
+		o.span.TraceID = randomHex(16)
+	}
+	e := l.newEntry(fields, MessageLog, LineOut, "begin ", name)
+	o.span.Start = e.Time
+	span := o.span
+	e.Span = &span
+	dispatch(e)
+	return o
+}
+
+// End logs the end of the operation with its duration, as an error if
+// err is not nil.
+func (o *Operation) End(err error) {
+	o.end(err)
+}
+
+func (o *Operation) end(err error) {
+	d := now().Sub(o.span.Start).Round(time.Microsecond)
+	var e *Entry
+	if err != nil {
+		e = o.logger.newEntry(o.fields, ErrorLog, LineOut, fmt.Sprintf("%s failed after %v: ", o.span.Name, d), err)
+	} else {
+		e = o.logger.newEntry(o.fields, MessageLog, LineOut, fmt.Sprintf("end %s after %v", o.span.Name, d))
+	}
+	span := o.span
+	span.End = e.Time
+	e.Span = &span
+	dispatch(e)
+}
+
+// TraceID returns the trace of the operation
+func (o *Operation) TraceID() string {
+	return o.span.TraceID
+}
+
+// SpanID returns the ID of the operation in its trace
+func (o *Operation) SpanID() string {
+	return o.span.SpanID
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	randomBytes(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestOperation(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	var op *Operation
+	out, err := getOutput(func(msg ...interface{}) {
+		op = WithField("order", 7).Begin("checkout")
+		op.Begin("charge").End(errors.New("card declined"))
+		op.End(nil)
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ts := now().Format(TimeFormat)
+	expected := "^" + ts + ` \[msg\] begin checkout order=7
+` + ts + ` \[msg\] begin charge order=7
+` + ts + ` \[error\] charge failed after [0-9.]+[µm]?s: card declined order=7
+` + ts + ` \[msg\] end checkout after [0-9.]+[µm]?s order=7
+$`
+	if !regexp.MustCompile(expected).Match(out) {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expected)
+	}
+
+	if len(entries) != 4 {
+		t.Fatalf("Error, expected 4 entries, got %d", len(entries))
+	}
+	checkout, charge := entries[3].Span, entries[2].Span
+	if checkout.TraceID != op.TraceID() || checkout.SpanID != op.SpanID() || checkout.ParentID != "" ||
+		len(checkout.TraceID) != 32 || len(checkout.SpanID) != 16 {
+		t.Fatalf("Error, unexpected span %+v", checkout)
+	}
+	if charge.TraceID != checkout.TraceID || charge.ParentID != checkout.SpanID || charge.SpanID == checkout.SpanID {
+		t.Fatalf("Error, unexpected child span %+v", charge)
+	}
+	if !entries[0].Span.End.IsZero() || checkout.End.IsZero() || checkout.End.Before(checkout.Start) {
+		t.Fatalf("Error, unexpected times begin %+v end %+v", entries[0].Span, checkout)
+	}
+	if entries[3].Caller == "" || entries[1].Caller != entries[2].Caller {
+		t.Fatalf("Error, unexpected callers %q %q %q", entries[1].Caller, entries[2].Caller, entries[3].Caller)
+	}
+
+	b, err := json.Marshal(entries[2])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var d Entry
+	if err = json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Span == nil || d.Span.SpanID != charge.SpanID || !d.Span.End.Equal(charge.End) {
+		t.Fatalf("Error, decoded span %+v, expected %+v", d.Span, charge)
+	}
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// MaxStackFrames limits the frames of the stacks attached to entries
+var MaxStackFrames = 20
+
+// Frame is a function call of a stack trace
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// Panicked logs a value returned by recover as an error with its type and
+// the stack of the panic, trimmed of the runtime frames. stack is the
+// output of debug.Stack, if nil it is taken by Panicked, which must then
+// be called by the deferred function:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			log.Panicked(r, nil)
+//		}
+//	}()
+func Panicked(recovered interface{}, stack []byte) {
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	e := std.panicEntry(nil, recovered, stack)
+	e.Caller, e.callerFile = caller(1)
+	dispatch(e)
+}
+
+// panicEntry returns the entry of Panicked
+func (l *Logger) panicEntry(fields []field, recovered interface{}, stack []byte) *Entry {
+	var e *Entry
+	switch v := recovered.(type) {
+	case error:
+		e = l.newEntry(fields, ErrorLog, FormattedOut, "panic: %v (%T)", v, v)
+	case string:
+		e = l.newEntry(fields, ErrorLog, FormattedOut, "panic: %s", v)
+	default:
+		e = l.newEntry(fields, ErrorLog, FormattedOut, "panic: %#v (%T)", v, v)
+	}
+	if e.Fields == nil {
+		e.Fields = make(Fields)
+	}
+	e.Fields["panic.type"] = fmt.Sprintf("%T", recovered)
+	e.Stack = trimStack(parseStack(stack))
+	return e
+}
+
+// parseStack parses the output of debug.Stack
+func parseStack(stack []byte) []Frame {
+	var frames []Frame
+	lines := strings.Split(string(stack), "\n")
+	for i := 0; i < len(lines)-1; i++ {
+		fn := lines[i]
+		if fn == "" || strings.HasPrefix(fn, "\t") || strings.HasPrefix(fn, "goroutine ") {
+			continue
+		}
+		loc := lines[i+1]
+		if !strings.HasPrefix(loc, "\t") {
+			continue
+		}
+		i++
+		loc = strings.TrimSpace(loc)
+		if j := strings.LastIndex(loc, " +0x"); j >= 0 {
+			loc = loc[:j]
+		}
+		f := Frame{Function: fn, File: loc}
+		if j := strings.LastIndexByte(loc, ':'); j >= 0 {
+			f.Line, _ = strconv.Atoi(loc[j+1:])
+			f.File = loc[:j]
+		}
+		if j := strings.LastIndexByte(fn, '('); j > 0 && !strings.HasPrefix(fn, "created by ") {
+			f.Function = fn[:j]
+		}
+		frames = append(frames, f)
+	}
+	return frames
+}
+
+// trimStack removes the frames of debug.Stack and of the panic machinery
+// and limits the stack to MaxStackFrames.
+func trimStack(frames []Frame) []Frame {
+	for i, f := range frames {
+		if f.Function == "panic" {
+			frames = frames[i+1:]
+			break
+		}
+	}
+	for len(frames) > 0 && strings.HasPrefix(frames[0].Function, "runtime/debug.") {
+		frames = frames[1:]
+	}
+	if MaxStackFrames > 0 && len(frames) > MaxStackFrames {
+		frames = frames[:MaxStackFrames]
+	}
+	return frames
+}
+
+// stackLines returns the frames as indented lines for the console
+func stackLines(frames []Frame) []string {
+	lines := make([]string, len(frames))
+	for i, f := range frames {
+		lines[i] = "    at " + f.String()
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPanicked(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+
+	panicking := func(v interface{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				Panicked(r, nil)
+			}
+		}()
+		panic(v)
+	}
+
+	testCases := []struct {
+		value    interface{}
+		expected string
+		typ      string
+	}{
+		{errors.New("boom"), "panic: boom (*errors.errorString)", "*errors.errorString"},
+		{"oops", "panic: oops", "string"},
+		{[]int{1, 2}, "panic: []int{1, 2} ([]int)", "[]int"},
+	}
+	for _, tc := range testCases {
+		out, err := getOutput(func(msg ...interface{}) { panicking(tc.value) })
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !strings.Contains(string(out), tc.expected+"\n    at ") {
+			t.Fatalf("Error, printed %q, expected %q and the stack", string(out), tc.expected)
+		}
+	}
+
+	for i, e := range entries {
+		if e.Type != ErrorLog || e.Fields["panic.type"] != testCases[i].typ {
+			t.Fatalf("Error, unexpected entry %+v", e)
+		}
+		if len(e.Stack) == 0 {
+			t.Fatal("Error, expected stack")
+		}
+		if !strings.HasSuffix(e.Stack[0].Function, "TestPanicked.func2") {
+			t.Fatalf("Error, expected the function that panicked first, got %v", e.Stack[0])
+		}
+		if !regexp.MustCompile(`panic_test\.go$`).MatchString(e.Stack[0].File) || e.Stack[0].Line == 0 {
+			t.Fatalf("Error, unexpected frame %v", e.Stack[0])
+		}
+	}
+}
+
+func TestParseStack(t *testing.T) {
+	stack := "goroutine 1 [running]:\n" +
+		"runtime/debug.Stack()\n" +
+		"\t/usr/local/go/src/runtime/debug/stack.go:26 +0x5e\n" +
+		"main.main.func1()\n" +
+		"\t/app/main.go:10 +0x25\n" +
+		"panic({0x4a0e60?, 0x4e8f30?})\n" +
+		"\t/usr/local/go/src/runtime/panic.go:770 +0x132\n" +
+		"main.main()\n" +
+		"\t/app/main.go:14 +0x45\n"
+	frames := trimStack(parseStack([]byte(stack)))
+	if len(frames) != 1 || frames[0] != (Frame{Function: "main.main", File: "/app/main.go", Line: 14}) {
+		t.Fatalf("Error, unexpected frames %v", frames)
+	}
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// JSONKeyColor is the color of the keys of the payloads of JSON and YAML
+// and of the tags of the payloads of XML in TextFormatter when the colors
+// are enabled
+var JSONKeyColor = "\x1b[36m"
+
+type payloadKind int
+
+const (
+	jsonPayload payloadKind = iota
+	yamlPayload
+	xmlPayload
+)
+
+// payload of an entry logged by JSON, YAML or XML
+type payload struct {
+	label string
+	kind  payloadKind
+	// data is the value encoded as compact JSON, as XML for xmlPayload
+	data []byte
+}
+
+// JSON logs v encoded as JSON with the label as a message of type m.
+// TextFormatter writes it indented on the lines after the label, with
+// the keys colored by JSONKeyColor, and JSONFormatter embeds it as the
+// "payload" object, not as an escaped string. The other formatters and
+// the adapters get the label followed by the compact JSON as message. A
+// value that can't be encoded is logged with the error instead.
+func JSON(m MsgType, label string, v interface{}) {
+	std.payload(nil, m, label, jsonPayload, v)
+}
+
+// JSON works like log.JSON logging to the adapters of l
+func (l *Logger) JSON(m MsgType, label string, v interface{}) {
+	l.payload(nil, m, label, jsonPayload, v)
+}
+
+// JSON works like log.JSON adding the fields of l
+func (l *FieldLogger) JSON(m MsgType, label string, v interface{}) {
+	l.logger.payload(l.fields, m, label, jsonPayload, v)
+}
+
+func (l *Logger) payload(fields []field, m MsgType, label string, kind payloadKind, v interface{}) {
+	b, err := encodePayload(kind, v)
+	if err != nil {
+		dispatch(l.newEntry(fields, m, LineOut, label, ": ", err))
+		return
+	}
+	if redacting() {
+		b = redactPayload(kind, b)
+	}
+	e := l.newEntry(fields, m, LineOut, label, " ", string(b))
+	e.payload = &payload{label: label, kind: kind, data: b}
+	dispatch(e)
+}
+
+func encodePayload(kind payloadKind, v interface{}) ([]byte, error) {
+	if kind == xmlPayload {
+		return xml.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// embedded returns the payload of e as the "payload" of JSONFormatter
+func (p *payload) embedded() json.RawMessage {
+	if p.kind == xmlPayload {
+		b, _ := json.Marshal(string(p.data))
+		return b
+	}
+	return p.data
+}
+
+// payloadLines returns the lines of the payload of e indented, with the
+// keys colored if colored is set
+func (e *Entry) payloadLines(colored bool) []string {
+	key := func(s string) string { return s }
+	if colored {
+		key = func(s string) string { return JSONKeyColor + s + e.color() }
+	}
+	var s string
+	switch e.payload.kind {
+	case yamlPayload:
+		s = yamlText(e.payload.data, key)
+	case xmlPayload:
+		s = xmlText(e.payload.data, key)
+	default:
+		s = jsonText(e.payload.data, key)
+	}
+	return strings.Split(s, "\n")
+}
+
+// jsonText returns the JSON b indented with the keys of the objects passed
+// through key
+func jsonText(b []byte, key func(string) string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return string(b)
+	}
+	s := buf.String()
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '"' {
+			out.WriteByte(s[i])
+			continue
+		}
+		end := i + 1
+		for ; end < len(s) && s[end] != '"'; end++ {
+			if s[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(s) {
+			out.WriteString(s[i:])
+			break
+		}
+		str := s[i : end+1]
+		if strings.HasPrefix(strings.TrimLeft(s[end+1:], " "), ":") {
+			str = key(str)
+		}
+		out.WriteString(str)
+		i = end
+	}
+	return out.String()
+}
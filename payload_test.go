@@ -0,0 +1,60 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetMaxLineSize(20)
+
+	v := map[string]interface{}{"user": "ann", "roles": []string{"admin"}}
+	WithField("req", 7).JSON(MessageLog, "request", v)
+
+	ts := now().Format(TimeFormat)
+	expected := ts + " [msg] request req=7\n" +
+		ts + " [msg] {\n" +
+		ts + ` [msg]   "roles": [` + "\n" +
+		ts + ` [msg]     "admin"` + "\n" +
+		ts + " [msg]   ],\n" +
+		ts + ` [msg]   "user": "ann"` + "\n" +
+		ts + " [msg] }\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	OutputFormat = JSONFormatter
+	JSON(MessageLog, "request", v)
+	var line struct {
+		Message string
+		Payload map[string]interface{}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err.Error())
+	}
+	if line.Message != "request" || line.Payload["user"] != "ann" {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+
+	buf.Reset()
+	JSON(ErrorLog, "bad", make(chan int))
+	if !strings.Contains(buf.String(), `"message":"bad: json: unsupported type: chan int"`) {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+}
+
+func TestJSONKeys(t *testing.T) {
+	s := jsonText([]byte(`{"a\"":"b:","c":{"d":1}}`), func(s string) string { return "<" + s + ">" })
+	expected := "{\n  <\"a\\\"\">: \"b:\",\n  <\"c\">: {\n    <\"d\">: 1\n  }\n}"
+	if s != expected {
+		t.Fatalf("Error, got %q, expected %q", s, expected)
+	}
+}
@@ -0,0 +1,26 @@
+// Package winservice configures the log package for programs running as
+// Windows services: no ANSI colors, no console output, warnings and
+// errors sent to the Windows Event Log and every message written to a
+// file rotated daily.
+package winservice
+
+import (
+	"os"
+
+	"github.com/nuveo/log"
+)
+
+// Started logs that the service name started, with the process id.
+func Started(name string) {
+	log.Printf("service %s started, pid %d\n", name, os.Getpid())
+}
+
+// Stopped logs that the service name stopped, err is the reason of the
+// stop, if any.
+func Stopped(name string, err error) {
+	if err != nil {
+		log.Errorf("service %s stopped: %v\n", name, err)
+		return
+	}
+	log.Printf("service %s stopped\n", name)
+}
@@ -0,0 +1,30 @@
+package winservice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nuveo/log"
+)
+
+func TestLifecycle(t *testing.T) {
+	var entries []*log.Entry
+	log.RemoveAdapter("stdout")
+	log.AddAdapter("capture", log.AdapterPod{
+		Adapter: func(e *log.Entry, config map[string]interface{}) {
+			entries = append(entries, e)
+		},
+	})
+	defer log.RemoveAdapter("capture")
+
+	Started("svc")
+	Stopped("svc", nil)
+	Stopped("svc", errors.New("boom"))
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, but got %d", len(entries))
+	}
+	if entries[2].Type != log.ErrorLog || entries[2].Message() != "service svc stopped: boom\n" {
+		t.Errorf("unexpected entry %q", entries[2].Message())
+	}
+}
@@ -0,0 +1,24 @@
+package winservice
+
+import (
+	"path/filepath"
+
+	"github.com/nuveo/log"
+	_ "github.com/nuveo/log/adapters/eventlog"
+	_ "github.com/nuveo/log/adapters/file"
+)
+
+// Setup configures the log package for the service source: colors and
+// the stdout adapter are disabled, warnings and errors go to the Event
+// Log under source and all messages to dir\source-YYYY-MM-DD.log.
+func Setup(source, dir string) {
+	log.EnableANSIColors = false
+	log.RemoveAdapter("stdout")
+	log.SetAdapterConfig("eventlog", map[string]interface{}{
+		"source":         source,
+		"enableMsgTypes": []log.MsgType{log.WarningLog, log.ErrorLog, log.FatalLog, log.PanicLog},
+	})
+	log.SetAdapterConfig("file", map[string]interface{}{
+		"fileName": filepath.Join(dir, source+"-%Y-%m-%d.log"),
+	})
+}
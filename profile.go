@@ -0,0 +1,146 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+var (
+	// ProfileCPUDuration is the duration of the CPU profiles captured by
+	// ProfileOnErrors, no CPU profile is captured if it is zero
+	ProfileCPUDuration = 10 * time.Second
+
+	// ProfileCooldown is the minimum time between the captures of
+	// ProfileOnErrors, so an incident doesn't fill the directory
+	ProfileCooldown = 10 * time.Minute
+)
+
+// errorStorm is the rule of ProfileOnErrors
+type errorStorm struct {
+	dir    string
+	window time.Duration
+	// times of the last errors, next is the oldest
+	times     []time.Time
+	next      int
+	capturing bool
+	last      time.Time
+}
+
+var (
+	storm     *errorStorm
+	stormLock = sync.Mutex{}
+	// capturing is done when the capture running ends
+	capturing = sync.WaitGroup{}
+)
+
+// ProfileOnErrors captures a heap profile and a CPU profile of
+// ProfileCPUDuration to dir, created if needed, when threshold errors,
+// fatal or panic messages are logged within window, automating what the
+// operators do during an incident. A warning with the paths of the
+// profiles in the "heap_profile" and "cpu_profile" fields is logged when
+// they are written, read them with go tool pprof. The profiles are
+// captured at most once in ProfileCooldown. A threshold or a window of
+// zero disables it, the default.
+func ProfileOnErrors(dir string, threshold int, window time.Duration) {
+	stormLock.Lock()
+	defer stormLock.Unlock()
+	if threshold <= 0 || window <= 0 {
+		storm = nil
+		return
+	}
+	storm = &errorStorm{dir: dir, window: window, times: make([]time.Time, threshold)}
+}
+
+// countError checks the rule of ProfileOnErrors for an error logged now
+func countError() {
+	stormLock.Lock()
+	s := storm
+	if s == nil {
+		stormLock.Unlock()
+		return
+	}
+	t := time.Now()
+	oldest := s.times[s.next]
+	s.times[s.next] = t
+	s.next = (s.next + 1) % len(s.times)
+	if oldest.IsZero() || t.Sub(oldest) > s.window || s.capturing ||
+		(!s.last.IsZero() && t.Sub(s.last) < ProfileCooldown) {
+		stormLock.Unlock()
+		return
+	}
+	s.capturing = true
+	s.last = t
+	capturing.Add(1)
+	stormLock.Unlock()
+	go func() {
+		defer capturing.Done()
+		s.capture(len(s.times), t.Sub(oldest))
+		stormLock.Lock()
+		s.capturing = false
+		stormLock.Unlock()
+	}()
+}
+
+// capture writes the profiles of the storm of n errors logged in elapsed
+func (s *errorStorm) capture(n int, elapsed time.Duration) {
+	fields := Fields{
+		"errors":  n,
+		"elapsed": elapsed.Round(time.Millisecond),
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		WithFields(fields).runAdapters(WarningLog, LineOut, "error storm, profiles not captured: ", err)
+		return
+	}
+	prefix := filepath.Join(s.dir, fmt.Sprintf("%s-%d-%s",
+		filepath.Base(os.Args[0]), os.Getpid(), time.Now().Format("20060102T150405")))
+
+	heap := prefix + "-heap.pprof"
+	runtime.GC()
+	if err := writeProfile(heap, func(f *os.File) error {
+		return pprof.Lookup("heap").WriteTo(f, 0)
+	}); err != nil {
+		WithFields(fields).runAdapters(WarningLog, LineOut, "error storm, heap profile not captured: ", err)
+	} else {
+		fields["heap_profile"] = heap
+	}
+	if d := ProfileCPUDuration; d > 0 {
+		cpu := prefix + "-cpu.pprof"
+		if err := writeProfile(cpu, func(f *os.File) error {
+			if err := pprof.StartCPUProfile(f); err != nil {
+				return err
+			}
+			time.Sleep(d)
+			pprof.StopCPUProfile()
+			return nil
+		}); err != nil {
+			WithFields(fields).runAdapters(WarningLog, LineOut, "error storm, CPU profile not captured: ", err)
+		} else {
+			fields["cpu_profile"] = cpu
+		}
+	}
+	if fields["heap_profile"] != nil || fields["cpu_profile"] != nil {
+		WithFields(fields).runAdapters(WarningLog, LineOut, "error storm, profiles captured")
+	}
+}
+
+// writeProfile creates the file name and writes a profile to it with
+// write, the file is removed if it fails
+func writeProfile(name string, write func(f *os.File) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(name)
+	}
+	return err
+}
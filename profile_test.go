@@ -0,0 +1,87 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProfileOnErrors(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	defer func(d, c time.Duration) {
+		ProfileCPUDuration, ProfileCooldown = d, c
+	}(ProfileCPUDuration, ProfileCooldown)
+	ProfileCPUDuration = 50 * time.Millisecond
+
+	var (
+		mu      sync.Mutex
+		entries []*Entry
+	)
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		mu.Lock()
+		entries = append(entries, e)
+		mu.Unlock()
+	}})
+	RemoveAdapter("stdout")
+	dir := filepath.Join(t.TempDir(), "profiles")
+	ProfileOnErrors(dir, 3, time.Hour)
+
+	Errorln("one")
+	Println("not an error")
+	Errorln("two")
+	capturing.Wait()
+	if _, err := os.Stat(dir); err == nil {
+		t.Fatal("Error, profiles captured before the threshold")
+	}
+	Errorln("three")
+	capturing.Wait()
+	// in cooldown
+	Errorln("four")
+	capturing.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(entries) != 6 {
+		t.Fatalf("Error, expected 6 entries, got %d", len(entries))
+	}
+	var e *Entry
+	for _, x := range entries {
+		if x.Type == WarningLog {
+			e = x
+		}
+	}
+	if e == nil {
+		t.Fatal("Error, profiles not logged")
+	}
+	if e.Type != WarningLog || e.Message() != "error storm, profiles captured" || e.Fields["errors"] != 3 {
+		t.Fatalf("Error, logged %v %q %v", e.Type, e.Message(), e.Fields)
+	}
+	for _, k := range []string{"heap_profile", "cpu_profile"} {
+		name, _ := e.Fields[k].(string)
+		if !strings.HasPrefix(name, dir) || !strings.HasSuffix(name, ".pprof") {
+			t.Fatalf("Error, %s %q", k, name)
+		}
+		if fi, err := os.Stat(name); err != nil || fi.Size() == 0 {
+			t.Fatalf("Error, %s not written: %v", k, err)
+		}
+	}
+}
+
+func TestProfileOnErrorsWindow(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	dir := filepath.Join(t.TempDir(), "profiles")
+	ProfileOnErrors(dir, 2, time.Millisecond)
+	RemoveAdapter("stdout")
+	Errorln("one")
+	time.Sleep(10 * time.Millisecond)
+	Errorln("two")
+	capturing.Wait()
+	if _, err := os.Stat(dir); err == nil {
+		t.Fatal("Error, profiles captured for errors out of the window")
+	}
+}
@@ -0,0 +1,170 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateKeys bounds the messages tracked by the rate limit, once reached
+// the new messages are not limited until a window ends
+const maxRateKeys = 10000
+
+// repeat counts the entries of a message in a window of the rate limit
+type repeat struct {
+	start      time.Time
+	count      int
+	suppressed int
+	last       *Entry
+	ended      bool
+}
+
+var (
+	rateWindow time.Duration
+	rateBurst  int
+	repeats    map[string]*repeat
+	rateLock   = sync.Mutex{}
+)
+
+// RateLimit writes at most burst entries of each message in every window,
+// protecting the output and the adapters from the storms of errors logged
+// in tight loops. The entries of a message are the ones of the same type
+// and caller with the same format, for Printf and the like, or the same
+// text. The others are dropped and, when the window ends or by Flush, a
+// single entry like the last one dropped is written instead, e.g.
+//
+//	message repeated 1523 times in the last 10s: connection refused
+//
+// with the "repeated" count field. The dropped entries are counted by
+// Counts and don't run the hooks again. A window or a burst of zero
+// disables the limit, the default.
+func RateLimit(window time.Duration, burst int) {
+	flushRepeated()
+	rateLock.Lock()
+	defer rateLock.Unlock()
+	if window <= 0 || burst <= 0 {
+		rateWindow, rateBurst, repeats = 0, 0, nil
+		return
+	}
+	rateWindow, rateBurst = window, burst
+	repeats = make(map[string]*repeat)
+}
+
+// rateKey identifies the message of e
+func rateKey(e *Entry) string {
+	msg := ""
+	if f, ok := firstString(e.Msg); ok && e.Out == FormattedOut {
+		msg = f
+	} else {
+		msg = e.Message()
+	}
+	return fmt.Sprint(int(e.Type), " ", e.Caller, " ", msg)
+}
+
+func firstString(msg []interface{}) (string, bool) {
+	if len(msg) == 0 {
+		return "", false
+	}
+	s, ok := msg[0].(string)
+	return s, ok
+}
+
+// limited reports if e exceeds the burst of its message and must be
+// dropped
+func limited(e *Entry) bool {
+	rateLock.Lock()
+	if repeats == nil {
+		rateLock.Unlock()
+		return false
+	}
+	key := rateKey(e)
+	t := time.Now()
+	var ended *repeat
+	r, ok := repeats[key]
+	if ok && t.Sub(r.start) >= rateWindow {
+		// the timer didn't run yet
+		ended = endRepeat(key, r)
+		ok = false
+	}
+	if !ok {
+		if len(repeats) >= maxRateKeys {
+			rateLock.Unlock()
+			writeRepeated(ended)
+			return false
+		}
+		r = &repeat{start: t}
+		repeats[key] = r
+		time.AfterFunc(rateWindow, func() {
+			rateLock.Lock()
+			s := endRepeat(key, r)
+			rateLock.Unlock()
+			writeRepeated(s)
+		})
+	}
+	r.count++
+	drop := r.count > rateBurst
+	if drop {
+		r.suppressed++
+		r.last = e
+	}
+	rateLock.Unlock()
+	writeRepeated(ended)
+	return drop
+}
+
+// endRepeat ends the window of r, it returns r if entries were dropped.
+// rateLock must be held.
+func endRepeat(key string, r *repeat) *repeat {
+	if r.ended {
+		return nil
+	}
+	r.ended = true
+	if repeats[key] == r {
+		delete(repeats, key)
+	}
+	if r.suppressed == 0 {
+		return nil
+	}
+	return r
+}
+
+// writeRepeated writes the entry of the dropped entries of r
+func writeRepeated(r *repeat) {
+	if r == nil {
+		return
+	}
+	last := r.last
+	fields := make([]field, 0, len(last.Keys)+1)
+	for _, k := range last.Keys {
+		fields = append(fields, field{key: k, value: last.Fields[k]})
+	}
+	fields = append(fields, field{key: "repeated", value: r.suppressed})
+	elapsed := time.Since(r.start)
+	if elapsed >= time.Second {
+		elapsed = elapsed.Round(time.Second)
+	} else {
+		elapsed = elapsed.Round(time.Millisecond)
+	}
+	e := last.logger.logger().newEntry(fields, last.Type, LineOut,
+		fmt.Sprintf("message repeated %d times in the last %v: %s", r.suppressed, elapsed, strings.TrimSuffix(last.Message(), "\n")))
+	e.Caller, e.callerFile = last.Caller, last.callerFile
+	txLock.RLock()
+	send(e)
+	txLock.RUnlock()
+}
+
+// flushRepeated ends the windows with dropped entries, writing them
+func flushRepeated() {
+	rateLock.Lock()
+	var list []*repeat
+	for key, r := range repeats {
+		if s := endRepeat(key, r); s != nil {
+			list = append(list, s)
+		}
+	}
+	rateLock.Unlock()
+	for _, r := range list {
+		writeRepeated(r)
+	}
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	var b bytes.Buffer
+	SetOutput(&b)
+	RateLimit(time.Hour, 3)
+
+	for i := 0; i < 10; i++ {
+		Errorf("request %d failed\n", i)
+		WithField("host", "db").Errorln("connection refused")
+	}
+	Println("other")
+	out := b.String()
+	if n := strings.Count(out, "failed"); n != 3 {
+		t.Fatalf("Error, %d formatted entries written, expected 3:\n%s", n, out)
+	}
+	if n := strings.Count(out, "connection refused"); n != 3 {
+		t.Fatalf("Error, %d entries written, expected 3:\n%s", n, out)
+	}
+	if !strings.Contains(out, "other") {
+		t.Fatalf("Error, other message dropped:\n%s", out)
+	}
+	if _, errs := Counts(); errs < 20 {
+		t.Fatalf("Error, %d errors counted", errs)
+	}
+
+	b.Reset()
+	_ = Flush()
+	out = b.String()
+	if !strings.Contains(out, "message repeated 7 times in the last ") ||
+		!strings.Contains(out, ": request 9 failed") {
+		t.Fatalf("Error, formatted repeat not written:\n%s", out)
+	}
+	if !strings.Contains(out, ": connection refused host=db repeated=7") {
+		t.Fatalf("Error, repeat not written:\n%s", out)
+	}
+	b.Reset()
+	_ = Flush()
+	if b.Len() != 0 {
+		t.Fatalf("Error, repeat written twice:\n%s", b.String())
+	}
+
+	// the window ends with its timer
+	RateLimit(20*time.Millisecond, 1)
+	for i := 0; i < 5; i++ {
+		Warningln("busy")
+	}
+	time.Sleep(100 * time.Millisecond)
+	Warningln("busy")
+	out = b.String()
+	if n := strings.Count(out, "busy"); n != 3 || !strings.Contains(out, "message repeated 4 times") {
+		t.Fatalf("Error, window not ended:\n%s", out)
+	}
+
+	b.Reset()
+	RateLimit(0, 0)
+	for i := 0; i < 5; i++ {
+		Warningln("free")
+	}
+	if n := strings.Count(b.String(), "free"); n != 5 {
+		t.Fatalf("Error, %d entries written without limit", n)
+	}
+}
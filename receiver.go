@@ -0,0 +1,140 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// Receiver accepts entries sent by other local processes through a unix
+// socket and logs them through the adapters of this process.
+type Receiver struct {
+	listener net.Listener
+	path     string
+	// socket is the file of the socket, not removed by Close if replaced
+	socket os.FileInfo
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+}
+
+// ListenUnix creates the unix socket path and starts receiving entries,
+// one JSON encoded entry (see Entry.MarshalJSON) per line, as sent by the
+// adapters/unix adapter. On Linux only processes of the same user, or
+// root, are accepted (SO_PEERCRED). The socket is created with the 0600
+// permissions in a private directory and then moved to path, so the
+// other users can't connect meanwhile. A socket left at path by a
+// previous run is replaced, any other file is an error. The received
+// entries keep their time but get a new sequence number. Be careful to
+// not register adapters that send entries back to the same socket.
+func ListenUnix(path string) (*Receiver, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("log: %s exists and is not a socket", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".log-sock-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "s")
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// the socket is removed by Close at path, not at tmp
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	var socket os.FileInfo
+	err = os.Chmod(tmp, 0600)
+	if err == nil {
+		socket, err = os.Lstat(tmp)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	r := &Receiver{
+		listener: l,
+		path:     path,
+		socket:   socket,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	r.wg.Add(1)
+	go r.accept()
+	return r, nil
+}
+
+// Addr returns the address of the socket
+func (r *Receiver) Addr() net.Addr {
+	return &net.UnixAddr{Name: r.path, Net: "unix"}
+}
+
+// Close stops receiving entries, removes the socket and waits for the
+// connections to finish.
+func (r *Receiver) Close() error {
+	err := r.listener.Close()
+	if fi, e := os.Lstat(r.path); e == nil && os.SameFile(fi, r.socket) {
+		_ = os.Remove(r.path)
+	}
+	r.mu.Lock()
+	for c := range r.conns {
+		_ = c.Close()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+	return err
+}
+
+func (r *Receiver) accept() {
+	defer r.wg.Done()
+	for {
+		c, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		if !peerAllowed(c) {
+			Warningln("log: unix socket connection refused, peer of a different user")
+			_ = c.Close()
+			continue
+		}
+		r.mu.Lock()
+		r.conns[c] = struct{}{}
+		r.mu.Unlock()
+		r.wg.Add(1)
+		go r.receive(c)
+	}
+}
+
+func (r *Receiver) receive(c net.Conn) {
+	defer r.wg.Done()
+	defer func() {
+		r.mu.Lock()
+		delete(r.conns, c)
+		r.mu.Unlock()
+		_ = c.Close()
+	}()
+	scanner := bufio.NewScanner(c)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		e := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			Warningln("log: invalid entry received:", err)
+			continue
+		}
+		e.Seq = atomic.AddUint64(&seq, 1)
+		dispatch(e)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package log
+
+import "net"
+
+// peerAllowed relies on the socket file permissions where SO_PEERCRED is
+// not available.
+func peerAllowed(c net.Conn) bool {
+	return true
+}
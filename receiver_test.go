@@ -0,0 +1,105 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenUnix(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	received := make(chan *Entry, 1)
+	RemoveAdapter("stdout")
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			received <- e
+		},
+	})
+
+	r, err := ListenUnix(filepath.Join(t.TempDir(), "log.sock"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer r.Close()
+
+	c, err := net.Dial("unix", r.Addr().String())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer c.Close()
+
+	sent := &Entry{Seq: 42, Time: time.Unix(1498405744, 0).UTC(), Type: WarningLog, Out: FormattedOut, Msg: []interface{}{"%d apples", 3}}
+	b, err := json.Marshal(sent)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, err = c.Write(append(b, '\n'))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	select {
+	case e := <-received:
+		if e.Type != WarningLog || e.Message() != "3 apples" || !e.Time.Equal(sent.Time) {
+			t.Fatalf("Error, unexpected entry %+v", e)
+		}
+		if e.Seq == sent.Seq {
+			t.Fatal("Error, expected a local sequence number")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error, entry not received")
+	}
+}
+
+func TestListenUnixPath(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "data")
+	if err := ioutil.WriteFile(file, []byte("keep"), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := ListenUnix(file); err == nil {
+		t.Fatal("Error, expected an error for a regular file")
+	}
+	if b, err := ioutil.ReadFile(file); err != nil || string(b) != "keep" {
+		t.Fatalf("Error, regular file removed: %v", err)
+	}
+
+	path := filepath.Join(dir, "log.sock")
+	r, err := ListenUnix(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 || fi.Mode().Perm() != 0600 {
+		t.Fatalf("Error, socket %v: %v", fi, err)
+	}
+	// the socket of a previous run is replaced
+	r2, err := ListenUnix(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_ = r.Close()
+	c, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c.Close()
+	if err = r2.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err = os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("Error, socket not removed: %v", err)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("Error, expected only the regular file, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// RepanicOnRecover makes Recover and RecoverMiddleware panic again with
+// the recovered value after logging it and flushing the adapters, so the
+// panic still stops the goroutine or the request as without them.
+var RepanicOnRecover bool
+
+// Recover logs the panic of the goroutine, if any, as an error with the
+// stack of the panic like Panicked. It must be deferred directly:
+//
+//	defer log.Recover()
+//
+// The caller of the entry is where the panic happened. The goroutine
+// returns normally from the function unless RepanicOnRecover is set.
+func Recover() {
+	if r := recover(); r != nil {
+		std.recovered(nil, r)
+	}
+}
+
+// Recover works like log.Recover logging to the adapters of l
+func (l *Logger) Recover() {
+	if r := recover(); r != nil {
+		l.recovered(nil, r)
+	}
+}
+
+// Recover works like log.Recover adding the fields of l
+func (l *FieldLogger) Recover() {
+	if r := recover(); r != nil {
+		l.logger.recovered(l.fields, r)
+	}
+}
+
+// recovered logs the value recovered from a panic, and panics again with
+// it if RepanicOnRecover is set
+func (l *Logger) recovered(fields []field, r interface{}) *Entry {
+	e := l.panicEntry(fields, r, debug.Stack())
+	if len(e.Stack) > 0 {
+		e.Caller, e.callerFile = frameCaller(e.Stack[0])
+	}
+	dispatch(e)
+	if RepanicOnRecover {
+		_ = l.Flush()
+		panic(r)
+	}
+	return e
+}
+
+// RecoverMiddleware recovers the panics of next, see
+// Logger.RecoverMiddleware.
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return std.RecoverMiddleware(next)
+}
+
+// RecoverMiddleware recovers the panics of the handlers of next, logging
+// them with l like Recover with the method and the path of the request,
+// and responds with the body of HTTPError and the status 500 if the
+// response wasn't started. Inside Middleware, the panic is logged with
+// the fields of the request and the access log gets the reference of the
+// error:
+//
+//	http.ListenAndServe(addr, log.Middleware(log.RecoverMiddleware(mux)))
+//
+// With RepanicOnRecover the panic goes on to the server, which closes the
+// connection. The http.ErrAbortHandler panics, used to abort a response,
+// are not logged.
+func (l *Logger) RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			rw, ok := w.(*responseWriter)
+			log := l.WithFields(Fields{"method": r.Method, "path": r.URL.Path})
+			if ok {
+				log = rw.log
+			}
+			e := log.logger.recovered(log.fields, rec)
+			if ok {
+				rw.ref = e.Ref
+				if rw.status != 0 {
+					// the response was started
+					return
+				}
+			}
+			writeHTTPError(w, http.StatusInternalServerError, "", e)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
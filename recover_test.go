@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	func() {
+		defer Recover()
+		panic("boom")
+	}()
+	if len(entries) != 1 {
+		t.Fatalf("Error, got %d entries, expected 1", len(entries))
+	}
+	e := entries[0]
+	if e.Type != ErrorLog || e.Message() != "panic: boom" || e.Caller != "recover_test.go:23" {
+		t.Fatalf("Error, got %v %q at %s", e.Type, e.Message(), e.Caller)
+	}
+	if len(e.Stack) == 0 || !strings.Contains(e.Stack[0].Function, "TestRecover") {
+		t.Fatalf("Error, expected the stack to start at the panic, got %v", e.Stack)
+	}
+	if out := buf.String(); !strings.Contains(out, "[error] panic: boom") {
+		t.Fatalf("Error, printed %q", out)
+	}
+
+	RepanicOnRecover = true
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer WithField("job", 1).Recover()
+		panic("again")
+	}()
+	if repanicked != "again" || len(entries) != 2 || entries[1].Fields["job"] != 1 {
+		t.Fatalf("Error, recovered %v with %d entries", repanicked, len(entries))
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	ErrorRefGenerator = func() string { return "ref1" }
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	})
+	mux.HandleFunc("/started", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("too late")
+	})
+	h := Middleware(RecoverMiddleware(mux))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/panic", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"reference": "ref1"`) {
+		t.Fatalf("Error, response %d %q", w.Code, w.Body.String())
+	}
+	if len(entries) != 2 || entries[0].Message() != "panic: handler failed" || entries[0].Fields["path"] != "/panic" {
+		t.Fatalf("Error, got %d entries", len(entries))
+	}
+	if entries[1].Fields["status"] != 500 || entries[1].Fields["ref"] != "ref1" {
+		t.Fatalf("Error, the access log has %v", entries[1].Fields)
+	}
+	if out := buf.String(); !strings.Contains(out, "[error] panic: handler failed method=GET path=/panic (ref ref1)") {
+		t.Fatalf("Error, printed %q", out)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/started", nil))
+	if w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Fatalf("Error, response %d %q", w.Code, w.Body.String())
+	}
+
+	RepanicOnRecover = true
+	defer func() {
+		if r := recover(); r != "handler failed" {
+			t.Fatalf("Error, recovered %v", r)
+		}
+	}()
+	RecoverMiddleware(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/panic", nil))
+}
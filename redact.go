@@ -0,0 +1,365 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var (
+	// RedactedFields are the names of the fields whose values are
+	// replaced by RedactMask in every entry, compared ignoring the case,
+	// also as the last element of a dotted name ("db.password") and as
+	// the keys of the fields that are maps, e.g. the Authorization header
+	// of an http.Header, of the payloads of JSON, YAML and XML and of the
+	// values compared by Diff. CommonSecretFields are the usual ones.
+	RedactedFields []string
+
+	// RedactPatterns are the patterns replaced by RedactMask in the
+	// messages, the errors, the string fields, the payloads of JSON, YAML
+	// and XML and the values compared by Diff of every entry. Only the
+	// first group is replaced in the patterns with groups, so
+	// `password=(\S+)` keeps "password=". CommonSecretPatterns are the
+	// usual ones.
+	RedactPatterns []*regexp.Regexp
+
+	// RedactMask replaces the redacted values
+	RedactMask = "***"
+)
+
+// CommonSecretFields are the names of the fields that usually have
+// secrets
+var CommonSecretFields = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token",
+	"api_key", "apikey", "authorization", "cookie", "set-cookie",
+}
+
+// CommonSecretPatterns match the secrets usually found in messages: the
+// bearer and basic credentials of the Authorization header and the
+// password, secret and token parameters
+var CommonSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9._~+/=-]+)`),
+	regexp.MustCompile(`(?i)\b(?:password|passwd|secret|token|api_?key)"?\s*[=:]\s*"?([^\s"&,;]+)`),
+}
+
+// Redact replaces the matches of RedactPatterns in s by RedactMask
+func Redact(s string) string {
+	for _, re := range RedactPatterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, RedactMask)
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			if m[2] < 0 {
+				continue
+			}
+			b.WriteString(s[last:m[2]])
+			b.WriteString(RedactMask)
+			last = m[3]
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// redactError presents an error, and its causes, with the messages
+// redacted.
+type redactError struct {
+	err error
+}
+
+func (e redactError) Error() string {
+	return Redact(e.err.Error())
+}
+
+func (e redactError) Unwrap() []error {
+	c := causes(e.err)
+	for i := range c {
+		c[i] = redactError{c[i]}
+	}
+	return c
+}
+
+// redacting reports if RedactedFields or RedactPatterns are set
+func redacting() bool {
+	return len(RedactedFields) > 0 || len(RedactPatterns) > 0
+}
+
+// redact masks the secrets of the entry. It runs when the entry is
+// created and again after the hooks, so the fields they add and the
+// entries received from other processes are masked too.
+func (e *Entry) redact() {
+	if len(RedactPatterns) > 0 {
+		msg := make([]interface{}, len(e.Msg))
+		for i, m := range e.Msg {
+			switch v := m.(type) {
+			case string:
+				if i == 0 && e.Out == FormattedOut {
+					// the verbs of the format are kept, the secrets
+					// of the format are redacted below
+					msg[i] = v
+					break
+				}
+				msg[i] = Redact(v)
+			case redactError:
+				msg[i] = v
+			case error:
+				msg[i] = redactError{v}
+			default:
+				if s := fmt.Sprint(v); Redact(s) != s {
+					msg[i] = Redact(s)
+				} else {
+					msg[i] = v
+				}
+			}
+		}
+		e.Msg = msg
+		// a secret split between the format and the arguments
+		if s := e.Message(); Redact(s) != s {
+			if e.Out == FormattedOut {
+				e.Msg = []interface{}{"%s", Redact(s)}
+			} else {
+				e.Msg = []interface{}{Redact(s)}
+			}
+		}
+	}
+
+	for k, v := range e.Fields {
+		if isRedactedField(k) {
+			e.Fields[k] = RedactMask
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			e.Fields[k] = Redact(v)
+		case redactError:
+		case error:
+			e.Fields[k] = redactError{v}
+		case http.Header:
+			e.Fields[k] = redactHeader(v)
+		case map[string]string:
+			m := make(map[string]string, len(v))
+			for mk, mv := range v {
+				if isRedactedField(mk) {
+					mv = RedactMask
+				}
+				m[mk] = Redact(mv)
+			}
+			e.Fields[k] = m
+		case map[string]interface{}:
+			e.Fields[k] = redactValue(v)
+		}
+	}
+}
+
+// keyValueLine matches the lines of the configuration files that set a
+// key, e.g. "password: x", "PASSWORD=x" or `"password": "x",`
+var keyValueLine = regexp.MustCompile(`^(\s*"?([\w.-]+)"?\s*[:=]\s*)(.*?)(,?)$`)
+
+// redactText returns the lines of s redacted, with the values of the
+// lines that set a redacted key masked
+func redactText(s string) string {
+	if len(RedactedFields) > 0 {
+		lines := strings.Split(s, "\n")
+		for i, l := range lines {
+			if m := keyValueLine.FindStringSubmatch(l); m != nil && m[3] != "" && isRedactedField(m[2]) {
+				lines[i] = m[1] + RedactMask + m[4]
+			}
+		}
+		s = strings.Join(lines, "\n")
+	}
+	return Redact(s)
+}
+
+// redactValue returns v, as decoded from JSON, with the values of the
+// redacted keys masked and the strings redacted
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, mv := range v {
+			if isRedactedField(k) {
+				m[k] = RedactMask
+			} else {
+				m[k] = redactValue(mv)
+			}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, lv := range v {
+			l[i] = redactValue(lv)
+		}
+		return l
+	case string:
+		return Redact(v)
+	}
+	return v
+}
+
+// redactPayload returns the data of a payload of the kind with the
+// secrets masked, keeping the order of the keys
+func redactPayload(kind payloadKind, data []byte) []byte {
+	var out bytes.Buffer
+	var err error
+	if kind == xmlPayload {
+		err = redactXML(data, &out)
+	} else {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = redactJSON(d, &out, false)
+	}
+	if err != nil {
+		// not expected for the values encoded by JSON and XML
+		b, _ := json.Marshal(RedactMask)
+		return b
+	}
+	return out.Bytes()
+}
+
+// redactJSON copies the next value of d to out, the whole value is
+// replaced by RedactMask if mask is set
+func redactJSON(d *json.Decoder, out *bytes.Buffer, mask bool) error {
+	t, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := t.(json.Delim); ok {
+		if mask {
+			for depth := 1; depth > 0; {
+				if t, err = d.Token(); err != nil {
+					return err
+				}
+				switch t {
+				case json.Delim('{'), json.Delim('['):
+					depth++
+				case json.Delim('}'), json.Delim(']'):
+					depth--
+				}
+			}
+			return writeJSON(out, RedactMask)
+		}
+		out.WriteRune(rune(delim))
+		for i := 0; d.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			maskValue := false
+			if delim == '{' {
+				if t, err = d.Token(); err != nil {
+					return err
+				}
+				key, _ := t.(string)
+				if err = writeJSON(out, key); err != nil {
+					return err
+				}
+				out.WriteByte(':')
+				maskValue = isRedactedField(key)
+			}
+			if err = redactJSON(d, out, maskValue); err != nil {
+				return err
+			}
+		}
+		if t, err = d.Token(); err != nil {
+			return err
+		}
+		out.WriteRune(rune(t.(json.Delim)))
+		return nil
+	}
+	switch {
+	case mask:
+		t = RedactMask
+	case t != nil:
+		if s, ok := t.(string); ok {
+			t = Redact(s)
+		}
+	}
+	return writeJSON(out, t)
+}
+
+func writeJSON(out *bytes.Buffer, v interface{}) error {
+	b, err := json.Marshal(v)
+	out.Write(b)
+	return err
+}
+
+// redactXML copies the XML data to out with the text of the redacted
+// elements and the values of the redacted attributes masked
+func redactXML(data []byte, out *bytes.Buffer) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	enc := xml.NewEncoder(out)
+	// masked is the depth of the redacted elements open
+	masked := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch v := t.(type) {
+		case xml.StartElement:
+			v = v.Copy()
+			if masked > 0 || isRedactedField(v.Name.Local) {
+				masked++
+			}
+			for i, a := range v.Attr {
+				if isRedactedField(a.Name.Local) {
+					v.Attr[i].Value = RedactMask
+				} else {
+					v.Attr[i].Value = Redact(a.Value)
+				}
+			}
+			t = v
+		case xml.EndElement:
+			if masked > 0 {
+				masked--
+			}
+		case xml.CharData:
+			if masked > 0 && len(bytes.TrimSpace(v)) > 0 {
+				t = xml.CharData(RedactMask)
+			} else {
+				t = xml.CharData(Redact(string(v)))
+			}
+		default:
+			t = xml.CopyToken(t)
+		}
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+func redactHeader(h http.Header) http.Header {
+	r := make(http.Header, len(h))
+	for k, vs := range h {
+		masked := make([]string, len(vs))
+		for i, v := range vs {
+			if isRedactedField(k) {
+				masked[i] = RedactMask
+			} else {
+				masked[i] = Redact(v)
+			}
+		}
+		r[k] = masked
+	}
+	return r
+}
+
+func isRedactedField(name string) bool {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, f := range RedactedFields {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
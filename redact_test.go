@@ -0,0 +1,164 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RedactedFields = CommonSecretFields
+	RedactPatterns = CommonSecretPatterns
+
+	testCases := []struct {
+		in       string
+		expected string
+	}{
+		{"Authorization: Bearer eyJhbGciOi.x-y", "Authorization: Bearer ***"},
+		{"login password=hunter2 user=bob", "login password=*** user=bob"},
+		{`{"token": "abc123"}`, `{"token": "***"}`},
+		{"GET /?api_key=k1&page=2", "GET /?api_key=***&page=2"},
+		{"nothing secret here", "nothing secret here"},
+	}
+	for _, tc := range testCases {
+		if got := Redact(tc.in); got != tc.expected {
+			t.Errorf("Error, Redact(%q) = %q, expected %q", tc.in, got, tc.expected)
+		}
+	}
+
+	h := http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}, "Accept": {"*/*"}}
+	err := fmt.Errorf("login: %w", errors.New("bad password=hunter2"))
+	f := WithFields(Fields{
+		"Password": "hunter2",
+		"db.token": 42,
+		"headers":  h,
+		"query":    "secret=s3 page=1",
+		"user":     "bob",
+	})
+	e := std.newEntry(f.fields, ErrorLog, FormattedOut, "token=%s %v", "abc", err)
+	msg := e.Message()
+	if strings.Contains(msg, "abc") || strings.Contains(msg, "hunter2") {
+		t.Fatalf("Error, message %q not redacted", msg)
+	}
+	for _, l := range errorLines(e.Out, e.Msg...) {
+		if strings.Contains(l, "hunter2") {
+			t.Fatalf("Error, cause %q not redacted", l)
+		}
+	}
+	if e.Fields["Password"] != RedactMask || e.Fields["db.token"] != RedactMask ||
+		e.Fields["query"] != "secret=*** page=1" || e.Fields["user"] != "bob" {
+		t.Fatalf("Error, fields %v not redacted", e.Fields)
+	}
+	rh := e.Fields["headers"].(http.Header)
+	if rh.Get("Authorization") != RedactMask || rh.Get("Accept") != "*/*" {
+		t.Fatalf("Error, headers %v not redacted", rh)
+	}
+	if h.Get("Authorization") == RedactMask {
+		t.Fatal("Error, the header of the caller changed")
+	}
+
+	var b bytes.Buffer
+	SetOutput(&b)
+	WithFields(Fields{"password": "hunter2"}).Println("Bearer abc.def")
+	if out := b.String(); strings.Contains(out, "hunter2") || strings.Contains(out, "abc.def") {
+		t.Fatalf("Error, output %q not redacted", out)
+	}
+}
+
+func TestRedactPayloads(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RedactedFields = []string{"password", "token"}
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetDebugMode(true)
+
+	type login struct {
+		User     string            `json:"user" xml:"user"`
+		Password string            `json:"password" xml:"password"`
+		Extra    map[string]string `json:"extra" xml:"-"`
+	}
+	v := login{User: "bob", Password: "hunter2", Extra: map[string]string{"token": "abc"}}
+	JSON(MessageLog, "login", v)
+	YAML(MessageLog, "login", v)
+	XML(MessageLog, "login", v)
+	Diff("login", v, login{User: "bob", Password: "new1"})
+	Diff("config", "user=bob\npassword=hunter2", "user=bob\npassword=new1")
+	out := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(b.String(), "")
+	for _, secret := range []string{"hunter2", "abc", "new1"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("Error, %q not redacted:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "user: bob") || !strings.Contains(out, "<user>bob</user>") {
+		t.Fatalf("Error, payloads not written:\n%s", out)
+	}
+
+	b.Reset()
+	OutputFormat = JSONFormatter
+	JSON(MessageLog, "login", v)
+	if out := b.String(); strings.Contains(out, "hunter2") || !strings.Contains(out, `"payload":{"user":"bob","password":"***"`) {
+		t.Fatalf("Error, JSON payload %q", out)
+	}
+}
+
+func TestRedactHooksAndReceived(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RedactedFields = []string{"password"}
+	RedactPatterns = CommonSecretPatterns
+	received := make(chan *Entry, 2)
+	RemoveAdapter("stdout")
+	AddAdapter("capture", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {
+			received <- e
+		},
+	})
+	AddHook(func(e *Entry) error {
+		if e.Type == MessageLog {
+			e.SetField("password", "hunter2")
+			e.SetField("url", "https://x/?token=abc")
+		}
+		return nil
+	})
+	Println("hooked")
+	e := <-received
+	if e.Fields["password"] != RedactMask || e.Fields["url"] != "https://x/?token=***" {
+		t.Fatalf("Error, fields of the hook %v not redacted", e.Fields)
+	}
+
+	r, err := ListenUnix(filepath.Join(t.TempDir(), "log.sock"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer r.Close()
+	c, err := net.Dial("unix", r.Addr().String())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer c.Close()
+	sent := &Entry{Type: WarningLog, Out: LineOut, Msg: []interface{}{"password=hunter2"},
+		Fields: Fields{"password": "hunter2"}, Keys: []string{"password"}}
+	j, _ := json.Marshal(sent)
+	if _, err = c.Write(append(j, '\n')); err != nil {
+		t.Fatal(err.Error())
+	}
+	select {
+	case e := <-received:
+		if strings.Contains(e.Message(), "hunter2") || e.Fields["password"] != RedactMask {
+			t.Fatalf("Error, received entry %q %v not redacted", e.Message(), e.Fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error, entry not received")
+	}
+}
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SARIFTool describes the tool that found the diagnostics in the SARIF
+// logs, GitHub code scanning shows it with the results
+type SARIFTool struct {
+	Name           string
+	Version        string
+	InformationURI string
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver sarifDriver `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId,omitempty"`
+	RuleIndex *int   `json:"ruleIndex,omitempty"`
+	Level     string `json:"level"`
+	Message   struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI       string `json:"uri"`
+			URIBaseID string `json:"uriBaseId,omitempty"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIF writes the collected diagnostics to w as a SARIF 2.1.0 log of
+// the tool, for code scanning services and editors.
+func WriteSARIF(w io.Writer, tool string) error {
+	return WriteSARIFTool(w, SARIFTool{Name: tool})
+}
+
+// WriteSARIFTool works like WriteSARIF describing the tool with its
+// version and URI. The rules of the diagnostics are listed in the driver
+// and the relative paths of the files are relative to %SRCROOT%, the root
+// of the repository for GitHub code scanning. Absolute paths under the
+// working directory are made relative to it.
+func WriteSARIFTool(w io.Writer, tool SARIFTool) error {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver = sarifDriver{
+		Name:           tool.Name,
+		Version:        tool.Version,
+		InformationURI: tool.InformationURI,
+	}
+	diagnostics := Diagnostics()
+
+	rules := make(map[string]int)
+	for _, d := range diagnostics {
+		if d.Rule != "" {
+			rules[d.Rule] = 0
+		}
+	}
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for i, id := range ids {
+		rules[id] = i
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id})
+	}
+
+	wd, _ := os.Getwd()
+	for _, d := range diagnostics {
+		r := sarifResult{RuleID: d.Rule, Level: "warning"}
+		if d.Rule != "" {
+			i := rules[d.Rule]
+			r.RuleIndex = &i
+		}
+		if d.Type == ErrorLog {
+			r.Level = "error"
+		}
+		r.Message.Text = d.Message
+		if d.File != "" {
+			var l sarifLocation
+			l.PhysicalLocation.ArtifactLocation.URI, l.PhysicalLocation.ArtifactLocation.URIBaseID = sarifURI(d.File, wd)
+			if d.Line > 0 {
+				l.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+			}
+			r.Locations = []sarifLocation{l}
+		}
+		run.Results = append(run.Results, r)
+	}
+	b, err := json.MarshalIndent(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// sarifURI returns the URI of the file and its base, %SRCROOT% for the
+// relative paths
+func sarifURI(file, wd string) (string, string) {
+	if filepath.IsAbs(file) {
+		rel, err := filepath.Rel(wd, file)
+		if wd == "" || err != nil || strings.HasPrefix(rel, "..") {
+			return "file://" + filepath.ToSlash(file), ""
+		}
+		file = rel
+	}
+	return filepath.ToSlash(filepath.Clean(file)), "%SRCROOT%"
+}
+
+// WriteSARIFFile writes the collected diagnostics to the file name as a
+// SARIF log, e.g. for the upload-sarif action of GitHub code scanning.
+func WriteSARIFFile(name string, tool SARIFTool) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err = WriteSARIFTool(f, tool); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	SetOutput(ioutil.Discard)
+	CollectDiagnostics(true)
+	defer CollectDiagnostics(false)
+	defer ResetDiagnostics()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	At("b.go", 3, 0).WithField(RuleKey, "types").Errorln("undefined: y")
+	At(filepath.Join(wd, "pkg", "a.go"), 12, 3).WithField(RuleKey, "unused").Warningln("unused variable x")
+	Warningln("no go files in testdata")
+
+	var out bytes.Buffer
+	if err = WriteSARIFTool(&out, SARIFTool{Name: "vet", Version: "1.2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+					Rules   []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex *int   `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI       string `json:"uri"`
+							URIBaseID string `json:"uriBaseId"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err = json.Unmarshal(out.Bytes(), &sarif); err != nil {
+		t.Fatal(err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 {
+		t.Fatalf("Error, SARIF %s", out.String())
+	}
+	d := sarif.Runs[0].Tool.Driver
+	if d.Name != "vet" || d.Version != "1.2.0" || len(d.Rules) != 2 || d.Rules[0].ID != "types" || d.Rules[1].ID != "unused" {
+		t.Fatalf("Error, SARIF driver %+v", d)
+	}
+	r := sarif.Runs[0].Results
+	if len(r) != 3 || len(r[0].Locations) != 0 || r[0].RuleIndex != nil || r[0].Message.Text != "no go files in testdata" {
+		t.Fatalf("Error, SARIF results %s", out.String())
+	}
+	// sorted by file, the absolute path first
+	loc := r[1].Locations[0].PhysicalLocation
+	if r[1].Level != "warning" || *r[1].RuleIndex != 1 || loc.ArtifactLocation.URI != "pkg/a.go" ||
+		loc.ArtifactLocation.URIBaseID != "%SRCROOT%" || loc.Region.StartLine != 12 || loc.Region.StartColumn != 3 {
+		t.Fatalf("Error, SARIF result %+v", r[1])
+	}
+	if r[2].Level != "error" || r[2].RuleID != "types" || *r[2].RuleIndex != 0 {
+		t.Fatalf("Error, SARIF result %+v", r[2])
+	}
+
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "results.sarif")
+	if err = WriteSARIFFile(name, SARIFTool{Name: "vet", Version: "1.2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(name); !bytes.Equal(b, out.Bytes()) {
+		t.Fatalf("Error, wrote %q to the file", b)
+	}
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SelfTestResult is the outcome of the self test of one adapter
+type SelfTestResult struct {
+	Adapter string
+	// Latency is the time spent to check, write and flush the test
+	// message.
+	Latency time.Duration
+	Err     error
+}
+
+// SelfTest validates the config of every adapter and sends a test message
+// through them, so misconfigured adapters are found at startup instead of
+// failing silently later. The results are sorted by adapter name.
+func SelfTest() []SelfTestResult {
+	e := newEntry(MessageLog, LineOut, "log self test")
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(adapters))
+	for name := range adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]SelfTestResult, 0, len(names))
+	for _, name := range names {
+		start := time.Now()
+		err := selfTest(adapters[name], e)
+		results = append(results, SelfTestResult{
+			Adapter: name,
+			Latency: time.Since(start),
+			Err:     err,
+		})
+	}
+	return results
+}
+
+func selfTest(a AdapterPod, e *Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("adapter panic: %v", r)
+		}
+	}()
+	if a.Adapter == nil && a.Write == nil {
+		return fmt.Errorf("adapter not registered")
+	}
+	if a.Check != nil {
+		if err = a.Check(a.Config); err != nil {
+			return err
+		}
+	}
+	if a.Write != nil {
+		if err = a.Write(e, a.Config); err != nil {
+			return err
+		}
+	} else {
+		a.Adapter(e, a.Config)
+	}
+	if a.Flush != nil {
+		err = a.Flush(a.Config)
+	}
+	return err
+}
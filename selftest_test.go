@@ -0,0 +1,49 @@
+package log
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	RemoveAdapter("stdout")
+	AddAdapter("ok", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {},
+	})
+	AddAdapter("panic", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) { panic("boom") },
+	})
+	AddAdapter("unreachable", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {},
+		Check: func(config map[string]interface{}) error {
+			return errors.New("host unreachable")
+		},
+	})
+	SetAdapterConfig("missing", nil)
+
+	expected := map[string]string{
+		"missing":     "adapter not registered",
+		"ok":          "",
+		"panic":       "adapter panic: boom",
+		"unreachable": "host unreachable",
+	}
+	results := SelfTest()
+	if len(results) != len(expected) {
+		t.Fatalf("Error, expected %d results, got %d", len(expected), len(results))
+	}
+	for i, r := range results {
+		if i > 0 && results[i-1].Adapter > r.Adapter {
+			t.Fatal("Error, results are not sorted by adapter name")
+		}
+		var msg string
+		if r.Err != nil {
+			msg = r.Err.Error()
+		}
+		if msg != expected[r.Adapter] {
+			t.Errorf("Error, adapter %s expected %q, got %q", r.Adapter, expected[r.Adapter], msg)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+)
+
+// Flush waits for the entries of the async mode and the entries queued
+// for the workers and writes the entries of the messages dropped by
+// RateLimit and the entries buffered by the adapters, it returns the
+// first error found.
+func Flush() error {
+	return std.Flush()
+}
+
+// Close flushes and closes the adapters, it returns the first error found.
+func Close() error {
+	return std.Close()
+}
+
+// Flush writes the entries buffered by the adapters of l, it returns the
+// first error found.
+func (l *Logger) Flush() (err error) {
+	flushRepeated()
+	if l == std {
+		waitAsync()
+		waitWorkers()
+	}
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	for _, a := range *l.adapters {
+		if a.Flush == nil {
+			continue
+		}
+		if e := a.Flush(a.Config); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Close flushes and closes the adapters of l, it returns the first error
+// found. The async mode of the default logger is stopped, the entries
+// logged after Close wait for the adapters.
+func (l *Logger) Close() (err error) {
+	if l == std {
+		StopAsync()
+	}
+	err = l.Flush()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	for _, a := range *l.adapters {
+		if a.Close == nil {
+			continue
+		}
+		if e := a.Close(a.Config); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// FlushOnShutdown registers Flush to run when srv.Shutdown is called.
+func FlushOnShutdown(srv *http.Server) {
+	srv.RegisterOnShutdown(func() {
+		_ = Flush()
+	})
+}
+
+// NotifyContext works like signal.NotifyContext and also flushes the
+// adapters when one of the signals arrives or parent is done.
+func NotifyContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, signals...)
+	go func() {
+		<-ctx.Done()
+		_ = Flush()
+	}()
+	return ctx, stop
+}
+
+// Cleaner is implemented by *testing.T, *testing.B and *testing.F
+type Cleaner interface {
+	Cleanup(func())
+}
+
+// CloseOnCleanup closes the adapters when the test that owns c and all
+// its subtests complete.
+func CloseOnCleanup(c Cleaner) {
+	c.Cleanup(func() {
+		_ = Close()
+	})
+}
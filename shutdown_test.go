@@ -0,0 +1,98 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func addFlushCounter(flushes, closes *int32) {
+	AddAdapter("counter", AdapterPod{
+		Adapter: func(e *Entry, config map[string]interface{}) {},
+		Flush: func(config map[string]interface{}) error {
+			atomic.AddInt32(flushes, 1)
+			return nil
+		},
+		Close: func(config map[string]interface{}) error {
+			atomic.AddInt32(closes, 1)
+			return errors.New("closed")
+		},
+	})
+}
+
+func TestFlushAndClose(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var flushes, closes int32
+	addFlushCounter(&flushes, &closes)
+
+	if err := Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := Close(); err == nil || err.Error() != "closed" {
+		t.Fatalf("Error, expected \"closed\", got %v", err)
+	}
+	if flushes != 2 || closes != 1 {
+		t.Fatalf("Error, expected 2 flushes and 1 close, got %d and %d", flushes, closes)
+	}
+}
+
+func TestFlushOnShutdown(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var flushes, closes int32
+	addFlushCounter(&flushes, &closes)
+
+	srv := &http.Server{}
+	FlushOnShutdown(srv)
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	// RegisterOnShutdown functions run in their own goroutine
+	for i := 0; i < 100 && atomic.LoadInt32(&flushes) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&flushes) != 1 {
+		t.Fatalf("Error, expected 1 flush, got %d", flushes)
+	}
+}
+
+func TestNotifyContext(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var flushes, closes int32
+	addFlushCounter(&flushes, &closes)
+
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, stop := NotifyContext(parent)
+	defer stop()
+	cancel()
+	<-ctx.Done()
+	for i := 0; i < 100 && atomic.LoadInt32(&flushes) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&flushes) != 1 {
+		t.Fatalf("Error, expected 1 flush, got %d", flushes)
+	}
+}
+
+func TestCloseOnCleanup(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var flushes, closes int32
+	addFlushCounter(&flushes, &closes)
+
+	t.Run("subtest", func(t *testing.T) {
+		CloseOnCleanup(t)
+	})
+	if closes != 1 {
+		t.Fatalf("Error, expected 1 close, got %d", closes)
+	}
+}
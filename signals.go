@@ -0,0 +1,63 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var signals chan os.Signal
+
+// LogSignals logs a warning for every one of the signals received by the
+// process, e.g. "received signal terminated", with the "signo" number of
+// the signal, the "pid" and the "uptime" of the process, and flushes the adapters so the entry is
+// written even if the signal stops the program. The signals are logged as
+// soon as they arrive, independently of the handlers of the program, so
+// the log shows why it exited or reloaded.
+//
+// Like signal.Notify, it disables the default action of the signals, e.g.
+// the exit on SIGTERM, so only the signals handled by the program should
+// be given, e.g. the ones of NotifyContext. Go doesn't tell the process
+// that sent a signal. Calling it again adds the signals.
+func LogSignals(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		return
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if signals == nil {
+		signals = make(chan os.Signal, 8)
+		go func(c chan os.Signal) {
+			for sig := range c {
+				logSignal(sig)
+			}
+		}(signals)
+	}
+	signal.Notify(signals, sigs...)
+}
+
+// StopLogSignals stops logging the signals, their handling is restored if
+// the program doesn't handle them itself.
+func StopLogSignals() {
+	lock.Lock()
+	defer lock.Unlock()
+	if signals == nil {
+		return
+	}
+	signal.Stop(signals)
+	close(signals)
+	signals = nil
+}
+
+func logSignal(sig os.Signal) {
+	fields := Fields{
+		"pid":    os.Getpid(),
+		"uptime": time.Since(startTime).Round(time.Second),
+	}
+	if s, ok := sig.(syscall.Signal); ok {
+		fields["signo"] = int(s)
+	}
+	WithFields(fields).runAdapters(WarningLog, LineOut, "received signal ", sig)
+	_ = Flush()
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// lineWriter sends the lines written to it
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestLogSignals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals can't be sent to the process")
+	}
+	resetDefaults()
+	defer resetDefaults()
+	out := make(lineWriter, 1)
+	SetOutput(out)
+	got := make(chan *Entry, 1)
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		got <- e
+	}})
+
+	LogSignals(syscall.SIGHUP)
+	defer StopLogSignals()
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err.Error())
+	}
+	select {
+	case e := <-got:
+		if e.Type != WarningLog || e.Message() != "received signal hangup" ||
+			e.Fields["signo"] != int(syscall.SIGHUP) || e.Fields["pid"] != os.Getpid() {
+			t.Fatalf("Error, got %v %q with %v", e.Type, e.Message(), e.Fields)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error, the signal wasn't logged")
+	}
+	select {
+	case line := <-out:
+		if !strings.Contains(line, "[warning] received signal hangup pid=") {
+			t.Fatalf("Error, printed %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error, the signal wasn't printed")
+	}
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Exit codes suggested by Summary
+const (
+	ExitOK      = 0
+	ExitWarning = 1
+	ExitError   = 2
+)
+
+var (
+	warningCount uint64
+	errorCount   uint64
+)
+
+func countMessage(m MsgType) {
+	switch {
+	case m == WarningLog:
+		atomic.AddUint64(&warningCount, 1)
+	case m.IsError():
+		atomic.AddUint64(&errorCount, 1)
+		countError()
+	}
+}
+
+// Counts returns the number of warnings and errors logged so far.
+func Counts() (warnings, errors uint64) {
+	return atomic.LoadUint64(&warningCount), atomic.LoadUint64(&errorCount)
+}
+
+// Summary shows the number of warnings and errors logged since the
+// program started and returns a suggested exit code: ExitError if any
+// error was logged, ExitWarning if only warnings were logged and ExitOK
+// otherwise. It is meant to be called at the end of CLI tools, e.g.
+// os.Exit(log.Summary()).
+func Summary() int {
+	w, e := Counts()
+	Println(fmt.Sprintf("%d warning(s), %d error(s)", w, e))
+	switch {
+	case e > 0:
+		return ExitError
+	case w > 0:
+		return ExitWarning
+	}
+	return ExitOK
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+	atomic.StoreUint64(&warningCount, 0)
+	atomic.StoreUint64(&errorCount, 0)
+
+	var code int
+	summary := func(msg ...interface{}) { code = Summary() }
+
+	out, err := getOutput(summary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expectedValue := "\x1b[37m" + timeFormated + " [msg] 0 warning(s), 0 error(s)\x1b[0;00m\n"
+	if string(out) != expectedValue {
+		t.Fatalf("Error, printed %q, expected %q", string(out), expectedValue)
+	}
+	if code != ExitOK {
+		t.Fatalf("Error, expected exit code %d, got %d", ExitOK, code)
+	}
+
+	_, _ = getOutput(Warningln, "warning")
+	_, _ = getOutput(summary)
+	if code != ExitWarning {
+		t.Fatalf("Error, expected exit code %d, got %d", ExitWarning, code)
+	}
+
+	_, _ = getOutput(Errorln, "error")
+	_, _ = getOutput(Errorf, "error %d", 2)
+	_, _ = getOutput(summary)
+	if code != ExitError {
+		t.Fatalf("Error, expected exit code %d, got %d", ExitError, code)
+	}
+
+	w, e := Counts()
+	if w != 1 || e != 2 {
+		t.Fatalf("Error, expected 1 warning and 2 errors, got %d and %d", w, e)
+	}
+}
@@ -0,0 +1,58 @@
+package log
+
+import "sync"
+
+// TagKey is the field that names the subsystem of the entries logged by
+// the loggers returned by Tagged
+const TagKey = "tag"
+
+var (
+	tagColors     = make(map[string]string)
+	tagColorsLock = sync.RWMutex{}
+)
+
+// Tagged returns a FieldLogger of the default logger that adds the field
+// TagKey to every entry, e.g. log.Tagged("db"), so the output of a
+// subsystem can be told apart, see SetTagColor.
+func Tagged(tag string) *FieldLogger {
+	return WithField(TagKey, tag)
+}
+
+// Tagged returns a FieldLogger of l that adds the field TagKey to every
+// entry
+func (l *Logger) Tagged(tag string) *FieldLogger {
+	return l.WithField(TagKey, tag)
+}
+
+// Tagged returns a child of l that adds the field TagKey to every entry,
+// replacing the tag of l
+func (l *FieldLogger) Tagged(tag string) *FieldLogger {
+	return l.WithField(TagKey, tag)
+}
+
+// SetTagColor sets the ANSI color of the lines of the entries with the
+// tag in TextFormatter, instead of the color of their level, e.g.
+// SetTagColor("db", "\x1b[94m"). An empty color removes the override.
+func SetTagColor(tag, color string) {
+	tagColorsLock.Lock()
+	defer tagColorsLock.Unlock()
+	if color == "" {
+		delete(tagColors, tag)
+		return
+	}
+	tagColors[tag] = color
+}
+
+// color returns the color of the line of e, the color of its tag if one
+// was set with SetTagColor
+func (e *Entry) color() string {
+	if tag, ok := e.Fields[TagKey].(string); ok {
+		tagColorsLock.RLock()
+		c, ok := tagColors[tag]
+		tagColorsLock.RUnlock()
+		if ok {
+			return c
+		}
+	}
+	return levelColor(e.Type)
+}
@@ -0,0 +1,29 @@
+package log
+
+import "testing"
+
+func TestTagColor(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	SetTagColor("db", "\x1b[94m")
+	defer SetTagColor("db", "")
+	timeFormated := now().Format(TimeFormat)
+
+	err := validate("Tagged", Tagged("db").Println,
+		"^\x1b\\[94m"+timeFormated+" \\[msg\\] query tag=db\x1b\\[0;00m\n$", "query")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = validate("Tagged", Tagged("db").Tagged("http").Warningln,
+		"^\x1b\\[93m"+timeFormated+" \\[warning\\] request tag=http\x1b\\[0;00m\n$", "request")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	SetTagColor("db", "")
+	err = validate("Tagged", New().Tagged("db").Println,
+		"^\x1b\\[37m"+timeFormated+" \\[msg\\] query tag=db\x1b\\[0;00m\n$", "query")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
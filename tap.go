@@ -0,0 +1,103 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// tapCount is the number of test points written by TAPFormatter,
+	// tapStarted is set when the version line was written
+	tapCount   int
+	tapStarted bool
+	tapLock    = sync.Mutex{}
+)
+
+var tapEscaper = strings.NewReplacer("\\", "\\\\", "#", "\\#", "\r", " ", "\n", " ")
+
+// TAPFormatter formats the entries as Test Anything Protocol (version 13)
+// for the harnesses of scripted tools: the errors, fatal and panic
+// messages are "not ok" test points and the warnings "ok" test points,
+// both followed by a YAML diagnostics block with the message, the caller,
+// the reference and the fields. The other messages are comments. The
+// first line is preceded by the version line, call EndTAP at the end to
+// write the plan.
+func TAPFormatter(e *Entry) string {
+	var b strings.Builder
+	tapLock.Lock()
+	if !tapStarted {
+		tapStarted = true
+		b.WriteString("TAP version 13\n")
+	}
+	point := e.Type.IsError() || e.Type == WarningLog
+	if point {
+		tapCount++
+	}
+	n := tapCount
+	tapLock.Unlock()
+
+	msg := strings.TrimSuffix(e.Message(), "\n")
+	if !point {
+		for _, l := range strings.Split(msg, "\n") {
+			b.WriteString("# " + l + "\n")
+		}
+		return b.String()
+	}
+
+	status, severity := "ok", "warning"
+	if e.Type.IsError() {
+		status, severity = "not ok", "fail"
+	}
+	fmt.Fprintf(&b, "%s %d - %s\n", status, n, tapEscaper.Replace(msg))
+	b.WriteString("  ---\n")
+	b.WriteString("  message: " + strconv.Quote(msg) + "\n")
+	b.WriteString("  severity: " + severity + "\n")
+	b.WriteString("  level: " + Prefixes[e.Type] + "\n")
+	if e.Caller != "" {
+		b.WriteString("  at: " + strconv.Quote(e.Caller) + "\n")
+	}
+	if e.Ref != "" {
+		b.WriteString("  ref: " + strconv.Quote(e.Ref) + "\n")
+	}
+	if causes := entryCauses(e); len(causes) > 0 {
+		b.WriteString("  causes:\n")
+		for _, c := range causes {
+			b.WriteString("    - " + strconv.Quote(c) + "\n")
+		}
+	}
+	if len(e.Fields) > 0 {
+		keys := make([]string, 0, len(e.Fields))
+		for k := range e.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("  fields:\n")
+		for _, k := range keys {
+			b.WriteString("    " + strconv.Quote(k) + ": " + strconv.Quote(fmt.Sprint(e.Fields[k])) + "\n")
+		}
+	}
+	b.WriteString("  ...\n")
+	return b.String()
+}
+
+// EndTAP writes the plan of the test points written by TAPFormatter to the
+// output of the default logger, see SetOutput, and starts a new TAP
+// stream.
+func EndTAP() error {
+	// the queued entries are formatted before the plan
+	err := Flush()
+	tapLock.Lock()
+	plan := fmt.Sprintf("1..%d\n", tapCount)
+	if !tapStarted {
+		plan = "TAP version 13\n" + plan
+	}
+	tapCount, tapStarted = 0, false
+	tapLock.Unlock()
+	if e := std.out.write(plan, false); err == nil {
+		err = e
+	}
+	return err
+}
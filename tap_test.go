@@ -0,0 +1,58 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTAPFormatter(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	OutputFormat = TAPFormatter
+	ErrorRefGenerator = func() string { return "ref1" }
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	Println("checking # files")
+	WithField("file", "a.txt").Warningln("empty file")
+	Errorln("copy failed: ", fmt.Errorf("write a.txt: %w", errors.New("disk full")))
+	if err := EndTAP(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `TAP version 13
+# checking # files
+ok 1 - empty file
+  ---
+  message: "empty file"
+  severity: warning
+  level: warning
+  at: "tap_test.go:19"
+  fields:
+    "file": "a.txt"
+  ...
+not ok 2 - copy failed: write a.txt: disk full
+  ---
+  message: "copy failed: write a.txt: disk full"
+  severity: fail
+  level: error
+  at: "tap_test.go:20"
+  ref: "ref1"
+  causes:
+    - "disk full"
+  ...
+1..2
+`
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote\n%s\nexpected\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	if err := EndTAP(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "TAP version 13\n1..0\n" {
+		t.Fatalf("Error, wrote %q for an empty stream", buf.String())
+	}
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+)
+
+// lineSizeMargin is the room left at the end of the terminal for the
+// "..." added to truncated lines.
+const lineSizeMargin = 3
+
+var resize chan os.Signal
+
+// AutoMaxLineSize sets MaxLineSize from the width of the terminal attached
+// to stdout and keeps it updated when the terminal is resized (SIGWINCH).
+// It returns false, leaving MaxLineSize untouched, if stdout is not a
+// terminal or the platform does not support the detection.
+func AutoMaxLineSize() bool {
+	if !updateMaxLineSize() {
+		return false
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if resize != nil {
+		return true
+	}
+	resize = make(chan os.Signal, 1)
+	notifyResize(resize)
+	go func(c chan os.Signal) {
+		for range c {
+			updateMaxLineSize()
+		}
+	}(resize)
+	return true
+}
+
+// StopAutoMaxLineSize stops following the terminal width, MaxLineSize
+// keeps the last value detected.
+func StopAutoMaxLineSize() {
+	lock.Lock()
+	defer lock.Unlock()
+	if resize == nil {
+		return
+	}
+	signal.Stop(resize)
+	close(resize)
+	resize = nil
+}
+
+func updateMaxLineSize() bool {
+	width, ok := terminalWidth(os.Stdout.Fd())
+	if !ok || width <= lineSizeMargin {
+		return false
+	}
+	SetMaxLineSize(width - lineSizeMargin)
+	return true
+}
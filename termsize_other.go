@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package log
+
+import "os"
+
+func terminalWidth(fd uintptr) (int, bool) {
+	return 0, false
+}
+
+// isTerminal can't detect the terminals on this platform, the files are
+// assumed to be terminals so the colors are kept
+func isTerminal(fd uintptr) bool {
+	return true
+}
+
+func notifyResize(c chan os.Signal) {}
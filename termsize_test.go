@@ -0,0 +1,30 @@
+package log
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAutoMaxLineSize(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	rescueStdout := os.Stdout
+	defer func() { os.Stdout = rescueStdout }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer r.Close()
+	defer w.Close()
+	os.Stdout = w
+
+	if AutoMaxLineSize() {
+		t.Fatal("Error, expected false for a pipe")
+	}
+	if MaxLineSize != DefaultMaxLineSize {
+		t.Fatalf("Error, expected MaxLineSize %d, got %d", DefaultMaxLineSize, MaxLineSize)
+	}
+	StopAutoMaxLineSize()
+}
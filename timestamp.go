@@ -0,0 +1,52 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TimeMode selects how DefaultAdapter displays the time of messages
+type TimeMode uint8
+
+const (
+	// WallClockTime shows the current time formatted with TimeFormat
+	WallClockTime TimeMode = 0
+	// SinceStartTime shows the time elapsed since the process started
+	SinceStartTime TimeMode = 1
+	// SincePreviousTime shows the time elapsed since the previous message
+	SincePreviousTime TimeMode = 2
+)
+
+var (
+	// TimeDisplay defines the kind of time shown in the console,
+	// default WallClockTime.
+	TimeDisplay = WallClockTime
+
+	startTime    = time.Now()
+	previousTime time.Time
+	timeLock     = sync.Mutex{}
+)
+
+// timestamp returns the time, elapsed time or wall-clock formatted with
+// format, shown in front of a console message logged at t.
+func timestamp(t time.Time, format string) string {
+	switch TimeDisplay {
+	case SinceStartTime:
+		return elapsed(t.Sub(startTime))
+	case SincePreviousTime:
+		timeLock.Lock()
+		prev := previousTime
+		previousTime = t
+		timeLock.Unlock()
+		if prev.IsZero() {
+			prev = startTime
+		}
+		return elapsed(t.Sub(prev))
+	}
+	return t.Format(format)
+}
+
+func elapsed(d time.Duration) string {
+	return fmt.Sprintf("+%10.3fs", d.Seconds())
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeDisplay(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	current := time.Unix(1498405744, 0)
+	now = func() time.Time { return current }
+	startTime = current.Add(-1500 * time.Millisecond)
+	previousTime = time.Time{}
+
+	testCases := []struct {
+		name     string
+		mode     TimeMode
+		step     time.Duration
+		expected string
+	}{
+		{"wall clock", WallClockTime, 0, "\x1b[37m2017/06/25 15:49:04.000 [msg] log test\x1b[0;00m\n"},
+		{"since start", SinceStartTime, 0, "\x1b[37m+     1.500s [msg] log test\x1b[0;00m\n"},
+		{"first since previous", SincePreviousTime, 0, "\x1b[37m+     1.500s [msg] log test\x1b[0;00m\n"},
+		{"since previous", SincePreviousTime, 250 * time.Millisecond, "\x1b[37m+     0.250s [msg] log test\x1b[0;00m\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			current = current.Add(tc.step)
+			TimeDisplay = tc.mode
+			out, err := getOutput(Println, "log test")
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if string(out) != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, string(out))
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package log
+
+import "runtime"
+
+// TraceOnError attaches the chain of callers to the errors, fatal and
+// panic messages logged by this process, so the adapters get it in the
+// Stack of the entries and TextFormatter writes it on indented lines
+// after the message. The frames of the runtime are trimmed and at most
+// MaxStackFrames are kept.
+var TraceOnError bool
+
+// callerStack returns the callers from the function skip frames above
+// the caller of callerStack, plus CallerSkip frames
+func callerStack(skip int) []Frame {
+	pcs := make([]uintptr, MaxStackFrames+8)
+	if MaxStackFrames <= 0 {
+		pcs = make([]uintptr, 64)
+	}
+	n := runtime.Callers(skip+2+CallerSkip, pcs)
+	var stack []Frame
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if f.Function != "runtime.goexit" && f.Function != "runtime.main" {
+			stack = append(stack, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	if MaxStackFrames > 0 && len(stack) > MaxStackFrames {
+		stack = stack[:MaxStackFrames]
+	}
+	return stack
+}
@@ -0,0 +1,39 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTraceOnError(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	Errorln("not traced")
+	TraceOnError = true
+	Warningln("not an error")
+	WithField("job", 1).Errorf("failed %d", 2)
+	if len(entries) != 3 || entries[0].Stack != nil || entries[1].Stack != nil {
+		t.Fatalf("Error, expected only the last entry traced")
+	}
+	e := entries[2]
+	if len(e.Stack) == 0 || !strings.HasSuffix(e.Stack[0].Function, ".TestTraceOnError") ||
+		!strings.HasSuffix(e.Stack[0].File, "trace_test.go") || e.Stack[0].Line != 24 {
+		t.Fatalf("Error, expected the stack to start at the test, got %v", e.Stack)
+	}
+	if e.Message() != "failed 2" {
+		t.Fatalf("Error, the message is %q", e.Message())
+	}
+	if !strings.Contains(buf.String(), "failed 2 job=1\n    at "+e.Stack[0].String()+"\n") {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+}
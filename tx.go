@@ -0,0 +1,125 @@
+package log
+
+import "sync"
+
+// Tx buffers the entries of a logical operation, so they are written
+// together when it is committed, without the entries of other goroutines
+// between them, or dropped when it is discarded, e.g. the noisy messages
+// of best-effort operations that succeeded.
+type Tx struct {
+	logger *Logger
+	fields []field
+
+	lock    sync.Mutex
+	entries []*Entry
+	done    bool
+}
+
+// txLock is held for writing while a transaction is committed and for
+// reading while the entries outside transactions are sent
+var txLock sync.RWMutex
+
+// BeginTx returns a transaction of the default logger, its entries are
+// written by Commit or dropped by Discard.
+func BeginTx() *Tx {
+	return std.BeginTx()
+}
+
+// BeginTx returns a transaction of l
+func (l *Logger) BeginTx() *Tx {
+	return &Tx{logger: l}
+}
+
+// BeginTx returns a transaction of l adding the fields of l to its
+// entries
+func (l *FieldLogger) BeginTx() *Tx {
+	return &Tx{logger: l.logger, fields: l.fields}
+}
+
+// add buffers e, it reports false if the transaction is done
+func (tx *Tx) add(e *Entry) bool {
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+	if tx.done {
+		return false
+	}
+	tx.entries = append(tx.entries, e)
+	return true
+}
+
+// end marks tx as done and returns its entries
+func (tx *Tx) end() []*Entry {
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+	tx.done = true
+	entries := tx.entries
+	tx.entries = nil
+	return entries
+}
+
+// Commit writes the entries of tx in the order they were logged. The
+// entries logged after Commit or Discard are written directly. The
+// adapters must not log while a transaction is committed.
+func (tx *Tx) Commit() {
+	entries := tx.end()
+	if len(entries) == 0 {
+		return
+	}
+	txLock.Lock()
+	defer txLock.Unlock()
+	for _, e := range entries {
+		countMessage(e.Type)
+		send(e)
+	}
+}
+
+// Discard drops the entries of tx, they are not counted by Summary
+func (tx *Tx) Discard() {
+	tx.end()
+}
+
+func (tx *Tx) runAdapters(m MsgType, o OutType, msg ...interface{}) {
+	e := tx.logger.newEntry(tx.fields, m, o, msg...)
+	e.tx = tx
+	dispatch(e)
+}
+
+// Errorln works like log.Errorln buffering the entry in tx
+func (tx *Tx) Errorln(msg ...interface{}) {
+	tx.runAdapters(ErrorLog, LineOut, msg...)
+}
+
+// Errorf works like log.Errorf buffering the entry in tx
+func (tx *Tx) Errorf(msg ...interface{}) {
+	tx.runAdapters(ErrorLog, FormattedOut, msg...)
+}
+
+// Warningln works like log.Warningln buffering the entry in tx
+func (tx *Tx) Warningln(msg ...interface{}) {
+	tx.runAdapters(WarningLog, LineOut, msg...)
+}
+
+// Warningf works like log.Warningf buffering the entry in tx
+func (tx *Tx) Warningf(msg ...interface{}) {
+	tx.runAdapters(WarningLog, FormattedOut, msg...)
+}
+
+// Println works like log.Println buffering the entry in tx
+func (tx *Tx) Println(msg ...interface{}) {
+	tx.runAdapters(MessageLog, LineOut, msg...)
+}
+
+// Printf works like log.Printf buffering the entry in tx
+func (tx *Tx) Printf(msg ...interface{}) {
+	tx.runAdapters(MessageLog, FormattedOut, msg...)
+}
+
+// Debugln works like log.Debugln buffering the entry in tx
+func (tx *Tx) Debugln(msg ...interface{}) {
+	tx.runAdapters(DebugLog, LineOut, msg...)
+}
+
+// Debugf works like log.Debugf buffering the entry in tx
+func (tx *Tx) Debugf(msg ...interface{}) {
+	tx.runAdapters(DebugLog, FormattedOut, msg...)
+}
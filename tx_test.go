@@ -0,0 +1,85 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTx(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	warnings, errs := Counts()
+
+	tx := WithField("job", 3).BeginTx()
+	tx.Println("downloading")
+	tx.Warningln("retrying")
+	Println("other")
+	tx.Commit()
+	tx.Println("late")
+
+	discarded := BeginTx()
+	discarded.Errorln("best effort failed")
+	discarded.Discard()
+
+	ts := now().Format(TimeFormat)
+	expected := ts + " [msg] other\n" +
+		ts + " [msg] downloading job=3\n" +
+		ts + " [warning] retrying job=3\n" +
+		ts + " [msg] late job=3\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+	if w, e := Counts(); w != warnings+1 || e != errs {
+		t.Fatalf("Error, counted %d warnings and %d errors, expected %d and %d", w-warnings, e-errs, 1, 0)
+	}
+}
+
+func TestTxContiguous(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				tx := BeginTx()
+				for j := 0; j < 5; j++ {
+					tx.Println(fmt.Sprintf("tx%d-%d", g, i))
+				}
+				Println("direct")
+				tx.Commit()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4*20*6 {
+		t.Fatalf("Error, wrote %d lines", len(lines))
+	}
+	msg := regexp.MustCompile(`\S+$`)
+	for i := 0; i < len(lines); i++ {
+		m := msg.FindString(lines[i])
+		if m == "direct" {
+			continue
+		}
+		for j := 1; j < 5; j++ {
+			if got := msg.FindString(lines[i+j]); got != m {
+				t.Fatalf("Error, %q interleaved in the transaction %q", got, m)
+			}
+		}
+		i += 4
+	}
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// umask reads the umask from /proc, that doesn't change it like
+// syscall.Umask does.
+func umask() int {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		l := s.Text()
+		if !strings.HasPrefix(l, "Umask:") {
+			continue
+		}
+		u, err := strconv.ParseInt(strings.TrimSpace(l[len("Umask:"):]), 8, 32)
+		if err != nil {
+			return -1
+		}
+		return int(u)
+	}
+	return -1
+}
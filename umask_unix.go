@@ -0,0 +1,20 @@
+//go:build darwin || freebsd || netbsd || openbsd || dragonfly
+// +build darwin freebsd netbsd openbsd dragonfly
+
+package log
+
+import "syscall"
+
+// startUmask is the umask when the package is initialized. Reading it
+// means setting it, so it is read once, before the program creates files
+// in other goroutines, and the changes made later are not reported.
+var startUmask = func() int {
+	u := syscall.Umask(0o22)
+	syscall.Umask(u)
+	return u
+}()
+
+// umask returns the umask of the process when it started
+func umask() int {
+	return startUmask
+}
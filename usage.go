@@ -0,0 +1,43 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+var usageOnce sync.Once
+
+// LogResourceUsageOnExit registers an exit handler that logs the resource
+// footprint of the run: the "uptime", the "cpu_user" and "cpu_system"
+// time, the peak resident set size "max_rss" in bytes, the "gc_count"
+// and the total "gc_pause" of the garbage collector. The CPU time and the
+// peak RSS are only reported on the Unix systems. Calling it again does
+// nothing.
+func LogResourceUsageOnExit() {
+	usageOnce.Do(func() {
+		AddExitHandler(logResourceUsage)
+	})
+}
+
+func logResourceUsage() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	fields := Fields{
+		"uptime":   time.Since(startTime).Round(time.Millisecond),
+		"gc_count": m.NumGC,
+		"gc_pause": time.Duration(m.PauseTotalNs),
+	}
+	if u, ok := processUsage(); ok {
+		fields["cpu_user"] = u.user
+		fields["cpu_system"] = u.system
+		fields["max_rss"] = u.maxRSS
+	}
+	WithFields(fields).runAdapters(MessageLog, LineOut, "resource usage")
+}
+
+// usage of the resources by the process
+type usage struct {
+	user, system time.Duration
+	maxRSS       int64
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogResourceUsage(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	var entries []*Entry
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		entries = append(entries, e)
+	}})
+
+	runtime.GC()
+	logResourceUsage()
+	if len(entries) != 1 || entries[0].Message() != "resource usage" {
+		t.Fatalf("Error, got %d entries", len(entries))
+	}
+	if out := buf.String(); !strings.Contains(out, "[msg] resource usage ") || !strings.Contains(out, " gc_count=") {
+		t.Fatalf("Error, printed %q", out)
+	}
+	f := entries[0].Fields
+	if n, _ := f["gc_count"].(uint32); n == 0 {
+		t.Fatalf("Error, got %v", f)
+	}
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		if rss, _ := f["max_rss"].(int64); rss < 1<<20 {
+			t.Fatalf("Error, max_rss %v", f["max_rss"])
+		}
+		if _, ok := f["cpu_user"].(time.Duration); !ok {
+			t.Fatalf("Error, cpu_user %v", f["cpu_user"])
+		}
+	}
+}
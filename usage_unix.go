@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package log
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+func processUsage() (usage, bool) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return usage{}, false
+	}
+	u := usage{
+		user:   time.Duration(ru.Utime.Nano()),
+		system: time.Duration(ru.Stime.Nano()),
+		maxRSS: int64(ru.Maxrss),
+	}
+	// macOS reports bytes, the others kilobytes
+	if runtime.GOOS != "darwin" {
+		u.maxRSS *= 1024
+	}
+	return u, true
+}
@@ -0,0 +1,27 @@
+package log
+
+import "context"
+
+type debugKey struct{}
+
+// WithDebug returns a copy of ctx that enables the debug messages logged
+// with it by the *Ctx functions, even if DebugMode is disabled. As the
+// context is passed to the goroutines started to handle a request, it
+// raises the verbosity of the whole request, e.g. when a debug header is
+// present.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// IsDebug checks if ctx was created by WithDebug
+func IsDebug(ctx context.Context) bool {
+	v, _ := ctx.Value(debugKey{}).(bool)
+	return v
+}
+
+// DebugEnabled checks if the debug messages and info of the entry should
+// be shown, either because of the debug mode of its logger or because it
+// was logged with a context created by WithDebug.
+func (e *Entry) DebugEnabled() bool {
+	return e.logger.isDebug() || e.Verbose
+}
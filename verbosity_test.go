@@ -0,0 +1,41 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestWithDebug(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	timeFormated := now().Format(TimeFormat)
+
+	ctx := WithDebug(context.Background())
+	if !IsDebug(ctx) || IsDebug(context.Background()) {
+		t.Fatal("Error, IsDebug doesn't match WithDebug")
+	}
+
+	out, err := getOutput(func(msg ...interface{}) { DebuglnCtx(context.Background(), msg...) }, "hidden")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(out) != 0 {
+		t.Fatalf("Error, printed %q, expected nothing", string(out))
+	}
+
+	// the context is inherited by the goroutines started with it
+	worker := func(msg ...interface{}) {
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			DebuglnCtx(ctx, msg...)
+		}(ctx)
+		wg.Wait()
+	}
+	err = validate("DebuglnCtx", worker, "\x1b\\[96m"+timeFormated+" \\[debug\\] \\S+:\\d+ shown\x1b\\[0;00m\n", "shown")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// WatchdogTimer logs an error when a component doesn't report progress
+// with Kick in time, see Watchdog
+type WatchdogTimer struct {
+	name    string
+	timeout time.Duration
+	logger  *Logger
+	fields  []field
+	// caller and callerFile are of the call to Watchdog
+	caller, callerFile string
+
+	lock    sync.Mutex
+	timer   *time.Timer
+	last    time.Time
+	stalled bool
+	stopped bool
+	onStall func(name string, idle time.Duration)
+}
+
+// Watchdog returns a watchdog of the component name of the default
+// logger, that logs an error if Kick is not called for timeout, e.g. to
+// detect stuck workers:
+//
+//	w := log.Watchdog("consumer", 5*time.Minute)
+//	defer w.Stop()
+//	for msg := range messages {
+//		handle(msg)
+//		w.Kick()
+//	}
+//
+// The error is logged once, the first Kick after it logs the recovery.
+func Watchdog(name string, timeout time.Duration) *WatchdogTimer {
+	return std.watchdog(nil, name, timeout)
+}
+
+// Watchdog works like log.Watchdog logging to the adapters of l
+func (l *Logger) Watchdog(name string, timeout time.Duration) *WatchdogTimer {
+	return l.watchdog(nil, name, timeout)
+}
+
+// Watchdog works like log.Watchdog adding the fields of l to the entries
+func (l *FieldLogger) Watchdog(name string, timeout time.Duration) *WatchdogTimer {
+	return l.logger.watchdog(l.fields, name, timeout)
+}
+
+func (l *Logger) watchdog(fields []field, name string, timeout time.Duration) *WatchdogTimer {
+	w := &WatchdogTimer{
+		name:    name,
+		timeout: timeout,
+		logger:  l,
+		fields:  append(fields[:len(fields):len(fields)], field{key: "watchdog", value: name}),
+		last:    time.Now(),
+	}
+	w.caller, w.callerFile = caller(2)
+	w.timer = time.AfterFunc(timeout, w.expire)
+	return w
+}
+
+// OnStall sets f to be called with the name of the component and the
+// time since the last Kick after the error is logged, e.g. to restart
+// the component. It returns w.
+func (w *WatchdogTimer) OnStall(f func(name string, idle time.Duration)) *WatchdogTimer {
+	w.lock.Lock()
+	w.onStall = f
+	w.lock.Unlock()
+	return w
+}
+
+// Kick reports the progress of the component, restarting the timeout
+func (w *WatchdogTimer) Kick() {
+	w.lock.Lock()
+	if w.stopped {
+		w.lock.Unlock()
+		return
+	}
+	t := time.Now()
+	idle := t.Sub(w.last)
+	w.last = t
+	stalled := w.stalled
+	w.stalled = false
+	w.timer.Reset(w.timeout)
+	w.lock.Unlock()
+	if stalled {
+		w.log(MessageLog, w.name+" recovered after "+idle.Round(time.Millisecond).String())
+	}
+}
+
+// Stop stops the watchdog, e.g. when the component ends
+func (w *WatchdogTimer) Stop() {
+	w.lock.Lock()
+	w.stopped = true
+	w.timer.Stop()
+	w.lock.Unlock()
+}
+
+// expire logs the error of the component stalled
+func (w *WatchdogTimer) expire() {
+	w.lock.Lock()
+	if w.stopped || w.stalled {
+		w.lock.Unlock()
+		return
+	}
+	idle := time.Since(w.last)
+	if idle < w.timeout {
+		// kicked while expiring
+		w.lock.Unlock()
+		return
+	}
+	w.stalled = true
+	onStall := w.onStall
+	w.lock.Unlock()
+	w.log(ErrorLog, w.name+" stalled, no progress for "+idle.Round(time.Millisecond).String())
+	if onStall != nil {
+		onStall(w.name, idle)
+	}
+}
+
+func (w *WatchdogTimer) log(m MsgType, msg string) {
+	e := w.logger.newEntry(w.fields, m, LineOut, msg)
+	e.Caller, e.callerFile = w.caller, w.callerFile
+	dispatch(e)
+}
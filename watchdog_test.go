@@ -0,0 +1,86 @@
+package log
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+
+	var (
+		mu      sync.Mutex
+		entries []*Entry
+	)
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		mu.Lock()
+		entries = append(entries, e)
+		mu.Unlock()
+	}})
+	RemoveAdapter("stdout")
+
+	stalled := make(chan time.Duration, 1)
+	w := WithField("queue", "orders").Watchdog("consumer", 20*time.Millisecond).OnStall(func(name string, idle time.Duration) {
+		if name == "consumer" {
+			stalled <- idle
+		}
+	})
+	defer w.Stop()
+
+	select {
+	case idle := <-stalled:
+		if idle < 20*time.Millisecond {
+			t.Fatalf("Error, stalled after %v", idle)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error, watchdog didn't expire")
+	}
+	w.Kick()
+	w.Stop()
+	w.Kick()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(entries) != 2 {
+		t.Fatalf("Error, expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Type != ErrorLog || !regexp.MustCompile(`^consumer stalled, no progress for \S+$`).MatchString(e.Message()) ||
+		e.Fields["watchdog"] != "consumer" || e.Fields["queue"] != "orders" {
+		t.Fatalf("Error, logged %v %q %v", e.Type, e.Message(), e.Fields)
+	}
+	if !regexp.MustCompile(`^watchdog_test.go:\d+$`).MatchString(e.Caller) {
+		t.Fatalf("Error, caller %q", e.Caller)
+	}
+	if e = entries[1]; e.Type != MessageLog || !regexp.MustCompile(`^consumer recovered after \S+$`).MatchString(e.Message()) {
+		t.Fatalf("Error, logged %v %q", e.Type, e.Message())
+	}
+}
+
+func TestWatchdogKick(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	var mu sync.Mutex
+	n := 0
+	AddAdapter("capture", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		mu.Lock()
+		n++
+		mu.Unlock()
+	}})
+	RemoveAdapter("stdout")
+
+	w := Watchdog("worker", 200*time.Millisecond)
+	for i := 0; i < 10; i++ {
+		time.Sleep(20 * time.Millisecond)
+		w.Kick()
+	}
+	w.Stop()
+	mu.Lock()
+	defer mu.Unlock()
+	if n != 0 {
+		t.Fatalf("Error, %d entries logged by a kicked watchdog", n)
+	}
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// queued is an entry waiting in the queue of an adapter
+type queued struct {
+	e *Entry
+	a AdapterPod
+	n int
+}
+
+type adapterQueue struct {
+	name    string
+	entries chan queued
+	// urgent is the lane of the error entries, drained first
+	urgent chan queued
+	// scheduled is 1 while the queue is waiting for a worker or being
+	// drained by one, so each queue is drained by one worker at a time
+	// and the entries reach the adapter in order.
+	scheduled int32
+}
+
+type workerPool struct {
+	queueSize int
+	queues    map[string]*adapterQueue
+	lock      sync.Mutex
+	ready     chan *adapterQueue
+	workers   sync.WaitGroup
+
+	// pending counts the queued entries, done is signaled when it is
+	// zero. A WaitGroup can't be used, entries are queued while Flush
+	// waits.
+	pending     int
+	pendingLock sync.Mutex
+	done        *sync.Cond
+}
+
+var (
+	// DropOnFullQueue drops the entries below the error level, instead of
+	// blocking the caller, when the queue of an adapter is full. Error
+	// entries have their own queue and are never dropped.
+	DropOnFullQueue bool
+
+	// pool is not nil while the workers are started, guarded by lock
+	pool *workerPool
+
+	dropped uint64
+)
+
+// Dropped returns the number of entries dropped because of
+// DropOnFullQueue, counted once for each adapter.
+func Dropped() uint64 {
+	return atomic.LoadUint64(&dropped)
+}
+
+// StartWorkers makes the adapters run in workers goroutines instead of
+// the goroutine that logs, so a slow adapter doesn't delay the others.
+// Each adapter has a queue of queueSize entries, the entries of an
+// adapter are written in order and the caller blocks only while the queue
+// of some adapter is full. Error entries have a queue of their own,
+// written before the other entries, so they are not delayed by an
+// overload of less important messages. Use at least one worker more than
+// the number of adapters that may be slow. Flush waits for the queued
+// entries.
+func StartWorkers(workers, queueSize int) {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &workerPool{
+		queueSize: queueSize,
+		queues:    make(map[string]*adapterQueue),
+		ready:     make(chan *adapterQueue, workers),
+	}
+	p.done = sync.NewCond(&p.pendingLock)
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+	lock.Lock()
+	old := pool
+	pool = p
+	lock.Unlock()
+	if old != nil {
+		old.stop()
+	}
+}
+
+// StopWorkers waits for the queued entries, stops the workers and makes
+// the adapters run again in the goroutine that logs.
+func StopWorkers() {
+	lock.Lock()
+	p := pool
+	pool = nil
+	lock.Unlock()
+	if p != nil {
+		p.stop()
+	}
+}
+
+// waitWorkers waits for the entries queued when it is called
+func waitWorkers() {
+	lock.RLock()
+	p := pool
+	lock.RUnlock()
+	if p != nil {
+		p.wait()
+	}
+}
+
+func (p *workerPool) wait() {
+	p.pendingLock.Lock()
+	for p.pending > 0 {
+		p.done.Wait()
+	}
+	p.pendingLock.Unlock()
+}
+
+func (p *workerPool) stop() {
+	p.wait()
+	close(p.ready)
+	p.workers.Wait()
+}
+
+// enqueue adds e to the queues of the adapters, called holding lock
+func (p *workerPool) enqueue(e *Entry) {
+	n := 0
+	for _, a := range adapters {
+		if a.accepts(e.Type) {
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	atomic.StoreInt32(&e.pending, int32(n))
+	for name, a := range adapters {
+		if !a.accepts(e.Type) {
+			continue
+		}
+		p.lock.Lock()
+		q, ok := p.queues[name]
+		if !ok {
+			q = &adapterQueue{
+				name:    name,
+				entries: make(chan queued, p.queueSize),
+				urgent:  make(chan queued, p.queueSize),
+			}
+			p.queues[name] = q
+		}
+		p.lock.Unlock()
+
+		p.pendingLock.Lock()
+		p.pending++
+		p.pendingLock.Unlock()
+		it := queued{e: e, a: a, n: n}
+		switch {
+		case e.Type.IsError():
+			q.urgent <- it
+		case DropOnFullQueue:
+			select {
+			case q.entries <- it:
+			default:
+				atomic.AddUint64(&dropped, 1)
+				p.finish(it)
+				continue
+			}
+		default:
+			q.entries <- it
+		}
+		if atomic.CompareAndSwapInt32(&q.scheduled, 0, 1) {
+			p.ready <- q
+		}
+	}
+}
+
+// finish marks it as done
+func (p *workerPool) finish(it queued) {
+	if atomic.AddInt32(&it.e.pending, -1) == 0 {
+		finishEntry(it.e, it.n)
+	}
+	p.pendingLock.Lock()
+	if p.pending--; p.pending == 0 {
+		p.done.Broadcast()
+	}
+	p.pendingLock.Unlock()
+}
+
+// next returns the next entry of q, the urgent ones first
+func (q *adapterQueue) next() (queued, bool) {
+	select {
+	case it := <-q.urgent:
+		return it, true
+	default:
+	}
+	select {
+	case it := <-q.urgent:
+		return it, true
+	case it := <-q.entries:
+		return it, true
+	default:
+	}
+	return queued{}, false
+}
+
+func (p *workerPool) work() {
+	defer p.workers.Done()
+	for q := range p.ready {
+		for {
+			if it, ok := q.next(); ok {
+				if runAdapter(q.name, it.a, it.e) != nil {
+					atomic.AddInt32(&it.e.failures, 1)
+				}
+				p.finish(it)
+				continue
+			}
+			atomic.StoreInt32(&q.scheduled, 0)
+			// an entry may have been queued before scheduled was
+			// cleared, without scheduling the queue
+			if len(q.entries)+len(q.urgent) == 0 || !atomic.CompareAndSwapInt32(&q.scheduled, 0, 1) {
+				break
+			}
+		}
+	}
+}
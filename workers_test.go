@@ -0,0 +1,132 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkers(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+
+	var (
+		mu   sync.Mutex
+		fast []string
+		slow int32
+	)
+	release := make(chan struct{})
+	AddAdapter("fast", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		mu.Lock()
+		fast = append(fast, e.Message())
+		mu.Unlock()
+	}})
+	AddAdapter("slow", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		<-release
+		atomic.AddInt32(&slow, 1)
+	}})
+
+	StartWorkers(2, 10)
+	defer StopWorkers()
+
+	for _, msg := range []string{"a", "b", "c"} {
+		Println(msg)
+	}
+
+	// the fast adapter doesn't wait for the slow one
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(fast)
+		mu.Unlock()
+		if n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Error, fast adapter blocked by the slow one")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&slow) != 0 {
+		t.Fatal("Error, slow adapter not blocked")
+	}
+
+	close(release)
+	if err := Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if atomic.LoadInt32(&slow) != 3 {
+		t.Fatalf("Error, Flush returned with %d entries written by the slow adapter", slow)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fast[0] != "a" || fast[1] != "b" || fast[2] != "c" {
+		t.Fatalf("Error, entries out of order %v", fast)
+	}
+}
+
+func TestWorkersCaller(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	DebugMode = true
+	timeFormated := now().Format(TimeFormat)
+
+	StartWorkers(1, 1)
+	defer StopWorkers()
+	err := validate("Println", func(msg ...interface{}) {
+		Println(msg...)
+		_ = Flush()
+	}, "\x1b\\[37m"+timeFormated+" \\[msg\\] workers_test.go:\\d+ text\x1b\\[0;00m\n", "text")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestWorkersPriority(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	RemoveAdapter("stdout")
+	DropOnFullQueue = true
+	defer func() { DropOnFullQueue = false }()
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	release := make(chan struct{})
+	AddAdapter("slow", AdapterPod{Adapter: func(e *Entry, config map[string]interface{}) {
+		<-release
+		mu.Lock()
+		got = append(got, e.Message())
+		mu.Unlock()
+	}})
+
+	StartWorkers(1, 2)
+	defer StopWorkers()
+
+	before := Dropped()
+	Println("first") // taken by the worker, blocked in the adapter
+	time.Sleep(10 * time.Millisecond)
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		Println(msg)
+	}
+	Errorln("e1")
+	Errorln("e2")
+	close(release)
+	if err := Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if d := Dropped() - before; d != 2 {
+		t.Fatalf("Error, expected 2 dropped entries, got %d", d)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	expected := "first e1 e2 a b"
+	if strings.Join(got, " ") != expected {
+		t.Fatalf("Error, written %q, expected %q", strings.Join(got, " "), expected)
+	}
+}
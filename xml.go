@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// XML logs v encoded as XML with the label as a message of type m, it
+// works like JSON: TextFormatter writes the XML indented on the lines
+// after the label, with the tags colored by JSONKeyColor, and
+// JSONFormatter embeds it as the "payload" string.
+func XML(m MsgType, label string, v interface{}) {
+	std.payload(nil, m, label, xmlPayload, v)
+}
+
+// XML works like log.XML logging to the adapters of l
+func (l *Logger) XML(m MsgType, label string, v interface{}) {
+	l.payload(nil, m, label, xmlPayload, v)
+}
+
+// XML works like log.XML adding the fields of l
+func (l *FieldLogger) XML(m MsgType, label string, v interface{}) {
+	l.logger.payload(l.fields, m, label, xmlPayload, v)
+}
+
+// xmlText returns the XML b indented with the tags passed through key
+func xmlText(b []byte, key func(string) string) string {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	for {
+		t, err := d.Token()
+		if err != nil {
+			break
+		}
+		if err := enc.EncodeToken(xml.CopyToken(t)); err != nil {
+			return string(b)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return string(b)
+	}
+	// the text and the attributes have < and > escaped
+	s := buf.String()
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			break
+		}
+		out.WriteString(s[:start] + key(s[start:start+end+1]))
+		s = s[start+end+1:]
+	}
+	out.WriteString(s)
+	return out.String()
+}
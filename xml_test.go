@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type xmlOrder struct {
+	ID    string   `xml:"id,attr"`
+	Items []string `xml:"item"`
+}
+
+func TestXML(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	v := xmlOrder{ID: "7", Items: []string{"a<b", "c"}}
+	XML(MessageLog, "order", v)
+
+	ts := now().Format(TimeFormat)
+	expected := ts + " [msg] order\n" +
+		ts + ` [msg] <xmlOrder id="7">` + "\n" +
+		ts + " [msg]   <item>a&lt;b</item>\n" +
+		ts + " [msg]   <item>c</item>\n" +
+		ts + " [msg] </xmlOrder>\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	OutputFormat = JSONFormatter
+	XML(MessageLog, "order", v)
+	var line struct {
+		Message string
+		Payload string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err.Error())
+	}
+	if line.Message != "order" || line.Payload != `<xmlOrder id="7"><item>a&lt;b</item><item>c</item></xmlOrder>` {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+
+	buf.Reset()
+	XML(ErrorLog, "bad", map[string]int{})
+	if !strings.Contains(buf.String(), `"message":"bad: xml: unsupported type: map[string]int"`) {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+}
+
+func TestXMLTags(t *testing.T) {
+	s := xmlText([]byte(`<a x="&gt;"><b>1 &gt; 0</b></a>`), func(s string) string { return "[" + s + "]" })
+	expected := "[<a x=\"&gt;\">]\n  [<b>]1 &gt; 0[</b>]\n[</a>]"
+	if s != expected {
+		t.Fatalf("Error, got %q, expected %q", s, expected)
+	}
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// YAML logs v as YAML with the label as a message of type m, it works
+// like JSON: TextFormatter writes the YAML document on the lines after
+// the label and JSONFormatter embeds v as the "payload" object. The value
+// is encoded as by JSON, so the json tags name the keys.
+func YAML(m MsgType, label string, v interface{}) {
+	std.payload(nil, m, label, yamlPayload, v)
+}
+
+// YAML works like log.YAML logging to the adapters of l
+func (l *Logger) YAML(m MsgType, label string, v interface{}) {
+	l.payload(nil, m, label, yamlPayload, v)
+}
+
+// YAML works like log.YAML adding the fields of l
+func (l *FieldLogger) YAML(m MsgType, label string, v interface{}) {
+	l.logger.payload(l.fields, m, label, yamlPayload, v)
+}
+
+// yamlText returns the JSON b as a YAML block with the keys passed
+// through key
+func yamlText(b []byte, key func(string) string) string {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return string(b)
+	}
+	var lines []string
+	writeYAML(&lines, "", v, key)
+	return strings.Join(lines, "\n")
+}
+
+// writeYAML appends the lines of v indented by indent
+func writeYAML(lines *[]string, indent string, v interface{}, key func(string) string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			*lines = append(*lines, indent+"{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prefix := indent + key(yamlScalar(k)) + ":"
+			if isYAMLScalar(v[k]) {
+				*lines = append(*lines, prefix+" "+yamlValue(v[k]))
+				continue
+			}
+			*lines = append(*lines, prefix)
+			writeYAML(lines, indent+"  ", v[k], key)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			*lines = append(*lines, indent+"[]")
+			return
+		}
+		for _, item := range v {
+			if isYAMLScalar(item) {
+				*lines = append(*lines, indent+"- "+yamlValue(item))
+				continue
+			}
+			// the first line of the item follows the dash
+			n := len(*lines)
+			writeYAML(lines, indent+"  ", item, key)
+			(*lines)[n] = indent + "- " + strings.TrimPrefix((*lines)[n], indent+"  ")
+		}
+	default:
+		*lines = append(*lines, indent+yamlValue(v))
+	}
+}
+
+func isYAMLScalar(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
+
+func yamlValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlScalar(v)
+	case map[string]interface{}:
+		return "{}"
+	}
+	return "[]"
+}
+
+// yamlScalar returns s plain if YAML reads it as the same string, double
+// quoted otherwise
+func yamlScalar(s string) string {
+	switch strings.ToLower(s) {
+	case "", "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil ||
+		strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasSuffix(s, ":") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f || r == '\u2028' || r == '\u2029' {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestYAML(t *testing.T) {
+	resetDefaults()
+	defer resetDefaults()
+	EnableANSIColors = false
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	v := map[string]interface{}{
+		"name":  "api",
+		"ports": []int{80, 443},
+		"env":   []map[string]string{{"key": "MODE", "value": "on"}},
+		"empty": map[string]int{},
+	}
+	YAML(MessageLog, "config", v)
+
+	ts := now().Format(TimeFormat)
+	expected := ts + " [msg] config\n" +
+		ts + " [msg] empty: {}\n" +
+		ts + " [msg] env:\n" +
+		ts + " [msg]   - key: MODE\n" +
+		ts + ` [msg]     value: "on"` + "\n" +
+		ts + " [msg] name: api\n" +
+		ts + " [msg] ports:\n" +
+		ts + " [msg]   - 80\n" +
+		ts + " [msg]   - 443\n"
+	if buf.String() != expected {
+		t.Fatalf("Error, wrote %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	OutputFormat = JSONFormatter
+	YAML(MessageLog, "config", v)
+	var line struct {
+		Message string
+		Payload map[string]interface{}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err.Error())
+	}
+	if line.Message != "config" || line.Payload["name"] != "api" {
+		t.Fatalf("Error, wrote %q", buf.String())
+	}
+}
+
+func TestYAMLScalar(t *testing.T) {
+	for s, expected := range map[string]string{
+		"plain":       "plain",
+		"two words":   "two words",
+		"":            `""`,
+		"true":        `"true"`,
+		"Null":        `"Null"`,
+		"12.5":        `"12.5"`,
+		"- item":      `"- item"`,
+		"a: b":        `"a: b"`,
+		" padded":     `" padded"`,
+		"line\nbreak": `"line\nbreak"`,
+	} {
+		if got := yamlScalar(s); got != expected {
+			t.Errorf("Error, %q as %s, expected %s", s, got, expected)
+		}
+	}
+}